@@ -0,0 +1,149 @@
+package vexilla
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/server"
+)
+
+// AdminRole is the access level of an admin API caller
+type AdminRole int
+
+const (
+	// AdminRead allows the endpoints that only report state: stats, lint,
+	// evaluate and listing overrides
+	AdminRead AdminRole = iota
+
+	// AdminWrite also allows invalidating, refreshing and changing overrides
+	AdminWrite
+)
+
+// String returns the role name
+func (r AdminRole) String() string {
+	if r == AdminWrite {
+		return "write"
+	}
+	return "read"
+}
+
+// AdminIdentity is the authenticated caller of an admin endpoint
+type AdminIdentity struct {
+	Name string
+	Role AdminRole
+}
+
+// AdminAuthenticator identifies the caller of an admin request. It returns
+// false when the request carries no credentials it accepts; the next
+// authenticator is then tried.
+type AdminAuthenticator func(r *http.Request) (AdminIdentity, bool)
+
+// AdminAuditEntry records a mutating admin call
+type AdminAuditEntry struct {
+	Time   time.Time
+	Caller AdminIdentity
+	Method string
+	Path   string
+	Query  string
+
+	// Status is the HTTP status of the response
+	Status int
+}
+
+// BearerTokens accepts admin requests with an "Authorization: Bearer
+// <token>" header holding one of the tokens.
+//
+// Example:
+//
+//	vexilla.BearerTokens(map[string]vexilla.AdminIdentity{
+//	    os.Getenv("ADMIN_RO_TOKEN"): {Name: "dashboard", Role: vexilla.AdminRead},
+//	    os.Getenv("ADMIN_RW_TOKEN"): {Name: "oncall", Role: vexilla.AdminWrite},
+//	})
+func BearerTokens(tokens map[string]AdminIdentity) AdminAuthenticator {
+	converted := make(map[string]server.Identity, len(tokens))
+	for token, identity := range tokens {
+		converted[token] = toServerIdentity(identity)
+	}
+	return fromServerAuthenticator(server.BearerTokens(converted))
+}
+
+// SignedRequests accepts admin requests whose X-Admin-Signature header is
+// the hex HMAC-SHA256, optionally prefixed by "sha256=", of
+// "<timestamp>.<method>.<request URI>.<body>" with secret. The timestamp is
+// the X-Admin-Timestamp header, in Unix seconds, and must be within 5
+// minutes of the server clock; each signature is accepted once. Bodies
+// over 1 MiB are rejected.
+//
+// Example (signing a call):
+//
+//	ts := strconv.FormatInt(time.Now().Unix(), 10)
+//	mac := hmac.New(sha256.New, []byte(secret))
+//	mac.Write([]byte(ts + ".POST./admin/refresh?source=eu." + string(body)))
+//	req.Header.Set("X-Admin-Timestamp", ts)
+//	req.Header.Set("X-Admin-Signature", hex.EncodeToString(mac.Sum(nil)))
+func SignedRequests(secret string, identity AdminIdentity) AdminAuthenticator {
+	return fromServerAuthenticator(server.SignedRequests(secret, toServerIdentity(identity)))
+}
+
+// ClientCertificates accepts admin requests over mTLS whose verified client
+// certificate has one of the subject common names. The admin server must
+// verify client certificates: set AdminConfig.TLSConfig with
+// ClientAuth: tls.RequireAndVerifyClientCert, or terminate mTLS in the
+// application server when mounting Client.AdminHandler.
+func ClientCertificates(identities map[string]AdminIdentity) AdminAuthenticator {
+	converted := make(map[string]server.Identity, len(identities))
+	for cn, identity := range identities {
+		converted[cn] = toServerIdentity(identity)
+	}
+	return fromServerAuthenticator(server.ClientCertificates(converted))
+}
+
+// logAdminAudit is the default audit log, writing to slog's default logger
+func logAdminAudit(entry AdminAuditEntry) {
+	slog.Info("vexilla admin call",
+		"caller", entry.Caller.Name,
+		"role", entry.Caller.Role.String(),
+		"method", entry.Method,
+		"path", entry.Path,
+		"query", entry.Query,
+		"status", entry.Status,
+	)
+}
+
+// adminAuthenticators converts the authenticators for the admin server
+func adminAuthenticators(auth []AdminAuthenticator) []server.Authenticator {
+	converted := make([]server.Authenticator, len(auth))
+	for i, a := range auth {
+		converted[i] = func(r *http.Request) (server.Identity, bool) {
+			identity, ok := a(r)
+			return toServerIdentity(identity), ok
+		}
+	}
+	return converted
+}
+
+// adminAuditLogger converts an audit callback for the admin server
+func adminAuditLogger(audit func(AdminAuditEntry)) server.AuditLogger {
+	return func(e server.AuditEntry) {
+		audit(AdminAuditEntry{
+			Time:   e.Time,
+			Caller: AdminIdentity{Name: e.Caller.Name, Role: AdminRole(e.Caller.Role)},
+			Method: e.Method,
+			Path:   e.Path,
+			Query:  e.Query,
+			Status: e.Status,
+		})
+	}
+}
+
+func toServerIdentity(identity AdminIdentity) server.Identity {
+	return server.Identity{Name: identity.Name, Role: server.Role(identity.Role)}
+}
+
+func fromServerAuthenticator(auth server.Authenticator) AdminAuthenticator {
+	return func(r *http.Request) (AdminIdentity, bool) {
+		identity, ok := auth(r)
+		return AdminIdentity{Name: identity.Name, Role: AdminRole(identity.Role)}, ok
+	}
+}
@@ -0,0 +1,153 @@
+package vexilla
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AdminAuth(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	var audit []AdminAuditEntry
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithRefreshInterval(0),
+		WithAdminServer(AdminConfig{
+			DisableServer: true,
+			Auth: []AdminAuthenticator{
+				BearerTokens(map[string]AdminIdentity{"ro": {Name: "dashboard", Role: AdminRead}}),
+				SignedRequests("deploy-secret", AdminIdentity{Name: "deployer", Role: AdminWrite}),
+			},
+			Audit: func(e AdminAuditEntry) { audit = append(audit, e) },
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	handler := client.AdminHandler()
+	serve := func(method, path string, header http.Header) int {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "/admin/stats", nil))
+	assert.Equal(t, http.StatusOK, serve("GET", "/admin/stats", http.Header{"Authorization": {"Bearer ro"}}))
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/admin/invalidate-all", http.Header{"Authorization": {"Bearer ro"}}))
+
+	signed := func(method, uri string) http.Header {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte("deploy-secret"))
+		mac.Write([]byte(ts + "." + method + "." + uri + ".")) // empty body
+		return http.Header{
+			"X-Admin-Timestamp": {ts},
+			"X-Admin-Signature": {hex.EncodeToString(mac.Sum(nil))},
+		}
+	}
+	header := signed("POST", "/admin/invalidate-all")
+	assert.Equal(t, http.StatusOK, serve("POST", "/admin/invalidate-all", header))
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/admin/invalidate-all", header), "signatures are single use")
+
+	require.Len(t, audit, 1)
+	assert.Equal(t, AdminIdentity{Name: "deployer", Role: AdminWrite}, audit[0].Caller)
+	assert.Equal(t, "/admin/invalidate-all", audit[0].Path)
+
+	// Behind a stripped prefix the signature covers the URI as sent
+	handler = http.StripPrefix("/ops", client.AdminHandler())
+	assert.Equal(t, http.StatusOK, serve("POST", "/ops/admin/invalidate-all", signed("POST", "/ops/admin/invalidate-all")))
+}
+
+func TestClient_AdminAuth_ClientCertificates(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	serverCert, _ := selfSignedCert(t, "localhost")
+	clientCert, clientX509 := selfSignedCert(t, "ops-bot")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientX509)
+
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithRefreshInterval(0),
+		WithAdminServer(AdminConfig{
+			Port: 0,
+			Auth: []AdminAuthenticator{ClientCertificates(map[string]AdminIdentity{
+				"ops-bot": {Name: "ops-bot", Role: AdminWrite},
+			})},
+			Audit: func(AdminAuditEntry) {},
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				ClientCAs:    clientCAs,
+				ClientAuth:   tls.VerifyClientCertIfGiven,
+			},
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	url := "https://" + client.AdminAddr().String() + "/admin/refresh"
+	post := func(certs ...tls.Certificate) int {
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			Certificates:       certs,
+			InsecureSkipVerify: true,
+		}}}
+		resp, err := httpClient.Post(url, "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, post(clientCert))
+	assert.Equal(t, http.StatusUnauthorized, post())
+}
+
+// selfSignedCert creates a certificate usable by both TLS servers and clients
+func selfSignedCert(t *testing.T, cn string) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert
+}
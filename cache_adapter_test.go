@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/cache"
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
 	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/server"
 	"github.com/OrlandoBitencourt/vexilla/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,6 +107,54 @@ func TestCacheAdapter_RefreshFlags(t *testing.T) {
 	_ = err
 }
 
+func TestCacheAdapter_ExplainFlag(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(domain.Flag{
+		ID:      1,
+		Key:     "beta",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             1,
+				RolloutPercent: 100,
+				Constraints:    []domain.Constraint{{Property: "country", Operator: domain.OperatorEQ, Value: "BR"}},
+				Distributions:  []domain.Distribution{{VariantID: 1, Percent: 100}},
+			},
+		},
+		Variants: []domain.Variant{{ID: 1, Key: "on"}},
+	})
+
+	c, err := cache.New(
+		cache.WithFlagrClient(mockFlagr),
+		cache.WithStorage(storage.NewMockStorage()),
+		cache.WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(context.Background()))
+	defer c.Stop()
+
+	adapter := &cacheAdapter{cache: c}
+
+	assert.Equal(t, []string{"beta"}, adapter.FlagKeys(""))
+
+	report, err := adapter.ExplainFlag("beta", server.EvaluateRequest{
+		EntityID:   "user-1",
+		Attributes: map[string]interface{}{"country": "BR"},
+	})
+	require.NoError(t, err)
+
+	exp, ok := report.Explanation.(*Explanation)
+	require.True(t, ok)
+	assert.Equal(t, "on", exp.Result.VariantKey)
+	require.Len(t, exp.Segments, 1)
+	assert.True(t, exp.Segments[0].Selected)
+
+	analysis, ok := report.Analysis.(evaluator.FlagAnalysis)
+	require.True(t, ok)
+	assert.Equal(t, domain.StrategyLocal, analysis.Strategy)
+	assert.NotNil(t, report.Performance)
+}
+
 func TestCacheAdapter_ImplementsInterface(t *testing.T) {
 	mockFlagr := flagr.NewMockClient()
 	mockStorage := storage.NewMockStorage()
@@ -125,3 +175,33 @@ func TestCacheAdapter_ImplementsInterface(t *testing.T) {
 	assert.NotNil(t, adapter.InvalidateAll)
 	assert.NotNil(t, adapter.RefreshFlags)
 }
+
+func TestCacheAdapter_Overrides(t *testing.T) {
+	c, err := cache.New(
+		cache.WithFlagrClient(flagr.NewMockClient()),
+		cache.WithStorage(storage.NewMockStorage()),
+		cache.WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	adapter := &cacheAdapter{cache: c}
+
+	spec := server.OverrideSpec{FlagKey: "flag", EntityID: "qa-1", VariantKey: "on"}
+	require.NoError(t, adapter.SetOverride(spec))
+	assert.Error(t, adapter.SetOverride(server.OverrideSpec{FlagKey: "flag"}))
+
+	list := adapter.ListOverrides()
+	require.Len(t, list, 1)
+	assert.Equal(t, "runtime", list[0].Source)
+
+	result, err := c.Evaluate(context.Background(), "flag", domain.NewEvaluationContext("qa-1"))
+	require.NoError(t, err)
+	assert.Equal(t, ReasonOverride, result.EvaluationReason)
+
+	assert.True(t, adapter.RemoveOverride(spec))
+	assert.False(t, adapter.RemoveOverride(spec))
+
+	require.NoError(t, adapter.SetOverride(spec))
+	adapter.ClearOverrides()
+	assert.Empty(t, adapter.ListOverrides())
+}
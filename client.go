@@ -4,24 +4,76 @@ package vexilla
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/cache"
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/filewatch"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/override"
+	"github.com/OrlandoBitencourt/vexilla/internal/server"
 )
 
+// defaultShutdownTimeout is how long Stop waits for in-flight requests of
+// the admin and webhook servers
+const defaultShutdownTimeout = 5 * time.Second
+
 // Client is the main entry point for Vexilla.
 // It provides flag evaluation with intelligent caching and routing.
 type Client struct {
+	// cache is the cache of the default source
 	cache *cache.Cache
 
+	// Every flag source, the default first
+	sources []*source
+
 	// Server configurations
 	webhookEnabled bool
 	webhookPort    int
 	webhookSecret  string
 	adminEnabled   bool
 	adminPort      int
+
+	webhookShutdownTimeout time.Duration
+	adminShutdownTimeout   time.Duration
+
+	webhookPathPrefix string
+	adminPathPrefix   string
+
+	webhookQueueSize int
+	webhookDebounce  time.Duration
+
+	webhookPreviousSecrets  []string
+	webhookTolerance        time.Duration
+	webhookLegacySignatures bool
+
+	adminAuth  []AdminAuthenticator
+	adminAudit func(AdminAuditEntry)
+	adminTLS   *tls.Config
+
+	// Readiness thresholds of Health
+	readiness readiness
+
+	// Servers started by Start (nil when disabled or stopped); the webhook
+	// server also backs WebhookHandler
+	webhookServer *server.WebhookServer
+	adminServer   *server.AdminServer
+	serversMu     sync.Mutex
+
+	// Override file watcher (nil when no override file is configured)
+	overrideWatcher *filewatch.Watcher
+
+	// Flag file watcher (nil when flags come from Flagr)
+	flagFileWatcher *filewatch.Watcher
+
+	// Context attributes services send, checked by Lint
+	knownProperties []string
 }
 
 // New creates a new Vexilla client with the given options.
@@ -43,21 +95,94 @@ func New(opts ...Option) (*Client, error) {
 		}
 	}
 
-	// Build cache with options
-	cacheOpts := cfg.toCacheOptions()
-	c, err := cache.New(cacheOpts...)
-	if err != nil {
-		return nil, err
+	if cfg.flagFile != "" && cfg.flagrEndpoint != "" {
+		return nil, errors.New("flag file and flagr endpoint cannot be used together")
 	}
 
-	return &Client{
-		cache:          c,
+	client := &Client{
 		webhookEnabled: cfg.webhookEnabled,
 		webhookPort:    cfg.webhookPort,
 		webhookSecret:  cfg.webhookSecret,
 		adminEnabled:   cfg.adminEnabled,
 		adminPort:      cfg.adminPort,
-	}, nil
+
+		webhookShutdownTimeout: cfg.webhookShutdownTimeout,
+		adminShutdownTimeout:   cfg.adminShutdownTimeout,
+
+		webhookPathPrefix: cfg.webhookPathPrefix,
+		adminPathPrefix:   cfg.adminPathPrefix,
+
+		webhookQueueSize: cfg.webhookQueueSize,
+		webhookDebounce:  cfg.webhookDebounce,
+
+		webhookPreviousSecrets:  cfg.webhookPreviousSecrets,
+		webhookTolerance:        cfg.webhookTolerance,
+		webhookLegacySignatures: cfg.webhookLegacySignatures,
+
+		adminAuth:  cfg.adminAuth,
+		adminAudit: cfg.adminAudit,
+		adminTLS:   cfg.adminTLS,
+
+		readiness: cfg.readiness,
+
+		knownProperties: cfg.knownProperties,
+	}
+
+	// Build a cache per source. The client options configure the default
+	// source, unless only WithSource is used.
+	if cfg.flagrClient != nil || cfg.flagFile != "" || cfg.flagrEndpoint != "" || len(cfg.sources) == 0 {
+		c, err := cache.New(cfg.toCacheOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		client.sources = append(client.sources, &source{name: defaultSource, cache: c})
+	}
+
+	for _, src := range cfg.sources {
+		opts := cfg.forSource(src).toCacheOptions()
+		if len(client.sources) > 0 {
+			// Overrides are shared by every source
+			opts = append(opts, cache.WithOverrides(client.sources[0].cache.Overrides()))
+		}
+		c, err := cache.New(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", src.Name, err)
+		}
+		client.sources = append(client.sources, &source{name: src.Name, prefix: src.Prefix, cache: c})
+	}
+
+	client.cache = client.sources[0].cache
+	c := client.cache
+
+	if cfg.overrideFile != "" {
+		client.overrideWatcher = override.NewFileWatcher(c.Overrides(), cfg.overrideFile, cfg.overridePollInterval)
+		client.overrideWatcher.OnError = func(err error) {
+			// Report the error but keep the previous overrides
+			cfg.reportError(fmt.Errorf("override file reload: %w", err))
+		}
+	}
+
+	if cfg.fileSource != nil {
+		source := cfg.fileSource
+		client.flagFileWatcher = filewatch.New(source.Path(), 0, func() error {
+			return client.reloadFlagFile(source)
+		})
+		client.flagFileWatcher.OnError = func(err error) {
+			// Report the error but keep serving the previous flags
+			cfg.reportError(fmt.Errorf("flag file reload: %w", err))
+		}
+	}
+
+	return client, nil
+}
+
+// reloadFlagFile loads the flag file and pushes the change into the cache;
+// the sync drops flags that were removed from the file
+func (c *Client) reloadFlagFile(source *flagr.FileSource) error {
+	if err := source.Load(); err != nil {
+		return err
+	}
+	return c.cache.Sync(context.Background())
 }
 
 // Start initializes the client and begins background processes.
@@ -65,30 +190,48 @@ func New(opts ...Option) (*Client, error) {
 //
 // The initial sync has a timeout (default 10 seconds) configured via WithInitialTimeout().
 // If the sync fails and no disk cache is available, Start returns an error.
+// Flags whose details fail to load don't fail Start: the others are served
+// and the next refresh retries them.
 //
 // After the initial sync completes, background refresh begins automatically
 // based on the configured refresh interval.
 //
 // This must be called before evaluating flags.
 func (c *Client) Start(ctx context.Context) error {
-	err := c.cache.Start(ctx)
-	if err != nil {
-		return err
+	if c.flagFileWatcher != nil {
+		if err := c.flagFileWatcher.Start(ctx); err != nil {
+			return err
+		}
 	}
 
-	if err := c.cache.Sync(ctx); err != nil {
-		return err
+	if c.overrideWatcher != nil {
+		if err := c.overrideWatcher.Start(ctx); err != nil {
+			c.stopWatchers()
+			return err
+		}
 	}
 
-	// Start optional servers
+	for i, s := range c.sources {
+		if err := s.cache.Start(ctx); err != nil {
+			for _, started := range c.sources[:i] {
+				started.cache.Stop()
+			}
+			c.stopWatchers()
+			return sourceError(s, err)
+		}
+	}
+
+	// Start optional servers; a port conflict stops everything started
 	if c.webhookEnabled {
-		if err := c.startWebhookServer(ctx, c.webhookPort, c.webhookSecret); err != nil {
+		if err := c.startWebhookServer(ctx); err != nil {
+			c.Stop()
 			return fmt.Errorf("failed to start webhook server: %w", err)
 		}
 	}
 
 	if c.adminEnabled {
 		if err := c.startAdminServer(ctx, c.adminPort); err != nil {
+			c.Stop()
 			return fmt.Errorf("failed to start admin server: %w", err)
 		}
 	}
@@ -96,16 +239,159 @@ func (c *Client) Start(ctx context.Context) error {
 	return nil
 }
 
+// WebhookHandler returns the webhook endpoint as an http.Handler, to mount
+// on an existing router behind the application's own middleware instead of
+// opening a separate port. It uses the Secret and PathPrefix of
+// WithWebhookInvalidation; set DisableServer there to skip the standalone
+// server.
+//
+// Example:
+//
+//	client, _ := vexilla.New(
+//	    vexilla.WithFlagrEndpoint("http://flagr:18000"),
+//	    vexilla.WithWebhookInvalidation(vexilla.WebhookConfig{
+//	        Secret:        os.Getenv("WEBHOOK_SECRET"),
+//	        PathPrefix:    "/flagr",
+//	        DisableServer: true,
+//	    }),
+//	)
+//	mux.Handle("/flagr/", client.WebhookHandler()) // POST /flagr/webhook
+func (c *Client) WebhookHandler() http.Handler {
+	return c.webhook().Handler()
+}
+
+// webhook returns the webhook server shared by Start and WebhookHandler,
+// so Stop applies the events queued by either
+func (c *Client) webhook() *server.WebhookServer {
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	if c.webhookServer == nil {
+		c.webhookServer = c.newWebhookServer(c.webhookPort, c.webhookSecret)
+	}
+	return c.webhookServer
+}
+
+// AdminHandler returns the admin and health endpoints as an http.Handler,
+// to mount on an existing router behind the application's own auth
+// instead of opening a separate port. It uses the PathPrefix, Auth and
+// Audit of WithAdminServer; set DisableServer there to skip the standalone
+// server.
+//
+// Example:
+//
+//	client, _ := vexilla.New(
+//	    vexilla.WithFlagrEndpoint("http://flagr:18000"),
+//	    vexilla.WithAdminServer(vexilla.AdminConfig{PathPrefix: "/ops", DisableServer: true}),
+//	)
+//	mux.Handle("/ops/", requireAuth(client.AdminHandler())) // /ops/admin/stats, ...
+func (c *Client) AdminHandler() http.Handler {
+	return c.newAdminServer(c.adminPort).Handler()
+}
+
+// WebhookAddr returns the address the webhook server listens on, or nil
+// when it is not running. With port 0 it holds the port that was picked.
+func (c *Client) WebhookAddr() net.Addr {
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	if c.webhookServer == nil {
+		return nil
+	}
+	return c.webhookServer.Addr()
+}
+
+// AdminAddr returns the address the admin server listens on, or nil when
+// it is not running. With port 0 it holds the port that was picked.
+func (c *Client) AdminAddr() net.Addr {
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	if c.adminServer == nil {
+		return nil
+	}
+	return c.adminServer.Addr()
+}
+
+// Sync fetches the flags of every source.
 func (c *Client) Sync(ctx context.Context) error {
 	if c.cache == nil {
 		return errors.New("vexilla client sync - cache not initialized")
 	}
-	return c.cache.Sync(ctx)
+
+	var errs []error
+	for _, s := range c.sources {
+		if err := s.cache.Sync(ctx); err != nil {
+			errs = append(errs, sourceError(s, err))
+		}
+	}
+	return joinErrors(errs)
 }
 
 // Stop gracefully shuts down the client and its background processes.
+// The admin and webhook servers stop accepting connections first and wait
+// for in-flight requests up to their ShutdownTimeout.
 func (c *Client) Stop() error {
-	return c.cache.Stop()
+	var errs []error
+	if err := c.stopServers(); err != nil {
+		errs = append(errs, err)
+	}
+
+	c.stopWatchers()
+
+	for _, s := range c.sources {
+		if err := s.cache.Stop(); err != nil {
+			errs = append(errs, sourceError(s, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// route picks the source of an evaluation and the flag key within it
+func (c *Client) route(flagKey string, evalCtx Context) (*source, string, error) {
+	return route(c.sources, flagKey, evalCtx.Source)
+}
+
+// stopServers shuts down the webhook and admin servers
+func (c *Client) stopServers() error {
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	var errs []error
+	if c.webhookServer != nil {
+		if err := shutdown(c.webhookServer, c.webhookShutdownTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("webhook server: %w", err))
+		}
+		c.webhookServer = nil
+	}
+	if c.adminServer != nil {
+		if err := shutdown(c.adminServer, c.adminShutdownTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("admin server: %w", err))
+		}
+		c.adminServer = nil
+	}
+	return errors.Join(errs...)
+}
+
+// shutdown drains a server for at most timeout (default 5s)
+func shutdown(srv interface{ Shutdown(context.Context) error }, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return srv.Shutdown(ctx)
+}
+
+// stopWatchers stops the flag and override file watchers
+func (c *Client) stopWatchers() {
+	if c.flagFileWatcher != nil {
+		c.flagFileWatcher.Stop()
+	}
+	if c.overrideWatcher != nil {
+		c.overrideWatcher.Stop()
+	}
 }
 
 // Bool evaluates a flag and returns a boolean result.
@@ -118,44 +404,99 @@ func (c *Client) Stop() error {
 //	    Attributes: map[string]any{"country": "BR"},
 //	})
 func (c *Client) Bool(ctx context.Context, flagKey string, evalCtx Context) bool {
-	return c.cache.EvaluateBool(ctx, flagKey, toDomainContext(evalCtx))
+	s, key, err := c.route(flagKey, evalCtx)
+	if err != nil {
+		return false
+	}
+	return s.cache.EvaluateBool(ctx, key, toDomainContext(evalCtx))
 }
 
 // String evaluates a flag and returns a string result.
 // Returns the default value if the flag is not found or evaluation fails.
 func (c *Client) String(ctx context.Context, flagKey string, evalCtx Context, defaultVal string) string {
-	return c.cache.EvaluateString(ctx, flagKey, toDomainContext(evalCtx), defaultVal)
+	s, key, err := c.route(flagKey, evalCtx)
+	if err != nil {
+		return defaultVal
+	}
+	return s.cache.EvaluateString(ctx, key, toDomainContext(evalCtx), defaultVal)
 }
 
 // Int evaluates a flag and returns an integer result.
 // Returns the default value if the flag is not found or evaluation fails.
 func (c *Client) Int(ctx context.Context, flagKey string, evalCtx Context, defaultVal int) int {
-	return c.cache.EvaluateInt(ctx, flagKey, toDomainContext(evalCtx), defaultVal)
+	s, key, err := c.route(flagKey, evalCtx)
+	if err != nil {
+		return defaultVal
+	}
+	return s.cache.EvaluateInt(ctx, key, toDomainContext(evalCtx), defaultVal)
 }
 
 // Evaluate performs a full flag evaluation and returns detailed results.
 // Use this when you need access to variant attachments or evaluation metadata.
 func (c *Client) Evaluate(ctx context.Context, flagKey string, evalCtx Context) (*Result, error) {
-	result, err := c.cache.Evaluate(ctx, flagKey, toDomainContext(evalCtx))
+	s, key, err := c.route(flagKey, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.cache.Evaluate(ctx, key, toDomainContext(evalCtx))
 	if err != nil {
 		return nil, err
 	}
 	return toResult(result), nil
 }
 
+// Explain evaluates a flag and returns a segment-by-segment trace of the
+// decision: every constraint's expected and actual value, which segment was
+// selected, the chosen distribution and why the flag was evaluated locally
+// or remotely. Remote flags are evaluated with Flagr's debug log enabled.
+//
+// When evaluation fails the partial explanation is returned with the error.
+//
+// Example:
+//
+//	exp, err := client.Explain(ctx, "beta-program", vexilla.NewContext("user-123"))
+//	for _, seg := range exp.Segments {
+//	    fmt.Printf("segment %d matched=%v\n", seg.SegmentID, seg.Matched)
+//	}
+func (c *Client) Explain(ctx context.Context, flagKey string, evalCtx Context) (*Explanation, error) {
+	s, key, err := c.route(flagKey, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	trace, err := s.cache.Explain(ctx, key, toDomainContext(evalCtx))
+	if trace == nil {
+		return nil, err
+	}
+	return toExplanation(trace), err
+}
+
 // InvalidateFlag removes a specific flag from the cache.
 // The flag will be re-fetched on the next evaluation or refresh.
+// The source of the flag is picked by the key prefix.
 func (c *Client) InvalidateFlag(ctx context.Context, flagKey string) error {
-	return c.cache.InvalidateFlag(ctx, flagKey)
+	s, key, err := c.route(flagKey, Context{})
+	if err != nil {
+		return err
+	}
+	return s.cache.InvalidateFlag(ctx, key)
 }
 
-// InvalidateAll clears all flags from the cache.
+// InvalidateAll clears all flags from the cache of every source.
 // All flags will be re-fetched on the next evaluation or refresh.
 func (c *Client) InvalidateAll(ctx context.Context) error {
-	return c.cache.InvalidateAll(ctx)
+	var errs []error
+	for _, s := range c.sources {
+		if err := s.cache.InvalidateAll(ctx); err != nil {
+			errs = append(errs, sourceError(s, err))
+		}
+	}
+	return joinErrors(errs)
 }
 
-// Metrics returns current cache performance metrics.
+// Metrics returns current cache performance metrics: those of the default
+// source, and in Sources those of every source.
 func (c *Client) Metrics() Metrics {
 	cacheMetrics := c.cache.GetMetrics()
 	return Metrics{
@@ -167,9 +508,20 @@ func (c *Client) Metrics() Metrics {
 		LastRefresh:      cacheMetrics.LastRefresh,
 		ConsecutiveFails: cacheMetrics.ConsecutiveFails,
 		CircuitOpen:      cacheMetrics.CircuitOpen,
+		Namespaces:       cacheMetrics.Namespaces,
+		Stale:            toStaleMetrics(cacheMetrics),
+		Sources:          c.sourceMetrics(),
 	}
 }
 
+func (c *Client) sourceMetrics() []SourceMetrics {
+	metrics := make([]SourceMetrics, len(c.sources))
+	for i, s := range c.sources {
+		metrics[i] = s.metrics()
+	}
+	return metrics
+}
+
 // Internal conversion helpers
 
 func toDomainContext(ctx Context) domain.EvaluationContext {
@@ -180,6 +532,14 @@ func toDomainContext(ctx Context) domain.EvaluationContext {
 	}
 }
 
+func fromDomainContext(ctx domain.EvaluationContext) Context {
+	return Context{
+		EntityID:   ctx.EntityID,
+		EntityType: ctx.EntityType,
+		Attributes: ctx.Context,
+	}
+}
+
 func toResult(r *domain.EvaluationResult) *Result {
 	return &Result{
 		FlagKey:           r.FlagKey,
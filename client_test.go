@@ -3,11 +3,18 @@ package vexilla
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -359,6 +366,357 @@ func TestClient_MissingFlag(t *testing.T) {
 	assert.False(t, enabled, "Missing flag with fail_closed should return false")
 }
 
+// TestClient_Explain tests the segment-by-segment evaluation trace
+func TestClient_Explain(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	server.AddFlag(domain.Flag{
+		ID:      8,
+		Key:     "beta-program",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             1,
+				Rank:           1,
+				RolloutPercent: 100,
+				Constraints: []domain.Constraint{
+					{Property: "account.region", Operator: domain.OperatorEQ, Value: "sa-east-1"},
+				},
+				Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}},
+			},
+		},
+		Variants: []domain.Variant{
+			{ID: 1, Key: "enabled", Attachment: map[string]json.RawMessage{"enabled": json.RawMessage(`true`)}},
+		},
+	})
+
+	client, err := New(WithFlagrEndpoint(server.URL))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	evalCtx := NewContext("user-explain").WithAttribute("account", map[string]any{"region": "us-east-1"})
+	exp, err := client.Explain(ctx, "beta-program", evalCtx)
+
+	require.NoError(t, err)
+	assert.Equal(t, "local", exp.Strategy)
+	require.Len(t, exp.Segments, 1)
+
+	seg := exp.Segments[0]
+	assert.False(t, seg.Matched)
+	require.Len(t, seg.Constraints, 1)
+	assert.Equal(t, "account.region", seg.Constraints[0].Property)
+	assert.Equal(t, "EQ", seg.Constraints[0].Operator)
+	assert.Equal(t, "sa-east-1", seg.Constraints[0].Expected)
+	assert.Equal(t, "us-east-1", seg.Constraints[0].Actual)
+	assert.False(t, seg.Constraints[0].Passed)
+
+	require.NotNil(t, exp.Result)
+	assert.Equal(t, "no segments matched", exp.Result.EvaluationReason)
+}
+
+func TestClient_Lint(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	variants := []domain.Variant{{ID: 1, Key: "enabled"}, {ID: 2, Key: "disabled"}}
+	server.AddFlag(domain.Flag{
+		ID:       1,
+		Key:      "shadowed",
+		Enabled:  true,
+		Variants: variants,
+		Segments: []domain.Segment{
+			{ID: 1, Rank: 1, RolloutPercent: 100, Distributions: []domain.Distribution{{VariantID: 2, Percent: 100}}},
+			{
+				ID:             2,
+				Rank:           2,
+				RolloutPercent: 100,
+				Constraints:    []domain.Constraint{{Property: "plan", Operator: domain.OperatorEQ, Value: "pro"}},
+				Distributions:  []domain.Distribution{{VariantID: 1, Percent: 100}},
+			},
+		},
+	})
+	server.AddFlag(domain.Flag{ID: 2, Key: "empty", Enabled: true})
+
+	client, err := New(WithFlagrEndpoint(server.URL), WithKnownProperties("country"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	issues := client.Lint(ctx)
+	require.Len(t, issues, 4)
+
+	assert.Equal(t, LintIssue{
+		FlagKey:  "empty",
+		Rule:     "enabled-without-segments",
+		Severity: "warning",
+		Message:  "flag is enabled but has no segments, so it serves no variant",
+	}, issues[0])
+
+	assert.Equal(t, "shadowed", issues[1].FlagKey)
+	assert.Equal(t, int64(2), issues[1].SegmentID)
+	assert.Equal(t, "unknown-property", issues[1].Rule)
+	assert.Equal(t, "shadowed-segment", issues[2].Rule)
+	assert.Equal(t, "unreachable-variant", issues[3].Rule)
+}
+
+// TestClient_Overrides tests forcing results with local overrides
+func TestClient_Overrides(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	server.AddFlag(domain.Flag{
+		ID:      9,
+		Key:     "new-checkout",
+		Enabled: true,
+		Variants: []domain.Variant{
+			{ID: 1, Key: "disabled", Attachment: map[string]json.RawMessage{"enabled": json.RawMessage(`false`)}},
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+overrides:
+  - flag_key: new-checkout
+    match:
+      country: BR
+    variant_key: br-variant
+`), 0o644))
+
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithOverrideFile(path, time.Hour),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	// File override
+	assert.Equal(t, "br-variant", client.String(ctx, "new-checkout", NewContext("u1").WithAttribute("country", "BR"), ""))
+
+	// Runtime override
+	require.NoError(t, client.SetOverride(Override{
+		FlagKey:    "new-checkout",
+		EntityID:   "qa-account-1",
+		VariantKey: "enabled",
+		Attachment: map[string]any{"enabled": true},
+	}))
+	assert.True(t, client.Bool(ctx, "new-checkout", NewContext("qa-account-1")))
+	assert.False(t, client.Bool(ctx, "new-checkout", NewContext("user-2")))
+
+	result, err := client.Evaluate(ctx, "new-checkout", NewContext("qa-account-1"))
+	require.NoError(t, err)
+	assert.Equal(t, ReasonOverride, result.EvaluationReason)
+
+	exp, err := client.Explain(ctx, "new-checkout", NewContext("qa-account-1"))
+	require.NoError(t, err)
+	assert.Contains(t, exp.StrategyReason, "override")
+
+	overrides := client.Overrides()
+	require.Len(t, overrides, 2)
+	assert.Equal(t, "runtime", overrides[0].Source)
+	assert.Equal(t, true, overrides[0].Attachment["enabled"])
+	assert.Equal(t, "file", overrides[1].Source)
+
+	assert.True(t, client.RemoveOverride(Override{FlagKey: "new-checkout", EntityID: "qa-account-1"}))
+	assert.False(t, client.Bool(ctx, "new-checkout", NewContext("qa-account-1")))
+
+	require.NoError(t, client.SetOverride(Override{FlagKey: "new-checkout", VariantKey: "on"}))
+	client.ClearOverrides()
+	assert.Len(t, client.Overrides(), 1)
+
+	assert.Error(t, client.SetOverride(Override{FlagKey: "new-checkout"}))
+}
+
+// TestClient_OverrideFileMissing tests that Start fails on a missing override file
+func TestClient_OverrideFileMissing(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithOverrideFile(filepath.Join(t.TempDir(), "missing.yaml"), 0),
+	)
+	require.NoError(t, err)
+
+	assert.Error(t, client.Start(context.Background()))
+}
+
+// TestClient_OverrideFileReloadError tests that reload errors reach the error handler
+func TestClient_OverrideFileReloadError(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("overrides: []\n"), 0o644))
+
+	errs := make(chan error, 10)
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithOverrideFile(path, 20*time.Millisecond),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	require.NoError(t, os.WriteFile(path, []byte("overrides: [unclosed"), 0o644))
+
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "override file reload")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reload error was not reported")
+	}
+
+	_, err = New(WithErrorHandler(nil))
+	assert.Error(t, err)
+}
+
+// TestClient_FlagFile tests serving flags from a local file without Flagr
+func TestClient_FlagFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+flags:
+  new-checkout:
+    variants:
+      enabled: {enabled: true}
+      disabled: {enabled: false}
+    segments:
+      - constraints:
+          - {property: country, operator: EQ, value: BR}
+        variant: enabled
+    default: disabled
+  ab-test:
+    segments:
+      - distribution: {a: 50, b: 50}
+`), 0o644))
+
+	client, err := New(WithFlagFile(path))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	assert.True(t, client.Bool(ctx, "new-checkout", NewContext("u1").WithAttribute("country", "BR")))
+	assert.False(t, client.Bool(ctx, "new-checkout", NewContext("u1").WithAttribute("country", "US")))
+
+	// Partial distributions are evaluated locally as well
+	variant := client.String(ctx, "ab-test", NewContext("u1"), "")
+	assert.Contains(t, []string{"a", "b"}, variant)
+	assert.Equal(t, variant, client.String(ctx, "ab-test", NewContext("u1"), ""))
+
+	// Changes to the file are picked up without a restart
+	require.NoError(t, os.WriteFile(path, []byte(`
+flags:
+  new-checkout:
+    variants:
+      enabled: {enabled: true}
+    segments:
+      - variant: enabled
+`), 0o644))
+
+	// Flags removed from the file are dropped from the cache
+	assert.Eventually(t, func() bool {
+		return client.Bool(ctx, "new-checkout", NewContext("u1").WithAttribute("country", "US")) &&
+			len(client.cache.FlagKeys()) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+// TestClient_FlagFileReloadError tests that reload errors reach the error handler
+func TestClient_FlagFileReloadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("flags:\n  kill-switch:\n    segments:\n      - variant: \"on\"\n"), 0o644))
+
+	errs := make(chan error, 10)
+	client, err := New(WithFlagFile(path), WithErrorHandler(func(err error) { errs <- err }))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	require.NoError(t, os.WriteFile(path, []byte("flags: [unclosed"), 0o644))
+
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "flag file reload")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reload error was not reported")
+	}
+	assert.Len(t, client.cache.FlagKeys(), 1, "the previous flags are kept")
+}
+
+// TestClient_FlagFileOptions tests flag file configuration errors
+func TestClient_FlagFileOptions(t *testing.T) {
+	_, err := New(WithFlagFile(""))
+	assert.Error(t, err)
+
+	_, err = New(WithFlagFile("flags.yaml"), WithFlagrEndpoint("http://localhost:18000"))
+	assert.Error(t, err)
+
+	client, err := New(WithFlagFile(filepath.Join(t.TempDir(), "missing.yaml")))
+	require.NoError(t, err)
+	assert.Error(t, client.Start(context.Background()))
+}
+
+// TestClient_Namespaces tests namespace-aware loading
+func TestClient_Namespaces(t *testing.T) {
+	tagged := func(id int64, key, namespace string) domain.Flag {
+		flag := themeFlag(id, key)
+		flag.Key = key
+		flag.Tags = []domain.Tag{{Value: namespace}}
+		return flag
+	}
+	srv := flagrtest.NewServer(
+		tagged(1, "new_cart", "checkout"),
+		tagged(2, "search_v2", "search"),
+		tagged(3, "fuzzy_search", "search"),
+		tagged(4, "dark_mode", "frontend"),
+	)
+	defer srv.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithRefreshInterval(0),
+		WithNamespaces("checkout"),
+		WithLazyNamespaces("search"),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	listed := srv.Requests("/api/v1/flags")
+	assert.Equal(t, 0, srv.Requests("/api/v1/flags/{flagID}"), "flag details are preloaded")
+	assert.Equal(t, []string{"checkout"}, client.Metrics().Namespaces)
+
+	evalCtx := NewContext("user-1")
+	assert.Equal(t, "new_cart", client.String(ctx, "new_cart", evalCtx, ""))
+
+	// search_v2 is looked up by key, then its namespace is loaded
+	assert.Equal(t, "search_v2", client.String(ctx, "search_v2", evalCtx, ""))
+	assert.Equal(t, listed+2, srv.Requests("/api/v1/flags"))
+	assert.Equal(t, "fuzzy_search", client.String(ctx, "fuzzy_search", evalCtx, ""))
+	assert.Equal(t, listed+2, srv.Requests("/api/v1/flags"), "the namespace is already loaded")
+	assert.Equal(t, []string{"checkout", "search"}, client.Metrics().Namespaces)
+
+	// Flags outside the namespaces get the fallback strategy
+	result, err := client.Evaluate(ctx, "dark_mode", evalCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "fallback: fail_closed", result.EvaluationReason)
+
+	_, err = New(WithFlagrEndpoint(srv.URL), WithNamespaces("search"), WithLazyNamespaces("search"))
+	assert.Error(t, err)
+}
+
 // TestClient_RemoteEvaluation tests remote strategy
 func TestClient_RemoteEvaluation(t *testing.T) {
 	server := NewMockFlagrServer(t)
@@ -470,6 +828,60 @@ func TestClient_Start_WithWebhookServer(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestClient_Start_ServerFailureStopsCaches tests that a server failing to
+// start doesn't leave the refresh loops running
+func TestClient_Start_ServerFailureStopsCaches(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	var calls atomic.Int64
+	mock := flagr.NewMockClient()
+	mock.GetAllFlagsFunc = func(ctx context.Context) ([]domain.Flag, error) {
+		calls.Add(1)
+		return nil, nil
+	}
+
+	client, err := New(
+		withFlagrClient(mock),
+		WithRefreshInterval(10*time.Millisecond),
+		WithAdminServer(AdminConfig{Port: busy.Addr().(*net.TCPAddr).Port}),
+	)
+	require.NoError(t, err)
+
+	require.Error(t, client.Start(context.Background()))
+
+	after := calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, after, calls.Load(), "the refresh loop was stopped")
+}
+
+// TestClient_Start_PartialFetch tests that a flag whose details fail to
+// load doesn't keep the client from starting with the others
+func TestClient_Start_PartialFetch(t *testing.T) {
+	mock := flagr.NewMockClient()
+	mock.GetAllFlagsFunc = func(ctx context.Context) ([]domain.Flag, error) {
+		return []domain.Flag{{
+				ID: 1, Key: "on-flag", Enabled: true,
+				Segments: []domain.Segment{
+					{RolloutPercent: 100, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}},
+				},
+				Variants: []domain.Variant{{ID: 1, Key: "on"}},
+			}},
+			&flagr.PartialError{Failed: map[int64]error{2: errors.New("HTTP 500")}}
+	}
+
+	client, err := New(withFlagrClient(mock))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	result, err := client.Evaluate(context.Background(), "on-flag", NewContext("user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey)
+}
+
 // TestClient_Start_WithAdminServer tests starting with admin server
 func TestClient_Start_WithAdminServer(t *testing.T) {
 	server := NewMockFlagrServer(t)
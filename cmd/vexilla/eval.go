@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla"
+	"github.com/OrlandoBitencourt/vexilla/internal/clienthooks"
+)
+
+// attributes collects repeated -attr key=value flags. Values that parse
+// as JSON (numbers, booleans, arrays...) keep their type; anything else is
+// a string.
+type attributes map[string]any
+
+func (a attributes) String() string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", key, a[key])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (a attributes) Set(s string) error {
+	key, raw, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+	a[key] = value
+	return nil
+}
+
+// runEval evaluates a flag through the same cache pipeline as services
+func runEval(e *env, args []string) error {
+	fs := newFlagSet(e, "eval", "[flags] <flag-key>",
+		"Evaluates a flag for an entity the way a Vexilla client does: flags are\n"+
+			"cached, deterministic ones are evaluated locally and the others by Flagr\n"+
+			"(or locally, from a snapshot).")
+	src := addSourceFlags(fs)
+	entityID := fs.String("entity", "", "entity ID")
+	entityType := fs.String("entity-type", "user", "entity type")
+	attrs := attributes{}
+	fs.Var(attrs, "attr", "context attribute as key=value (repeatable; JSON values such as 18 or true keep their type)")
+	explain := fs.Bool("explain", false, "show how every segment and constraint was evaluated")
+	asJSON := fs.Bool("json", false, "print JSON")
+	keys, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(keys) != 1 {
+		fs.Usage()
+		return &exitError{code: 2}
+	}
+	flagKey := keys[0]
+
+	flagrClient, err := src.client()
+	if err != nil {
+		return err
+	}
+
+	client, err := vexilla.New(clienthooks.WithFlagrClient(flagrClient).(vexilla.Option))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	if err := client.Start(ctx); err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	evalCtx := vexilla.NewContext(*entityID).WithEntityType(*entityType)
+	for key, value := range attrs {
+		evalCtx = evalCtx.WithAttribute(key, value)
+	}
+
+	if *explain {
+		exp, err := client.Explain(ctx, flagKey, evalCtx)
+		if exp == nil {
+			return err
+		}
+		if *asJSON {
+			if jsonErr := writeJSON(e.stdout, exp); jsonErr != nil {
+				return jsonErr
+			}
+		} else {
+			printExplanation(e.stdout, exp)
+		}
+		return err
+	}
+
+	result, err := client.Evaluate(ctx, flagKey, evalCtx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(e.stdout, map[string]any{
+			"flag_key":           result.FlagKey,
+			"variant_key":        result.VariantKey,
+			"variant_attachment": result.VariantAttachment,
+			"evaluation_reason":  result.EvaluationReason,
+		})
+	}
+
+	printResult(e.stdout, result)
+	return nil
+}
+
+func printResult(w io.Writer, r *vexilla.Result) {
+	fmt.Fprintf(w, "flag:       %s\n", r.FlagKey)
+	fmt.Fprintf(w, "variant:    %s\n", r.VariantKey)
+	if len(r.VariantAttachment) > 0 {
+		attachment, _ := json.Marshal(r.VariantAttachment)
+		fmt.Fprintf(w, "attachment: %s\n", attachment)
+	}
+	if r.EvaluationReason != "" {
+		fmt.Fprintf(w, "reason:     %s\n", r.EvaluationReason)
+	}
+}
+
+func printExplanation(w io.Writer, exp *vexilla.Explanation) {
+	state := "enabled"
+	if !exp.Enabled {
+		state = "disabled"
+	}
+	fmt.Fprintf(w, "%s (id %d, %s)\n", exp.FlagKey, exp.FlagID, state)
+	fmt.Fprintf(w, "strategy: %s (%s)\n", exp.Strategy, exp.StrategyReason)
+
+	for _, seg := range exp.Segments {
+		outcome := "no match"
+		switch {
+		case seg.Selected:
+			outcome = "selected"
+		case seg.Matched:
+			outcome = "matched"
+		}
+
+		fmt.Fprintf(w, "\nsegment %d (rank %d", seg.SegmentID, seg.Rank)
+		if seg.Description != "" {
+			fmt.Fprintf(w, ", %q", seg.Description)
+		}
+		fmt.Fprintf(w, ", rollout %d%%): %s\n", seg.RolloutPercent, outcome)
+
+		for _, c := range seg.Constraints {
+			mark := "FAIL"
+			if c.Passed {
+				mark = "ok  "
+			}
+			actual := "missing"
+			if c.Present {
+				actual = describeValue(c.Actual)
+			}
+			fmt.Fprintf(w, "  %s %s %s %s (actual: %s)", mark, c.Property, c.Operator, describeValue(c.Expected), actual)
+			if c.Error != "" {
+				fmt.Fprintf(w, " error: %s", c.Error)
+			}
+			fmt.Fprintln(w)
+		}
+
+		if seg.Distribution != nil {
+			fmt.Fprintf(w, "  distribution: %s (%d%%)\n", seg.Distribution.VariantKey, seg.Distribution.Percent)
+		}
+		if seg.Message != "" {
+			fmt.Fprintf(w, "  flagr: %s\n", seg.Message)
+		}
+	}
+
+	if exp.Result != nil {
+		fmt.Fprintln(w)
+		printResult(w, exp.Result)
+	}
+}
+
+func describeValue(v any) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(raw)
+}
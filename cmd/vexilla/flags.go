@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/storage"
+)
+
+// connection holds the flags used to reach Flagr
+type connection struct {
+	endpoint string
+	apiKey   string
+	timeout  time.Duration
+	retries  int
+}
+
+// addConnectionFlags registers the Flagr connection flags. The endpoint
+// and API key default to $FLAGR_ENDPOINT and $FLAGR_API_KEY.
+func addConnectionFlags(fs *flag.FlagSet) *connection {
+	c := &connection{}
+
+	endpoint := os.Getenv("FLAGR_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:18000"
+	}
+
+	fs.StringVar(&c.endpoint, "endpoint", endpoint, "Flagr endpoint ($FLAGR_ENDPOINT)")
+	fs.StringVar(&c.apiKey, "api-key", os.Getenv("FLAGR_API_KEY"), "Flagr API key ($FLAGR_API_KEY)")
+	fs.DurationVar(&c.timeout, "timeout", 10*time.Second, "timeout of each Flagr request")
+	fs.IntVar(&c.retries, "retries", 2, "retries of failed Flagr requests")
+	return c
+}
+
+func (c *connection) config() flagr.Config {
+	return flagr.Config{
+		Endpoint:   strings.TrimRight(c.endpoint, "/"),
+		APIKey:     c.apiKey,
+		Timeout:    c.timeout,
+		MaxRetries: c.retries,
+	}
+}
+
+func (c *connection) admin() *flagr.AdminClient {
+	return flagr.NewAdminClient(c.config())
+}
+
+// source holds the flags choosing where flags are read from: a live
+// Flagr, or a disk snapshot when -snapshot is set
+type source struct {
+	*connection
+	snapshot string
+}
+
+func addSourceFlags(fs *flag.FlagSet) *source {
+	s := &source{connection: addConnectionFlags(fs)}
+	fs.StringVar(&s.snapshot, "snapshot", "", "read flags from a disk snapshot (a snapshot.json file or its directory) instead of Flagr")
+	return s
+}
+
+// client returns a Flagr client, or a static source serving the snapshot
+// (which evaluates every flag locally)
+func (s *source) client() (flagr.Client, error) {
+	if s.snapshot == "" {
+		return flagr.NewHTTPClient(s.config()), nil
+	}
+
+	flags, err := readSnapshot(s.snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return flagr.NewStaticSource(flags...), nil
+}
+
+// flags returns every flag of the source, ordered by key
+func (s *source) flags(ctx context.Context) ([]domain.Flag, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := client.GetAllFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
+// readSnapshot reads a disk snapshot file, or the snapshot of a disk
+// storage directory
+func readSnapshot(path string) ([]domain.Flag, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, storage.SnapshotFile)
+	}
+
+	snapshot, err := storage.ReadSnapshotFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make([]domain.Flag, 0, len(snapshot))
+	for key, flag := range snapshot {
+		if flag.Key == "" {
+			flag.Key = key
+		}
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func newFlagSet(e *env, name, args, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: vexilla %s %s\n\n%s\n\nFlags:\n", name, args, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and returns the positional arguments, which may
+// come before, between or after flags. -h and invalid flags become exit
+// codes (the flag package already printed the usage).
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, &exitError{code: 0}
+			}
+			return nil, &exitError{code: 2}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/codegen"
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+)
+
+// runGenerate writes a Go package with typed accessors for the flags
+func runGenerate(e *env, args []string) error {
+	fs := newFlagSet(e, "generate", "[flags] [manifest]",
+		"Generates a Go package with one typed method per flag, so flag keys and\n"+
+			"types are checked by the compiler. Flags are read from a manifest (a\n"+
+			"flag file in the simple or Flagr export layout, or a directory of flag\n"+
+			"definitions), or from Flagr or a snapshot when no manifest is given.\n"+
+			"Works with go generate:\n\n"+
+			"  //go:generate go run github.com/OrlandoBitencourt/vexilla/cmd/vexilla generate -o flags.go ../flags")
+	src := addSourceFlags(fs)
+	output := fs.String("o", "", "write the package to this file instead of stdout")
+	pkg := fs.String("package", os.Getenv("GOPACKAGE"), "package name (default $GOPACKAGE, else flags)")
+	tags := fs.String("tags", "", "only generate flags with any of these comma-separated tags")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("expected a single manifest, got %d arguments", len(args))
+	}
+
+	var flags []domain.Flag
+	source := src.endpoint
+	if src.snapshot != "" {
+		source = src.snapshot
+	}
+
+	if len(args) == 1 {
+		source = args[0]
+		if flags, err = readManifest(args[0]); err != nil {
+			return err
+		}
+	} else {
+		ctx, stop := signalContext()
+		defer stop()
+
+		if flags, err = src.flags(ctx); err != nil {
+			return err
+		}
+	}
+
+	code, err := codegen.Generate(filterByTags(flags, *tags), codegen.Options{Package: *pkg, Source: source})
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = e.stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(*output, code, 0o644)
+}
+
+// readManifest reads the flags of a flag file, or of the definitions in a
+// directory, ordered by key
+func readManifest(path string) ([]domain.Flag, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []domain.Flag
+	if info.IsDir() {
+		defs, err := flagr.LoadDefinitions(path)
+		if err != nil {
+			return nil, err
+		}
+		if flags, err = flagr.DefinitionsToDomain(defs); err != nil {
+			return nil, fmt.Errorf("invalid definitions: %w", err)
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if flags, err = flagr.ParseFlagFile(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/vexillatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := definitionsDir(t, map[string]string{
+		"checkout.yaml": `flags:
+  new-checkout:
+    description: New checkout flow
+    variants:
+      enabled: {enabled: true}
+      disabled: {enabled: false}
+    default: disabled
+`,
+		"theme.yaml": `flags:
+  ui-theme:
+    variants:
+      dark: {value: dark}
+    default: dark
+`,
+	})
+
+	// A single file
+	res := execute("", "generate", filepath.Join(dir, "checkout.yaml"))
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "// Source: "+filepath.Join(dir, "checkout.yaml"))
+	assert.Contains(t, res.stdout, "package flags\n")
+	assert.Contains(t, res.stdout, "func (f *Flags) NewCheckout(ctx context.Context, evalCtx vexilla.Context) bool {")
+	assert.NotContains(t, res.stdout, "UITheme")
+
+	// A directory, written to a file
+	out := filepath.Join(t.TempDir(), "flags.go")
+	res = execute("", "generate", "-package", "features", "-o", out, dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Empty(t, res.stdout)
+	code, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(code), "package features\n")
+	assert.Contains(t, string(code), "NewCheckout")
+	assert.Contains(t, string(code), `return result.GetString("value", "dark")`)
+
+	res = execute("", "generate", "-package", "my-flags", dir)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, `invalid package name "my-flags"`)
+
+	res = execute("", "generate", filepath.Join(dir, "missing.yaml"))
+	assert.Equal(t, 1, res.code)
+}
+
+func TestGenerate_Flagr(t *testing.T) {
+	srv := vexillatest.NewFlagrServer(
+		vexillatest.BoolFlag("new-checkout", true).Tags("checkout"),
+		vexillatest.IntFlag("max-items", 20).Tags("checkout"),
+		vexillatest.BoolFlag("search-v2", false).Tags("search"),
+	)
+	defer srv.Close()
+
+	t.Setenv("GOPACKAGE", "checkout")
+	res := execute("", "generate", "-endpoint", srv.URL, "-tags", "checkout")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "// Source: "+srv.URL)
+	assert.Contains(t, res.stdout, "package checkout\n")
+	assert.Contains(t, res.stdout, "func (f *Flags) NewCheckout(")
+	assert.Contains(t, res.stdout, `return result.GetInt("value", 20)`)
+	assert.NotContains(t, res.stdout, "SearchV2", "only flags with the tags are generated")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+)
+
+// flagReport is the JSON output of list and analyze
+type flagReport struct {
+	Key      string   `json:"key"`
+	ID       int64    `json:"id"`
+	Enabled  bool     `json:"enabled"`
+	Tags     []string `json:"tags"`
+	Strategy string   `json:"strategy"`
+	Reason   string   `json:"reason"`
+
+	// Set by analyze only
+	ExpectedLatency string          `json:"expected_latency,omitempty"`
+	HTTPRequests    *int            `json:"http_requests,omitempty"`
+	ComplexityScore *int            `json:"complexity_score,omitempty"`
+	Segments        []segmentReport `json:"segments,omitempty"`
+}
+
+type segmentReport struct {
+	SegmentID         int64  `json:"segment_id"`
+	Description       string `json:"description,omitempty"`
+	RolloutPercent    int    `json:"rollout_percent"`
+	ConstraintCount   int    `json:"constraint_count"`
+	DistributionCount int    `json:"distribution_count"`
+	IsABTest          bool   `json:"is_ab_test"`
+	RequiresFlagr     bool   `json:"requires_flagr"`
+}
+
+// runList prints every flag with its evaluation strategy
+func runList(e *env, args []string) error {
+	fs := newFlagSet(e, "list", "[flags]",
+		"Lists the flags of Flagr, or of a snapshot, with the strategy Vexilla uses\n"+
+			"to evaluate them (local or remote) and why.")
+	src := addSourceFlags(fs)
+	tags := fs.String("tags", "", "only list flags with any of these comma-separated tags")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	flags, err := src.flags(ctx)
+	if err != nil {
+		return err
+	}
+	flags = filterByTags(flags, *tags)
+
+	strategy := evaluator.NewStrategyDeterminer()
+	reports := make([]flagReport, len(flags))
+	for i, flag := range flags {
+		reports[i] = newFlagReport(flag, strategy.AnalyzeFlag(flag))
+	}
+
+	if *asJSON {
+		return writeJSON(e.stdout, reports)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tID\tENABLED\tSTRATEGY\tTAGS\tREASON")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%t\t%s\t%s\t%s\n", r.Key, r.ID, r.Enabled, r.Strategy, strings.Join(r.Tags, ","), r.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	printSummary(e.stdout, reports)
+	return nil
+}
+
+// runAnalyze prints the evaluation analysis and performance estimate of
+// flags
+func runAnalyze(e *env, args []string) error {
+	fs := newFlagSet(e, "analyze", "[flags] [flag-key...]",
+		"Analyzes how flags are evaluated: strategy, per-segment requirements,\n"+
+			"expected latency and complexity. Every flag is analyzed by default.")
+	src := addSourceFlags(fs)
+	asJSON := fs.Bool("json", false, "print JSON")
+	keys, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	flags, err := src.flags(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		if flags, err = selectFlags(flags, keys); err != nil {
+			return err
+		}
+	}
+
+	strategy := evaluator.NewStrategyDeterminer()
+	reports := make([]flagReport, len(flags))
+	for i, flag := range flags {
+		r := newFlagReport(flag, strategy.AnalyzeFlag(flag))
+
+		estimate := strategy.EstimatePerformance(flag)
+		r.ExpectedLatency = estimate.ExpectedLatency
+		r.HTTPRequests = &estimate.HTTPRequests
+		r.ComplexityScore = &estimate.ComplexityScore
+		reports[i] = r
+	}
+
+	if *asJSON {
+		return writeJSON(e.stdout, reports)
+	}
+
+	for _, r := range reports {
+		state := "enabled"
+		if !r.Enabled {
+			state = "disabled"
+		}
+		fmt.Fprintf(e.stdout, "%s (id %d, %s)\n", r.Key, r.ID, state)
+		fmt.Fprintf(e.stdout, "  strategy:   %s (%s)\n", r.Strategy, r.Reason)
+		fmt.Fprintf(e.stdout, "  latency:    %s, %s per evaluation\n", r.ExpectedLatency, plural(*r.HTTPRequests, "HTTP request"))
+		fmt.Fprintf(e.stdout, "  complexity: %d\n", *r.ComplexityScore)
+
+		if len(r.Segments) > 0 {
+			fmt.Fprintln(e.stdout, "  segments:")
+		}
+		for i, seg := range r.Segments {
+			fmt.Fprintf(e.stdout, "    %d. segment %d", i+1, seg.SegmentID)
+			if seg.Description != "" {
+				fmt.Fprintf(e.stdout, " %q", seg.Description)
+			}
+			fmt.Fprintf(e.stdout, ": rollout %d%%, %s, %s",
+				seg.RolloutPercent, plural(seg.ConstraintCount, "constraint"), plural(seg.DistributionCount, "distribution"))
+			if seg.IsABTest {
+				fmt.Fprint(e.stdout, ", A/B test")
+			}
+			if seg.RequiresFlagr {
+				fmt.Fprint(e.stdout, " (requires Flagr)")
+			}
+			fmt.Fprintln(e.stdout)
+		}
+		fmt.Fprintln(e.stdout)
+	}
+
+	printSummary(e.stdout, reports)
+	return nil
+}
+
+func newFlagReport(flag domain.Flag, analysis evaluator.FlagAnalysis) flagReport {
+	r := flagReport{
+		Key:      flag.Key,
+		ID:       flag.ID,
+		Enabled:  flag.Enabled,
+		Tags:     make([]string, 0, len(flag.Tags)),
+		Strategy: string(analysis.Strategy),
+		Reason:   analysis.Reason,
+	}
+	for _, tag := range flag.Tags {
+		r.Tags = append(r.Tags, tag.Value)
+	}
+
+	descriptions := make(map[int64]string, len(flag.Segments))
+	for _, seg := range flag.Segments {
+		descriptions[seg.ID] = seg.Description
+	}
+	for _, seg := range analysis.Segments {
+		r.Segments = append(r.Segments, segmentReport{
+			SegmentID:         seg.SegmentID,
+			Description:       descriptions[seg.SegmentID],
+			RolloutPercent:    seg.RolloutPercent,
+			ConstraintCount:   seg.ConstraintCount,
+			DistributionCount: seg.DistributionCount,
+			IsABTest:          seg.IsABTest,
+			RequiresFlagr:     seg.RequiresFlagr,
+		})
+	}
+	return r
+}
+
+// selectFlags returns the flags with the given keys, in that order
+func selectFlags(flags []domain.Flag, keys []string) ([]domain.Flag, error) {
+	byKey := make(map[string]domain.Flag, len(flags))
+	for _, flag := range flags {
+		byKey[flag.Key] = flag
+	}
+
+	selected := make([]domain.Flag, 0, len(keys))
+	for _, key := range keys {
+		flag, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("flag %s not found", key)
+		}
+		selected = append(selected, flag)
+	}
+	return selected, nil
+}
+
+// filterByTags keeps the flags with any of the comma-separated tags
+func filterByTags(flags []domain.Flag, tags string) []domain.Flag {
+	if tags == "" {
+		return flags
+	}
+
+	wanted := make(map[string]bool)
+	for _, tag := range splitList(tags) {
+		wanted[tag] = true
+	}
+
+	filtered := make([]domain.Flag, 0, len(flags))
+	for _, flag := range flags {
+		for _, tag := range flag.Tags {
+			if wanted[tag.Value] {
+				filtered = append(filtered, flag)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func printSummary(w io.Writer, reports []flagReport) {
+	local := 0
+	for _, r := range reports {
+		if r.Strategy == string(domain.StrategyLocal) {
+			local++
+		}
+	}
+	fmt.Fprintf(w, "\n%s: %d local, %d remote\n", plural(len(reports), "flag"), local, len(reports)-local)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
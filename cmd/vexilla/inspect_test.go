@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/vexillatest"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlagrServer serves a deterministic and a partially rolled out flag
+func newFlagrServer() *flagrtest.Server {
+	return vexillatest.NewFlagrServer(
+		vexillatest.BoolFlag("br-launch", false).
+			Tags("checkout").
+			Segment(vexillatest.Segment().Description("brazil").Where("country", "EQ", "BR").Serve("enabled")),
+		vexillatest.Flag("pricing-v2").
+			Tags("pricing").
+			Variant(vexillatest.Variant("control")).
+			Variant(vexillatest.Variant("treatment")).
+			Segment(vexillatest.Segment().Rollout(20).Split("control", 50).Split("treatment", 50)),
+	)
+}
+
+// exportSnapshot writes the server's flags to a snapshot file
+func exportSnapshot(t *testing.T, srv *flagrtest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	res := execute("", "snapshot", "export", "-endpoint", srv.URL, "-o", path)
+	require.Equal(t, 0, res.code, res.stderr)
+	return path
+}
+
+func TestList(t *testing.T) {
+	srv := newFlagrServer()
+	defer srv.Close()
+
+	res := execute("", "list", "-endpoint", srv.URL)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "KEY")
+	assert.Regexp(t, `br-launch\s+1\s+true\s+local\s+checkout\s+100% deterministic`, res.stdout)
+	assert.Regexp(t, `pricing-v2\s+2\s+true\s+remote\s+pricing\s+partial rollout`, res.stdout)
+	assert.Contains(t, res.stdout, "2 flags: 1 local, 1 remote")
+
+	res = execute("", "list", "-endpoint", srv.URL, "-tags", "pricing", "-json")
+	require.Equal(t, 0, res.code, res.stderr)
+	var reports []flagReport
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "pricing-v2", reports[0].Key)
+	assert.Equal(t, "remote", reports[0].Strategy)
+
+	// Offline, from a snapshot
+	snapshot := exportSnapshot(t, srv)
+	srv.Close()
+
+	res = execute("", "list", "-snapshot", snapshot)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "2 flags: 1 local, 1 remote")
+
+	res = execute("", "list", "-endpoint", srv.URL, "-retries", "0")
+	assert.Equal(t, 1, res.code)
+	assert.NotEmpty(t, res.stderr)
+}
+
+func TestAnalyze(t *testing.T) {
+	srv := newFlagrServer()
+	defer srv.Close()
+
+	res := execute("", "analyze", "-endpoint", srv.URL, "pricing-v2")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "pricing-v2 (id 2, enabled)")
+	assert.Contains(t, res.stdout, "strategy:   remote (partial rollout requires consistent hashing)")
+	assert.Contains(t, res.stdout, "latency:    50-200ms, 1 HTTP request per evaluation")
+	assert.Contains(t, res.stdout, "rollout 20%, 0 constraints, 2 distributions, A/B test (requires Flagr)")
+	assert.NotContains(t, res.stdout, "br-launch")
+
+	res = execute("", "analyze", "-endpoint", srv.URL, "-json")
+	require.Equal(t, 0, res.code, res.stderr)
+	var reports []flagReport
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &reports))
+	require.Len(t, reports, 2)
+	assert.Equal(t, "< 1ms", reports[0].ExpectedLatency)
+	require.NotNil(t, reports[0].ComplexityScore)
+	assert.Equal(t, 31, *reports[0].ComplexityScore, "brazil and default segments")
+	assert.Equal(t, "brazil", reports[0].Segments[0].Description)
+
+	res = execute("", "analyze", "-endpoint", srv.URL, "missing")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, "flag missing not found")
+}
+
+func TestSnapshot(t *testing.T) {
+	srv := newFlagrServer()
+	defer srv.Close()
+
+	res := execute("", "snapshot", "export", "-endpoint", srv.URL)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, `"br-launch"`)
+
+	snapshot := exportSnapshot(t, srv)
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	res = execute("", "snapshot", "import", "-dir", dir, snapshot)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "Imported 2 flags")
+	assert.FileExists(t, filepath.Join(dir, "snapshot.json"))
+	assert.FileExists(t, filepath.Join(dir, "br-launch.json"))
+
+	// The storage directory is itself a snapshot source
+	res = execute("", "list", "-snapshot", dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "pricing-v2")
+
+	// Invalid flags are imported with a warning
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"bad": {"Key": "bad", "Segments": [{"RolloutPercent": 150}]}}`), 0o644))
+	res = execute("", "snapshot", "import", "-dir", t.TempDir(), invalid)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stderr, "warning: flag bad")
+
+	assert.Equal(t, 2, execute("", "snapshot").code)
+	assert.Equal(t, 2, execute("", "snapshot", "import", snapshot).code, "-dir is required")
+}
+
+func TestEval(t *testing.T) {
+	srv := newFlagrServer()
+	defer srv.Close()
+
+	res := execute("", "eval", "-endpoint", srv.URL, "-entity", "user-1", "-attr", "country=BR", "br-launch")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "flag:       br-launch")
+	assert.Contains(t, res.stdout, "variant:    enabled")
+
+	res = execute("", "eval", "-endpoint", srv.URL, "-entity", "user-1", "-attr", "country=US", "-explain", "br-launch")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "br-launch (id 1, enabled)")
+	assert.Contains(t, res.stdout, `no match`)
+	assert.Contains(t, res.stdout, `FAIL country EQ "BR" (actual: "US")`)
+
+	res = execute("", "eval", "-endpoint", srv.URL, "-entity", "user-1", "-attr", "country=BR", "-json", "br-launch")
+	require.Equal(t, 0, res.code, res.stderr)
+	var result map[string]any
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &result))
+	assert.Equal(t, "enabled", result["variant_key"])
+
+	// Offline, from a snapshot
+	snapshot := exportSnapshot(t, srv)
+	srv.Close()
+
+	res = execute("", "eval", "-snapshot", snapshot, "-entity", "user-1", "-attr", "country=BR", "br-launch")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "variant:    enabled")
+
+	assert.Equal(t, 2, execute("", "eval", "-snapshot", snapshot).code, "flag key is required")
+	assert.Equal(t, 2, execute("", "eval", "-snapshot", snapshot, "-attr", "novalue", "br-launch").code)
+}
+
+func TestAttributes(t *testing.T) {
+	attrs := attributes{}
+	require.NoError(t, attrs.Set("age=18"))
+	require.NoError(t, attrs.Set("beta=true"))
+	require.NoError(t, attrs.Set("country=BR"))
+	require.Error(t, attrs.Set("=x"))
+
+	assert.Equal(t, attributes{"age": float64(18), "beta": true, "country": "BR"}, attrs)
+	assert.Equal(t, "age=18,beta=true,country=BR", attrs.String())
+}
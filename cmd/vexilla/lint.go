@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+)
+
+// severityRank orders severities for -fail-on
+var severityRank = map[string]int{
+	string(evaluator.SeverityInfo):    1,
+	string(evaluator.SeverityWarning): 2,
+	string(evaluator.SeverityError):   3,
+	"none":                            4,
+}
+
+// runLint reports problems in flags
+func runLint(e *env, args []string) error {
+	fs := newFlagSet(e, "lint", "[flags] [flag-key...]",
+		"Checks flags for problems: distributions not adding up to 100%, segments\n"+
+			"shadowed by earlier ones, unreachable variants, constraints on properties\n"+
+			"services never send (with -properties), enabled flags without segments and\n"+
+			"flags evaluated by Flagr only because of a 99/1 split. Every flag is\n"+
+			"checked by default. Exits with 1 when there are issues at the -fail-on\n"+
+			"severity or above.")
+	src := addSourceFlags(fs)
+	properties := fs.String("properties", "", "comma-separated context attributes services send; constraints on others are reported")
+	failOn := fs.String("fail-on", "error", "lowest severity that fails: info, warning, error or none")
+	asJSON := fs.Bool("json", false, "print JSON")
+	keys, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	threshold, ok := severityRank[*failOn]
+	if !ok {
+		return fmt.Errorf("invalid -fail-on %q: expected info, warning, error or none", *failOn)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	flags, err := src.flags(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		if flags, err = selectFlags(flags, keys); err != nil {
+			return err
+		}
+	}
+
+	var known []string
+	if *properties != "" {
+		known = splitList(*properties)
+	}
+	issues := evaluator.NewLinter(known).Lint(flags)
+
+	if *asJSON {
+		if err := writeJSON(e.stdout, issues); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		for _, issue := range issues {
+			location := issue.FlagKey
+			if issue.SegmentID != 0 {
+				location = fmt.Sprintf("%s segment %d", issue.FlagKey, issue.SegmentID)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", issue.Severity, location, issue.Rule, issue.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		printLintSummary(e, len(flags), issues)
+	}
+
+	for _, issue := range issues {
+		if severityRank[string(issue.Severity)] >= threshold {
+			return &exitError{code: 1}
+		}
+	}
+	return nil
+}
+
+func printLintSummary(e *env, flags int, issues []evaluator.LintIssue) {
+	if len(issues) == 0 {
+		fmt.Fprintf(e.stdout, "%s checked, no issues\n", plural(flags, "flag"))
+		return
+	}
+
+	counts := map[evaluator.Severity]int{}
+	for _, issue := range issues {
+		counts[issue.Severity]++
+	}
+	fmt.Fprintf(e.stdout, "\n%s checked, %s: %s, %s, %d info\n", plural(flags, "flag"), plural(len(issues), "issue"),
+		plural(counts[evaluator.SeverityError], "error"), plural(counts[evaluator.SeverityWarning], "warning"),
+		counts[evaluator.SeverityInfo])
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	srv := vexillatest.NewFlagrServer(
+		vexillatest.BoolFlag("br-launch", false).
+			Segment(vexillatest.Segment().Where("country", "EQ", "BR").Serve("enabled")),
+		vexillatest.Flag("almost-everyone").
+			Variant(vexillatest.Variant("on")).
+			Variant(vexillatest.Variant("off")).
+			Segment(vexillatest.Segment().Where("plan", "EQ", "pro").Serve("on")).
+			Segment(vexillatest.Segment().Split("on", 99).Split("off", 1)),
+	)
+	defer srv.Close()
+
+	res := execute("", "lint", "-endpoint", srv.URL)
+	require.Equal(t, 0, res.code, "info issues do not fail by default")
+	assert.Regexp(t, `info\s+almost-everyone segment 2\s+near-deterministic-split\s+flag is evaluated by Flagr only because of a 99/1 split`, res.stdout)
+	assert.Contains(t, res.stdout, "2 flags checked, 1 issue: 0 errors, 0 warnings, 1 info")
+
+	res = execute("", "lint", "-endpoint", srv.URL, "-properties", "country", "-fail-on", "warning", "-json")
+	assert.Equal(t, 1, res.code)
+	var issues []evaluator.LintIssue
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &issues))
+	require.Len(t, issues, 2)
+	assert.Equal(t, evaluator.RuleUnknownProperty, issues[0].Rule)
+	assert.Equal(t, evaluator.SeverityWarning, issues[0].Severity)
+
+	res = execute("", "lint", "-endpoint", srv.URL, "br-launch")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "1 flag checked, no issues")
+
+	assert.Equal(t, 1, execute("", "lint", "-endpoint", srv.URL, "-fail-on", "info").code)
+	assert.Equal(t, 1, execute("", "lint", "-endpoint", srv.URL, "-fail-on", "bad").code)
+}
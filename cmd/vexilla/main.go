@@ -0,0 +1,110 @@
+// Command vexilla inspects, evaluates and manages Vexilla and Flagr feature
+// flags from the command line.
+//
+// Flags are read from a live Flagr or, with -snapshot, from a disk
+// snapshot, and evaluated through the same cache pipeline as services:
+//
+//	vexilla list
+//	vexilla eval -entity user-123 -attr country=BR -explain new-checkout
+//	vexilla analyze -snapshot /var/cache/vexilla
+//	vexilla lint -properties country,tier -json
+//	vexilla scan -tags checkout ./...
+//	vexilla generate -package flags -o flags/flags.go ./flags.yaml
+//	vexilla snapshot export -o snapshot.json
+//
+// Flags can also be kept as code: a directory of YAML definitions is reviewed
+// like any other change, "vexilla plan" shows what would change in Flagr
+// and "vexilla apply" makes the changes.
+//
+//	vexilla plan -endpoint http://localhost:18000 ./flags
+//	vexilla apply -endpoint http://localhost:18000 -auto-approve ./flags
+//
+// Run "vexilla help" for the list of commands.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a vexilla subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(env *env, args []string) error
+}
+
+var commands = []command{
+	{"list", "List flags with their evaluation strategy", runList},
+	{"eval", "Evaluate a flag for an entity, optionally explaining the decision", runEval},
+	{"analyze", "Show how flags are evaluated and what it costs", runAnalyze},
+	{"lint", "Report problems in flags", runLint},
+	{"scan", "Cross-check the flags evaluated in Go code with Flagr", runScan},
+	{"generate", "Generate a Go package with typed flag accessors", runGenerate},
+	{"snapshot", "Export flags to, or import them from, a disk snapshot", runSnapshot},
+	{"plan", "Show the changes needed for Flagr to match flag definitions", runPlan},
+	{"apply", "Make Flagr match flag definitions", runApply},
+}
+
+// env holds the standard streams, so commands can be tested
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// exitError ends the program with a specific exit code
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run executes the command named by args[0] and returns the exit code
+func run(args []string, e *env) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(e.stdout)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(e, args[1:])
+		if err == nil {
+			return 0
+		}
+		if exit, ok := err.(*exitError); ok {
+			return exit.code
+		}
+		fmt.Fprintf(e.stderr, "vexilla %s: %v\n", cmd.name, err)
+		return 1
+	}
+
+	fmt.Fprintf(e.stderr, "vexilla: unknown command %q\n\n", args[0])
+	usage(e.stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: vexilla <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "vexilla <command> -h" for the flags of a command.`)
+}
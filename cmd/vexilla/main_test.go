@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checkoutDefinitions = `
+flags:
+  new-checkout:
+    description: New checkout flow
+    tags: [checkout]
+    variants:
+      enabled: {enabled: true}
+      disabled: {enabled: false}
+    segments:
+      - constraints:
+          - {property: country, operator: EQ, value: BR}
+        variant: enabled
+      - rollout: 20
+        variant: enabled
+    default: disabled
+`
+
+const pricingDefinitions = `
+flags:
+  pricing-v2:
+    enabled: false
+    segments:
+      - distribution: {control: 50, treatment: 50}
+`
+
+// result is the outcome of a command run
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+func execute(stdin string, args ...string) result {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+// definitionsDir writes name -> content files to a temporary directory
+func definitionsDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestRun_Usage(t *testing.T) {
+	res := execute("")
+	assert.Equal(t, 2, res.code)
+	assert.Contains(t, res.stdout, "plan")
+
+	assert.Equal(t, 0, execute("", "help").code)
+	assert.Equal(t, 0, execute("", "plan", "-h").code)
+
+	res = execute("", "deploy")
+	assert.Equal(t, 2, res.code)
+	assert.Contains(t, res.stderr, `unknown command "deploy"`)
+
+	assert.Equal(t, 2, execute("", "plan", "-unknown").code)
+}
+
+func TestPlanApply(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+
+	dir := definitionsDir(t, map[string]string{
+		"checkout.yaml":       checkoutDefinitions,
+		"pricing/pricing.yml": pricingDefinitions,
+		"README.md":           "not a definition",
+	})
+
+	res := execute("", "plan", "-endpoint", srv.URL, "-detailed-exitcode", dir)
+	require.Equal(t, 2, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "+ new-checkout flag: New checkout flow")
+	assert.Contains(t, res.stdout, "+ pricing-v2 variant treatment")
+	assert.Contains(t, res.stdout, "to create")
+	assert.Empty(t, srv.Flags(), "plan changes nothing")
+
+	// Apply asks for confirmation
+	res = execute("no\n", "apply", "-endpoint", srv.URL, dir)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stdout, "Apply cancelled.")
+	assert.Empty(t, srv.Flags())
+
+	res = execute("yes\n", "apply", "-endpoint", srv.URL, dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "Apply complete!")
+	assert.Len(t, srv.Flags(), 2)
+
+	res = execute("", "plan", "-endpoint", srv.URL, "-detailed-exitcode", dir)
+	assert.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "No changes.")
+
+	res = execute("", "apply", "-endpoint", srv.URL, dir)
+	assert.Equal(t, 0, res.code, "nothing to confirm")
+
+	// Removing a file and pruning deletes its flags
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "pricing")))
+	res = execute("", "apply", "-endpoint", srv.URL, "-prune", "-auto-approve", dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "- pricing-v2 flag")
+	assert.Len(t, srv.Flags(), 1)
+}
+
+func TestPlan_Offline(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+
+	dir := definitionsDir(t, map[string]string{"checkout.yaml": checkoutDefinitions})
+	require.Equal(t, 0, execute("", "apply", "-endpoint", srv.URL, "-auto-approve", dir).code)
+
+	admin := flagr.NewAdminClient(flagr.Config{Endpoint: srv.URL})
+	flags, err := admin.ListFlags(t.Context())
+	require.NoError(t, err)
+	export, err := json.Marshal(flags)
+	require.NoError(t, err)
+	exportPath := filepath.Join(t.TempDir(), "export.json")
+	require.NoError(t, os.WriteFile(exportPath, export, 0o644))
+	srv.Close()
+
+	res := execute("", "plan", "-export", exportPath, dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "No changes.")
+
+	changed := strings.Replace(checkoutDefinitions, "rollout: 20", "rollout: 40", 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "checkout.yaml"), []byte(changed), 0o644))
+
+	res = execute("", "plan", "-export", exportPath, dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "~ new-checkout segment 2: rollout 20% -> rollout 40%")
+}
+
+func TestPlan_InvalidDefinitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "rollout above 100",
+			files: map[string]string{"f.yaml": "flags:\n  f:\n    segments:\n      - {variant: on, rollout: 150}\n"},
+			want:  "rollout percent must be between 0 and 100",
+		},
+		{
+			name:  "distribution not adding up to 100",
+			files: map[string]string{"f.yaml": "flags:\n  f:\n    segments:\n      - distribution: {a: 50, b: 20}\n"},
+			want:  "must equal 100",
+		},
+		{
+			name: "key defined twice",
+			files: map[string]string{
+				"a.yaml": "flags:\n  f:\n    segments: [{variant: on}]\n",
+				"b.yaml": "flags:\n  f:\n    segments: [{variant: on}]\n",
+			},
+			want: "defined in both",
+		},
+		{
+			name:  "no definitions",
+			files: map[string]string{"notes.txt": "nothing"},
+			want:  "no flag definitions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := execute("", "plan", "-endpoint", "http://127.0.0.1:1", definitionsDir(t, tt.files))
+			assert.Equal(t, 1, res.code)
+			assert.Contains(t, res.stderr, tt.want)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+)
+
+// runPlan prints the changes needed for Flagr, or a Flagr export, to match
+// the definitions
+func runPlan(e *env, args []string) error {
+	fs := newFlagSet(e, "plan", "[flags] [path]",
+		"Shows the changes needed for Flagr to match the flag definitions in path\n"+
+			"(a YAML or JSON file, or a directory of them; default \".\").")
+	conn := addConnectionFlags(fs)
+	export := fs.String("export", "", "compare with a Flagr export (JSON of GET /api/v1/flags?preload=true) instead of a live Flagr")
+	prune := fs.Bool("prune", false, "delete flags that are not defined")
+	detailed := fs.Bool("detailed-exitcode", false, "exit with status 2 when there are changes")
+	paths, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	defs, err := loadDefinitions(paths)
+	if err != nil {
+		return err
+	}
+	opts := flagr.ApplyOptions{Prune: *prune}
+
+	var plan *flagr.Plan
+	if *export != "" {
+		data, err := os.ReadFile(*export)
+		if err != nil {
+			return err
+		}
+		actual, err := flagr.ParseFlagrExport(*export, data)
+		if err != nil {
+			return err
+		}
+		if plan, err = flagr.PlanOffline(defs, actual, opts); err != nil {
+			return err
+		}
+	} else {
+		ctx, stop := signalContext()
+		defer stop()
+
+		if plan, err = conn.admin().Plan(ctx, defs, opts); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(e.stdout, plan)
+
+	if *detailed && !plan.Empty() {
+		return &exitError{code: 2}
+	}
+	return nil
+}
+
+// runApply plans and, once confirmed, makes the changes
+func runApply(e *env, args []string) error {
+	fs := newFlagSet(e, "apply", "[flags] [path]",
+		"Makes Flagr match the flag definitions in path (a YAML or JSON file, or a\n"+
+			"directory of them; default \".\"). The plan is shown and must be confirmed\n"+
+			"unless -auto-approve is set.")
+	conn := addConnectionFlags(fs)
+	prune := fs.Bool("prune", false, "delete flags that are not defined")
+	autoApprove := fs.Bool("auto-approve", false, "apply without asking for confirmation")
+	paths, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	defs, err := loadDefinitions(paths)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	admin := conn.admin()
+	plan, err := admin.Plan(ctx, defs, flagr.ApplyOptions{Prune: *prune})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(e.stdout, plan)
+	if plan.Empty() {
+		return nil
+	}
+
+	if !*autoApprove {
+		fmt.Fprint(e.stdout, "\nApply these changes? Only 'yes' will be accepted: ")
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Fprintln(e.stdout, "\nApply cancelled.")
+			return &exitError{code: 1}
+		}
+	}
+
+	if err := admin.ApplyPlan(ctx, plan); err != nil {
+		fmt.Fprintf(e.stdout, "\nApplied %d of %d changes.\n", plan.Applied, len(plan.Changes))
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "\nApply complete! %d changes made.\n", plan.Applied)
+	return nil
+}
+
+// loadDefinitions loads and validates the definitions at the path
+// argument, "." by default
+func loadDefinitions(args []string) ([]flagr.FlagDefinition, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("expected a single path, got %d arguments", len(args))
+	}
+
+	path := "."
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	defs, err := flagr.LoadDefinitions(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("no flag definitions found in %s", path)
+	}
+
+	// Validated up front so errors are reported before contacting Flagr
+	if _, err := flagr.DefinitionsToDomain(defs); err != nil {
+		return nil, fmt.Errorf("invalid definitions: %w", err)
+	}
+	return defs, nil
+}
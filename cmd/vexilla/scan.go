@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/scan"
+)
+
+// runScan cross-checks the flag keys evaluated in Go code with the flags
+func runScan(e *env, args []string) error {
+	fs := newFlagSet(e, "scan", "[flags] [packages]",
+		"Finds the flags Go code evaluates (Bool, String, Int, Evaluate and Explain\n"+
+			"calls with constant keys) and compares them with Flagr, or a snapshot:\n"+
+			"flags evaluated in code but missing, flags no code evaluates, String\n"+
+			"defaults replaced by the fallback strategy and flags evaluated with\n"+
+			"different defaults. Packages are directories, \"dir/...\" includes\n"+
+			"subdirectories (default ./...). Exits with 1 when there are findings.")
+	src := addSourceFlags(fs)
+	tags := fs.String("tags", "", "only compare with flags with any of these comma-separated tags")
+	fallback := fs.String("fallback", "", "fallback strategy of the clients (default: as configured in code, else fail_closed)")
+	tests := fs.Bool("tests", false, "include _test.go files")
+	list := fs.Bool("list", false, "only list the flag references, without reading flags")
+	asJSON := fs.Bool("json", false, "print JSON")
+	patterns, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	result, err := scan.Dir(patterns, scan.Options{Tests: *tests})
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		if *asJSON {
+			return writeJSON(e.stdout, result)
+		}
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		for _, ref := range result.References {
+			key := ref.FlagKey
+			if ref.Dynamic {
+				key = ref.Expr + " (dynamic)"
+			}
+			def := ""
+			if ref.Default != nil {
+				def = fmt.Sprintf("default %#v", ref.Default)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ref.Position(), ref.Method, key, def)
+		}
+		return tw.Flush()
+	}
+
+	strategy := *fallback
+	if strategy == "" {
+		if strategy, err = result.FallbackStrategy(); err != nil {
+			return fmt.Errorf("%w; set -fallback", err)
+		}
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	flags, err := src.flags(ctx)
+	if err != nil {
+		return err
+	}
+	findings := scan.Compare(result, filterByTags(flags, *tags), strategy)
+
+	if *asJSON {
+		if err := writeJSON(e.stdout, map[string]any{
+			"fallback_strategy": strategy,
+			"references":        result.References,
+			"findings":          findings,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printFindings(e, result, findings)
+	}
+
+	if len(findings) > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+func printFindings(e *env, result *scan.Result, findings []scan.Finding) {
+	for _, f := range findings {
+		fmt.Fprintf(e.stdout, "%s: %s\n", f.Kind, f.Message)
+		for _, pos := range f.References {
+			fmt.Fprintf(e.stdout, "    %s\n", pos)
+		}
+	}
+
+	var dynamic []scan.Reference
+	for _, ref := range result.References {
+		if ref.Dynamic {
+			dynamic = append(dynamic, ref)
+		}
+	}
+	if len(dynamic) > 0 {
+		fmt.Fprintf(e.stdout, "\nnot checked, the flag key is not a constant:\n")
+		for _, ref := range dynamic {
+			fmt.Fprintf(e.stdout, "    %s %s(%s)\n", ref.Position(), ref.Method, ref.Expr)
+		}
+	}
+
+	fmt.Fprintf(e.stdout, "\nScanned %s: %s, %s\n", plural(result.Packages, "package"),
+		plural(len(result.References), "flag reference"), plural(len(findings), "finding"))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/vexillatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const serviceSource = `package service
+
+import (
+	"context"
+
+	"github.com/OrlandoBitencourt/vexilla"
+)
+
+func Handle(ctx context.Context, client *vexilla.Client, key string) {
+	evalCtx := vexilla.NewContext("user-1")
+	client.Bool(ctx, "br-launch", evalCtx)
+	client.Bool(ctx, "removed-flag", evalCtx)
+	client.String(ctx, "theme", evalCtx, "light")
+	client.Evaluate(ctx, key, evalCtx)
+}
+`
+
+// serviceDir writes a Go package evaluating flags
+func serviceDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "service.go"), []byte(serviceSource), 0o644))
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	srv := vexillatest.NewFlagrServer(
+		vexillatest.BoolFlag("br-launch", true).Tags("checkout"),
+		vexillatest.StringFlag("theme", "dark").Tags("checkout"),
+		vexillatest.BoolFlag("legacy", false).Tags("checkout"),
+		vexillatest.BoolFlag("other-team", false).Tags("search"),
+	)
+	defer srv.Close()
+	dir := serviceDir(t)
+
+	res := execute("", "scan", "-endpoint", srv.URL, "-tags", "checkout", dir)
+	assert.Equal(t, 1, res.code, res.stderr)
+	file := filepath.Join(dir, "service.go")
+	assert.Contains(t, res.stdout, "missing: flag removed-flag is evaluated in code but does not exist\n    "+file+":12:2")
+	assert.Contains(t, res.stdout, `fallback-default: default "light" is never returned for a missing flag: with fallback fail_closed, String returns "disabled"`)
+	assert.Contains(t, res.stdout, "unused: flag legacy is not evaluated by any scanned code")
+	assert.NotContains(t, res.stdout, "other-team", "only flags with the tags are compared")
+	assert.Contains(t, res.stdout, file+":14:2 Evaluate(key)")
+	assert.Contains(t, res.stdout, "Scanned 1 package: 4 flag references, 3 findings")
+
+	res = execute("", "scan", "-endpoint", srv.URL, "-tags", "checkout", "-fallback", "error", "-json", dir)
+	assert.Equal(t, 1, res.code, res.stderr)
+	var report struct {
+		FallbackStrategy string `json:"fallback_strategy"`
+		Findings         []struct {
+			Kind    string `json:"kind"`
+			FlagKey string `json:"flag_key"`
+		} `json:"findings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &report))
+	assert.Equal(t, "error", report.FallbackStrategy)
+	require.Len(t, report.Findings, 2)
+	assert.Equal(t, "removed-flag", report.Findings[0].FlagKey)
+	assert.Equal(t, "legacy", report.Findings[1].FlagKey)
+
+	// Listing references does not need Flagr
+	res = execute("", "scan", "-list", "-endpoint", "http://127.0.0.1:1", dir)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Regexp(t, `service.go:13:2\s+String\s+theme\s+default "light"`, res.stdout)
+	assert.Regexp(t, `service.go:14:2\s+Evaluate\s+key \(dynamic\)`, res.stdout)
+}
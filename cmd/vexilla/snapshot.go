@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/storage"
+)
+
+// runSnapshot dispatches the snapshot subcommands
+func runSnapshot(e *env, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "export":
+			return runSnapshotExport(e, args[1:])
+		case "import":
+			return runSnapshotImport(e, args[1:])
+		}
+	}
+
+	fmt.Fprintln(e.stderr, "Usage: vexilla snapshot export [flags]")
+	fmt.Fprintln(e.stderr, "       vexilla snapshot import [flags] <snapshot>")
+	fmt.Fprintln(e.stderr)
+	fmt.Fprintln(e.stderr, "Disk snapshots hold the flags of a disk cache (snapshot.json). A service")
+	fmt.Fprintln(e.stderr, "using disk storage falls back to its snapshot when Flagr is unreachable.")
+	return &exitError{code: 2}
+}
+
+// runSnapshotExport writes the flags of the source as a disk snapshot
+func runSnapshotExport(e *env, args []string) error {
+	fs := newFlagSet(e, "snapshot export", "[flags]",
+		"Writes every flag of Flagr (or of another snapshot) as a disk snapshot.")
+	src := addSourceFlags(fs)
+	output := fs.String("o", "", "output file, or disk storage directory (default stdout)")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	flags, err := src.flags(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make(map[string]domain.Flag, len(flags))
+	for _, flag := range flags {
+		snapshot[flag.Key] = flag
+	}
+
+	if *output == "" {
+		return writeJSON(e.stdout, snapshot)
+	}
+
+	path := *output
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, storage.SnapshotFile)
+	}
+	if err := storage.WriteSnapshotFile(path, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "Exported %s to %s\n", plural(len(flags), "flag"), path)
+	return nil
+}
+
+// runSnapshotImport seeds a disk storage directory with a snapshot
+func runSnapshotImport(e *env, args []string) error {
+	fs := newFlagSet(e, "snapshot import", "[flags] <snapshot>",
+		"Seeds a disk storage directory with a snapshot, so a service using it starts\n"+
+			"with these flags when Flagr is unreachable. Invalid flags are reported.")
+	dir := fs.String("dir", "", "disk storage directory (required)")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 || *dir == "" {
+		fs.Usage()
+		return &exitError{code: 2}
+	}
+
+	flags, err := readSnapshot(rest[0])
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range flags {
+		if err := flag.Validate(); err != nil {
+			fmt.Fprintf(e.stderr, "warning: flag %s: %v\n", flag.Key, err)
+		}
+	}
+
+	ds, err := storage.NewDiskStorage(*dir)
+	if err != nil {
+		return err
+	}
+	defer ds.Close()
+
+	ctx := context.Background()
+	snapshot := make(map[string]domain.Flag, len(flags))
+	for _, flag := range flags {
+		if err := ds.Set(ctx, flag.Key, flag, 0); err != nil {
+			return err
+		}
+		snapshot[flag.Key] = flag
+	}
+	if err := ds.SaveSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "Imported %s into %s\n", plural(len(flags), "flag"), *dir)
+	return nil
+}
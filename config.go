@@ -73,6 +73,14 @@ type FilterConfig struct {
 	// "any": flag must have ANY of the tags
 	// "all": flag must have ALL of the tags
 	TagMatchMode string
+
+	// Namespaces are Flagr tags whose flags are loaded at start. Only
+	// their flags are fetched from Flagr (see WithNamespaces).
+	Namespaces []string
+
+	// LazyNamespaces are Flagr tags whose flags are loaded the first time
+	// one of them is requested (see WithLazyNamespaces).
+	LazyNamespaces []string
 }
 
 // CircuitBreakerConfig configures the circuit breaker.
@@ -106,3 +114,29 @@ func DefaultConfig() Config {
 		FallbackStrategy: "fail_closed",
 	}
 }
+
+// SourceConfig configures an additional flag source (see WithSource): a
+// Flagr instance with its own filters and refresh interval, cached
+// separately from the client's other sources.
+type SourceConfig struct {
+	// Name identifies the source in Context.WithSource, metrics and the
+	// admin API (required, unique)
+	Name string
+
+	// Prefix routes flag keys that start with it to the source, such as
+	// "eu/" for "eu/new-checkout". The prefix is removed before the flag is
+	// looked up in the source. Without a prefix, the source is only used
+	// through Context.WithSource.
+	Prefix string
+
+	// Flagr is the connection to the source's Flagr; Timeout and
+	// MaxRetries default to the client's
+	Flagr FlagrConfig
+
+	// RefreshInterval defaults to the client's
+	RefreshInterval time.Duration
+
+	// Filter configures which flags of the source are cached; the client's
+	// filter options do not apply to additional sources
+	Filter FilterConfig
+}
@@ -0,0 +1,80 @@
+package vexilla
+
+import (
+	"context"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/cache"
+)
+
+type evaluationsKey struct{}
+
+// evaluations is the request scope attached by WithEvaluations
+type evaluations struct {
+	memo    *cache.Memo
+	sources []*source
+}
+
+// EvaluatedFlag is a flag evaluated in a request scope (see WithEvaluations).
+type EvaluatedFlag struct {
+	// FlagKey is the key of the flag, with its source prefix
+	FlagKey  string
+	EntityID string
+
+	// Result is the result pinned for the rest of the request
+	Result *Result
+}
+
+// WithEvaluations returns a copy of ctx carrying a request-scoped
+// evaluation cache. The first successful result of every flag and entity
+// evaluated with the returned context is pinned: later evaluations in the
+// same request return it even if the flags were refreshed meanwhile, and
+// remote flags reach Flagr only once. HTTPMiddleware attaches one to every
+// request.
+//
+// A context already carrying a request scope is returned unchanged.
+func (c *Client) WithEvaluations(ctx context.Context) context.Context {
+	if cache.MemoFrom(ctx) != nil {
+		return ctx
+	}
+
+	memo := cache.NewMemo()
+	ctx = cache.WithMemo(ctx, memo)
+	return context.WithValue(ctx, evaluationsKey{}, &evaluations{memo: memo, sources: c.sources})
+}
+
+// EvaluatedFlags returns the flags evaluated so far in the request scope
+// of ctx, in evaluation order, for logging or response headers. It returns
+// nil when ctx carries no request scope.
+//
+// Example:
+//
+//	for _, f := range vexilla.EvaluatedFlags(r.Context()) {
+//	    log.Printf("flag %s=%s for %s", f.FlagKey, f.Result.VariantKey, f.EntityID)
+//	}
+func EvaluatedFlags(ctx context.Context) []EvaluatedFlag {
+	scope, ok := ctx.Value(evaluationsKey{}).(*evaluations)
+	if !ok {
+		return nil
+	}
+
+	entries := scope.memo.Entries()
+	flags := make([]EvaluatedFlag, len(entries))
+	for i, entry := range entries {
+		flags[i] = EvaluatedFlag{
+			FlagKey:  scope.prefix(entry.Cache) + entry.FlagKey,
+			EntityID: entry.EntityID,
+			Result:   toResult(entry.Result),
+		}
+	}
+	return flags
+}
+
+// prefix returns the key prefix of the source using the cache
+func (e *evaluations) prefix(c *cache.Cache) string {
+	for _, s := range e.sources {
+		if s.cache == c {
+			return s.prefix
+		}
+	}
+	return ""
+}
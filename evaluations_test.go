@@ -0,0 +1,89 @@
+package vexilla
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithEvaluations(t *testing.T) {
+	us := flagrtest.NewServer(themeFlag(1, "light"))
+	defer us.Close()
+	eu := flagrtest.NewServer(themeFlag(1, "eu"))
+	defer eu.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(us.URL),
+		WithRefreshInterval(0),
+		WithSource(SourceConfig{Name: "eu", Prefix: "eu/", Flagr: FlagrConfig{Endpoint: eu.URL}}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	assert.Nil(t, EvaluatedFlags(ctx))
+
+	reqCtx := client.WithEvaluations(ctx)
+	assert.Same(t, reqCtx, client.WithEvaluations(reqCtx), "scopes are not nested")
+
+	evalCtx := NewContext("user-1")
+	assert.Equal(t, "light", client.String(reqCtx, "theme", evalCtx, ""))
+	assert.Equal(t, "eu", client.String(reqCtx, "theme", evalCtx.WithSource("eu"), ""))
+
+	// A refresh lands mid-request
+	us.SetFlags([]domain.Flag{themeFlag(1, "dark")})
+	require.NoError(t, client.InvalidateAll(ctx))
+	require.NoError(t, client.Sync(ctx))
+	require.Eventually(t, func() bool {
+		return client.String(ctx, "theme", evalCtx, "") == "dark"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "light", client.String(reqCtx, "theme", evalCtx, ""), "pinned for the rest of the request")
+	result, err := client.Evaluate(reqCtx, "theme", evalCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "light", result.VariantKey)
+
+	flags := EvaluatedFlags(reqCtx)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "theme", flags[0].FlagKey)
+	assert.Equal(t, "user-1", flags[0].EntityID)
+	assert.Equal(t, "light", flags[0].Result.VariantKey)
+	assert.Equal(t, "eu/theme", flags[1].FlagKey, "keys carry the source prefix")
+}
+
+func TestClient_HTTPMiddlewareEvaluations(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	client, err := New(WithFlagrEndpoint(srv.URL), WithRefreshInterval(0))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	handler := client.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		evalCtx := ContextFromRequest(r)
+		client.String(r.Context(), "theme", evalCtx, "")
+		client.String(r.Context(), "theme", evalCtx, "")
+		client.Bool(r.Context(), "missing", evalCtx)
+
+		for _, f := range EvaluatedFlags(r.Context()) {
+			w.Header().Add("X-Flags", f.FlagKey+"="+f.Result.VariantKey)
+		}
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, []string{"theme=light"}, rec.Header().Values("X-Flags"), "failed evaluations are not pinned")
+}
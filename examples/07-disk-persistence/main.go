@@ -168,7 +168,7 @@ func main() {
 
 	fmt.Println("⚠️  Trade-offs:")
 	fmt.Println("   • Disk I/O overhead (mitigated by async writes)")
-	fmt.Println("   • Potential stale data (mitigated by TTL + background refresh)")
+	fmt.Println("   • Potential stale data (mitigated by background refresh and WithMaxStaleness)")
 	fmt.Println("   • Disk space usage (minimal: ~1KB per flag)")
 	fmt.Println()
 
@@ -0,0 +1,127 @@
+package vexilla
+
+import (
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// Explanation is a full trace of a flag evaluation.
+// It answers "why did this entity get this variant?".
+type Explanation struct {
+	// FlagKey is the key of the evaluated flag
+	FlagKey string `json:"flag_key"`
+
+	// FlagID is the Flagr ID of the flag (0 when the flag is not cached)
+	FlagID int64 `json:"flag_id"`
+
+	// Enabled reports whether the flag is enabled
+	Enabled bool `json:"enabled"`
+
+	// Strategy is "local" or "remote"
+	Strategy string `json:"strategy"`
+
+	// StrategyReason explains why the strategy was chosen
+	StrategyReason string `json:"strategy_reason"`
+
+	// Segments lists every segment in rank order
+	Segments []SegmentExplanation `json:"segments"`
+
+	// Result is the final evaluation result (nil if evaluation failed)
+	Result *Result `json:"result,omitempty"`
+}
+
+// SegmentExplanation describes how a single segment was evaluated.
+type SegmentExplanation struct {
+	SegmentID      int64  `json:"segment_id"`
+	Rank           int    `json:"rank"`
+	Description    string `json:"description,omitempty"`
+	RolloutPercent int    `json:"rollout_percent"`
+
+	// Matched is true when every constraint passed
+	Matched bool `json:"matched"`
+
+	// Selected is true for the segment that produced the result
+	Selected bool `json:"selected"`
+
+	Constraints []ConstraintExplanation `json:"constraints"`
+
+	// Distribution is the chosen distribution of the selected segment
+	Distribution *DistributionExplanation `json:"distribution,omitempty"`
+
+	// Message is Flagr's debug message for remote evaluations
+	Message string `json:"message,omitempty"`
+}
+
+// ConstraintExplanation describes how a single constraint was evaluated.
+type ConstraintExplanation struct {
+	Property string `json:"property"`
+	Operator string `json:"operator"`
+	Expected any    `json:"expected"`
+
+	// Actual is the value found in the context (nil when Present is false)
+	Actual  any  `json:"actual"`
+	Present bool `json:"present"`
+
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DistributionExplanation describes the distribution that selected the variant.
+type DistributionExplanation struct {
+	DistributionID int64  `json:"distribution_id"`
+	VariantID      int64  `json:"variant_id"`
+	VariantKey     string `json:"variant_key"`
+	Percent        int    `json:"percent"`
+}
+
+func toExplanation(t *domain.EvaluationTrace) *Explanation {
+	exp := &Explanation{
+		FlagKey:        t.FlagKey,
+		FlagID:         t.FlagID,
+		Enabled:        t.Enabled,
+		Strategy:       string(t.Strategy),
+		StrategyReason: t.StrategyReason,
+		Segments:       make([]SegmentExplanation, 0, len(t.Segments)),
+	}
+
+	if t.Result != nil {
+		exp.Result = toResult(t.Result)
+	}
+
+	for _, seg := range t.Segments {
+		segExp := SegmentExplanation{
+			SegmentID:      seg.SegmentID,
+			Rank:           seg.Rank,
+			Description:    seg.Description,
+			RolloutPercent: seg.RolloutPercent,
+			Matched:        seg.Matched,
+			Selected:       seg.Selected,
+			Constraints:    make([]ConstraintExplanation, 0, len(seg.Constraints)),
+			Message:        seg.Message,
+		}
+
+		for _, c := range seg.Constraints {
+			segExp.Constraints = append(segExp.Constraints, ConstraintExplanation{
+				Property: c.Property,
+				Operator: string(c.Operator),
+				Expected: c.Expected,
+				Actual:   c.Actual,
+				Present:  c.Present,
+				Passed:   c.Passed,
+				Error:    c.Error,
+			})
+		}
+
+		if seg.Distribution != nil {
+			segExp.Distribution = &DistributionExplanation{
+				DistributionID: seg.Distribution.DistributionID,
+				VariantID:      seg.Distribution.VariantID,
+				VariantKey:     seg.Distribution.VariantKey,
+				Percent:        seg.Distribution.Percent,
+			}
+		}
+
+		exp.Segments = append(exp.Segments, segExp)
+	}
+
+	return exp
+}
@@ -0,0 +1,107 @@
+package vexilla
+
+import (
+	"errors"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+)
+
+// Flag definitions used by FlagrAdmin.Plan and FlagrAdmin.Apply. They use
+// the same format as flag files (see WithFlagFile).
+type (
+	FlagDefinition       = flagr.FlagDefinition
+	SegmentDefinition    = flagr.SegmentDefinition
+	ConstraintDefinition = flagr.ConstraintDefinition
+)
+
+// LoadFlagDefinitions reads flag definitions from a YAML or JSON file, or
+// from every such file under a directory. Definitions are validated by
+// FlagrAdmin.Plan and FlagrAdmin.Apply.
+func LoadFlagDefinitions(path string) ([]FlagDefinition, error) {
+	return flagr.LoadDefinitions(path)
+}
+
+// Plans produced by FlagrAdmin.Plan and FlagrAdmin.Apply
+type (
+	FlagrPlan         = flagr.Plan
+	FlagrChange       = flagr.Change
+	FlagrChangeAction = flagr.ChangeAction
+	ApplyOptions      = flagr.ApplyOptions
+)
+
+// Change actions
+const (
+	ChangeCreate = flagr.ChangeCreate
+	ChangeUpdate = flagr.ChangeUpdate
+	ChangeDelete = flagr.ChangeDelete
+)
+
+// Flagr API models and request bodies used by FlagrAdmin
+type (
+	FlagrFlag           = flagr.FlagrFlag
+	FlagrVariant        = flagr.FlagrVariant
+	FlagrSegment        = flagr.FlagrSegment
+	FlagrConstraint     = flagr.FlagrConstraint
+	FlagrDistribution   = flagr.FlagrDistribution
+	FlagrTag            = flagr.Tag
+	CreateFlagRequest   = flagr.CreateFlagRequest
+	UpdateFlagRequest   = flagr.UpdateFlagRequest
+	VariantRequest      = flagr.VariantRequest
+	SegmentRequest      = flagr.SegmentRequest
+	ConstraintRequest   = flagr.ConstraintRequest
+	DistributionRequest = flagr.DistributionRequest
+)
+
+// FlagrAdmin creates, updates and deletes flags in Flagr.
+//
+// Besides one method per Flagr resource (flags, tags, variants, segments,
+// constraints and distributions), Apply makes Flagr match a list of flag
+// definitions, changing only what differs:
+//
+//	admin, err := vexilla.NewFlagrAdmin(
+//	    vexilla.WithFlagrEndpoint("http://localhost:18000"),
+//	)
+//
+//	plan, err := admin.Apply(ctx, []vexilla.FlagDefinition{
+//	    {
+//	        Key:      "new-checkout",
+//	        Variants: map[string]map[string]any{"on": {"enabled": true}},
+//	        Segments: []vexilla.SegmentDefinition{{
+//	            Constraints: []vexilla.ConstraintDefinition{
+//	                {Property: "country", Operator: "EQ", Value: "BR"},
+//	            },
+//	            Variant: "on",
+//	        }},
+//	    },
+//	}, vexilla.ApplyOptions{})
+//	fmt.Print(plan)
+//
+// Plan returns the same changes without making them.
+type FlagrAdmin struct {
+	*flagr.AdminClient
+}
+
+// NewFlagrAdmin creates a Flagr admin client. Only the Flagr connection
+// options (WithFlagrEndpoint, WithFlagrAPIKey, WithFlagrTimeout and
+// WithFlagrMaxRetries) are used; WithFlagrEndpoint is required.
+func NewFlagrAdmin(opts ...Option) (*FlagrAdmin, error) {
+	cfg := &clientConfig{}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.flagrEndpoint == "" {
+		return nil, errors.New("flagr endpoint is required")
+	}
+
+	return &FlagrAdmin{
+		AdminClient: flagr.NewAdminClient(flagr.Config{
+			Endpoint:   cfg.flagrEndpoint,
+			APIKey:     cfg.flagrAPIKey,
+			Timeout:    cfg.flagrTimeout,
+			MaxRetries: cfg.flagrMaxRetries,
+		}),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package vexilla
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFlagrAdmin(t *testing.T) {
+	_, err := NewFlagrAdmin()
+	assert.Error(t, err, "endpoint is required")
+
+	_, err = NewFlagrAdmin(WithFlagrEndpoint(""))
+	assert.Error(t, err)
+}
+
+func TestFlagrAdmin_Apply(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+
+	admin, err := NewFlagrAdmin(WithFlagrEndpoint(srv.URL), WithFlagrTimeout(time.Second))
+	require.NoError(t, err)
+
+	defs := []FlagDefinition{{
+		Key:      "new-checkout",
+		Variants: map[string]map[string]any{"on": {"enabled": true}},
+		Segments: []SegmentDefinition{{
+			Constraints: []ConstraintDefinition{{Property: "country", Operator: "EQ", Value: "BR"}},
+			Variant:     "on",
+		}},
+	}}
+
+	ctx := context.Background()
+	plan, err := admin.Apply(ctx, defs, ApplyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ChangeCreate, plan.Changes[0].Action)
+
+	// The applied flag is served to clients
+	client, err := New(WithFlagrEndpoint(srv.URL), WithFlagrMaxRetries(0))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	assert.True(t, client.Bool(ctx, "new-checkout", NewContext("u1").WithAttribute("country", "BR")))
+
+	plan, err = admin.Plan(ctx, defs, ApplyOptions{})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty())
+}
+
+func TestLoadFlagDefinitions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("flags:\n  dark-mode:\n    segments: [{variant: enabled}]\n"), 0o644))
+
+	defs, err := LoadFlagDefinitions(path)
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+	assert.Equal(t, "dark-mode", defs[0].Key)
+}
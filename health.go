@@ -0,0 +1,164 @@
+package vexilla
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Health is the state of the client reported by Client.Health and the
+// admin health endpoints.
+type Health struct {
+	// Status is "healthy" when Ready, "unhealthy" otherwise
+	Status string `json:"status"`
+
+	// Ready reports whether every source meets the readiness thresholds
+	// (see WithReadiness) and reaches Flagr: an open circuit breaker or a
+	// failed Flagr health check makes a source not ready
+	Ready bool `json:"ready"`
+
+	// Reasons explains why the client is not ready
+	Reasons []string `json:"reasons,omitempty"`
+
+	// Sources is the health of every flag source, the default first
+	Sources []SourceHealth `json:"sources"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SourceHealth is the health of one flag source.
+type SourceHealth struct {
+	Name string `json:"name"`
+
+	// LastSync is when flags were last fetched successfully, zero when the
+	// source never synced (e.g. it serves its disk snapshot); SyncAge is
+	// how long ago that was
+	LastSync time.Time     `json:"-"`
+	SyncAge  time.Duration `json:"-"`
+
+	ConsecutiveFailures int  `json:"consecutive_failures"`
+	CircuitOpen         bool `json:"circuit_open"`
+
+	// Flags is the number of cached flags
+	Flags int `json:"flags"`
+
+	// Snapshot is where the cached flags came from: "live" (Flagr), "file"
+	// (WithFlagFile) or "bootstrap" (disk snapshot restored at Start because
+	// Flagr was unreachable, until the first successful sync); empty before
+	// the first load
+	Snapshot string `json:"snapshot"`
+
+	// Flagr is the result of the Flagr health check: "reachable" or the
+	// error; empty when it was not checked
+	Flagr string `json:"flagr,omitempty"`
+}
+
+// MarshalJSON writes LastSync as RFC 3339 (null when never synced) and
+// SyncAge as a duration string
+func (h SourceHealth) MarshalJSON() ([]byte, error) {
+	type plain SourceHealth
+	out := struct {
+		plain
+		LastSync *time.Time `json:"last_sync"`
+		SyncAge  string     `json:"sync_age,omitempty"`
+	}{plain: plain(h)}
+
+	if !h.LastSync.IsZero() {
+		out.LastSync = &h.LastSync
+		out.SyncAge = h.SyncAge.Round(time.Millisecond).String()
+	}
+	return json.Marshal(out)
+}
+
+// readiness holds the thresholds of WithReadiness
+type readiness struct {
+	maxStaleness time.Duration
+	minFlags     int
+}
+
+// Health reports the sync state of every source, calls the Flagr health
+// check of each, and whether the readiness thresholds (see WithReadiness)
+// are met.
+//
+// The admin server exposes it on /health (with the Flagr check),
+// /healthz/ready (without) and /healthz/live, for Kubernetes probes:
+//
+//	livenessProbe:
+//	  httpGet: {path: /healthz/live, port: 19000}
+//	readinessProbe:
+//	  httpGet: {path: /healthz/ready, port: 19000}
+func (c *Client) Health(ctx context.Context) Health {
+	return c.readiness.check(ctx, c.sources, true)
+}
+
+// check builds the health of sources; checkFlagr also calls Flagr
+func (r readiness) check(ctx context.Context, sources []*source, checkFlagr bool) Health {
+	now := time.Now()
+	health := Health{
+		Ready:     true,
+		Sources:   make([]SourceHealth, len(sources)),
+		Timestamp: now,
+	}
+
+	for i, s := range sources {
+		m := s.cache.GetMetrics()
+		sh := SourceHealth{
+			Name:                s.name,
+			LastSync:            m.LastRefresh,
+			ConsecutiveFailures: m.ConsecutiveFails,
+			CircuitOpen:         m.CircuitOpen,
+			Flags:               m.Flags,
+			Snapshot:            m.Snapshot,
+		}
+		if !m.LastRefresh.IsZero() {
+			sh.SyncAge = now.Sub(m.LastRefresh)
+		}
+
+		if checkFlagr {
+			sh.Flagr = "reachable"
+			if err := s.cache.HealthCheck(ctx); err != nil {
+				sh.Flagr = err.Error()
+			}
+		}
+
+		for _, reason := range r.unmet(sh) {
+			health.Ready = false
+			health.Reasons = append(health.Reasons, fmt.Sprintf("%s: %s", s.name, reason))
+		}
+		health.Sources[i] = sh
+	}
+
+	health.Status = "healthy"
+	if !health.Ready {
+		health.Status = "unhealthy"
+	}
+	return health
+}
+
+// unmet returns why a source is not ready
+func (r readiness) unmet(sh SourceHealth) []string {
+	if sh.Snapshot == "" {
+		return []string{"no flags loaded"}
+	}
+
+	var reasons []string
+	if sh.CircuitOpen {
+		reasons = append(reasons, fmt.Sprintf("circuit breaker open after %d failures", sh.ConsecutiveFailures))
+	}
+	if sh.Flagr != "" && sh.Flagr != "reachable" {
+		reasons = append(reasons, "flagr unreachable: "+sh.Flagr)
+	}
+	if sh.Flags < r.minFlags {
+		reasons = append(reasons, fmt.Sprintf("%d flags cached, %d required", sh.Flags, r.minFlags))
+	}
+	if r.maxStaleness > 0 {
+		switch {
+		case sh.LastSync.IsZero():
+			reasons = append(reasons, fmt.Sprintf("never synced, serving the %s snapshot", sh.Snapshot))
+		case sh.SyncAge > r.maxStaleness:
+			reasons = append(reasons, fmt.Sprintf("last sync %s ago exceeds %s", sh.SyncAge.Round(time.Second), r.maxStaleness))
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,202 @@
+package vexilla
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/storage"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Health(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	client, err := New(WithFlagrEndpoint(srv.URL), WithRefreshInterval(0), WithReadiness(time.Minute, 2))
+	require.NoError(t, err)
+
+	health := client.Health(context.Background())
+	assert.False(t, health.Ready)
+	assert.Equal(t, []string{"default: no flags loaded"}, health.Reasons)
+
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	health = client.Health(context.Background())
+	assert.False(t, health.Ready)
+	assert.Equal(t, "unhealthy", health.Status)
+	assert.Equal(t, []string{"default: 1 flags cached, 2 required"}, health.Reasons)
+	require.Len(t, health.Sources, 1)
+	sh := health.Sources[0]
+	assert.Equal(t, "default", sh.Name)
+	assert.Equal(t, "live", sh.Snapshot)
+	assert.Equal(t, "reachable", sh.Flagr)
+	assert.Equal(t, 1, sh.Flags)
+	assert.Less(t, sh.SyncAge, time.Minute)
+
+	banner := themeFlag(2, "on")
+	banner.Key = "banner"
+	srv.SetFlags([]domain.Flag{themeFlag(1, "light"), banner})
+	require.NoError(t, client.Sync(context.Background()))
+
+	health = client.Health(context.Background())
+	assert.True(t, health.Ready)
+	assert.Equal(t, "healthy", health.Status)
+	assert.Empty(t, health.Reasons)
+
+	// Flagr going down makes the client unhealthy, even with cached flags
+	srv.FailWith(http.StatusServiceUnavailable)
+	assert.Error(t, client.Sync(context.Background()))
+
+	health = client.Health(context.Background())
+	assert.False(t, health.Ready)
+	assert.Equal(t, "unhealthy", health.Status)
+	assert.Equal(t, 1, health.Sources[0].ConsecutiveFailures)
+	assert.NotEqual(t, "reachable", health.Sources[0].Flagr)
+	require.Len(t, health.Reasons, 1)
+	assert.Contains(t, health.Reasons[0], "default: flagr unreachable")
+}
+
+func TestClient_HealthEndpoints_FlagrDown(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	// No WithReadiness: the defaults must still report the outage
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithRefreshInterval(0),
+		WithFlagrMaxRetries(0),
+		WithCircuitBreaker(1, time.Minute),
+		WithAdminServer(AdminConfig{DisableServer: true}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	handler := client.AdminHandler()
+	get := func(path string) (int, Health) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		var health Health
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+		return rec.Code, health
+	}
+
+	code, health := get("/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", health.Status)
+
+	srv.FailWith(http.StatusServiceUnavailable)
+	assert.Error(t, client.Sync(context.Background()))
+
+	code, health = get("/health")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", health.Status)
+	assert.True(t, health.Sources[0].CircuitOpen)
+	assert.Contains(t, health.Reasons, "default: circuit breaker open after 1 failures")
+
+	code, health = get("/healthz/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, []string{"default: circuit breaker open after 1 failures"}, health.Reasons)
+}
+
+func TestClient_Health_Bootstrap(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+	srv.FailWith(http.StatusServiceUnavailable)
+
+	// Flagr is down at start: the disk snapshot is served
+	dir := t.TempDir()
+	require.NoError(t, storage.WriteSnapshotFile(filepath.Join(dir, storage.SnapshotFile),
+		map[string]domain.Flag{"theme": themeFlag(1, "dark")}))
+	disk, err := storage.NewDiskStorage(dir)
+	require.NoError(t, err)
+
+	client, err := New(WithFlagrEndpoint(srv.URL), WithRefreshInterval(0), WithFlagrMaxRetries(0), withStorage(disk))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	sh := client.Health(context.Background()).Sources[0]
+	assert.Equal(t, "bootstrap", sh.Snapshot)
+	assert.Equal(t, 1, sh.Flags)
+	assert.True(t, sh.LastSync.IsZero())
+
+	// The first successful sync makes the flags live
+	srv.Recover()
+	require.NoError(t, client.Sync(context.Background()))
+
+	sh = client.Health(context.Background()).Sources[0]
+	assert.Equal(t, "live", sh.Snapshot)
+	assert.False(t, sh.LastSync.IsZero())
+}
+
+func TestClient_Health_MaxStaleness(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	client, err := New(WithFlagrEndpoint(srv.URL), WithRefreshInterval(0), WithReadiness(50*time.Millisecond, 0))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	require.NoError(t, client.Sync(context.Background()))
+	assert.True(t, client.Health(context.Background()).Ready)
+
+	require.Eventually(t, func() bool {
+		return !client.Health(context.Background()).Ready
+	}, time.Second, 10*time.Millisecond)
+	assert.Contains(t, client.Health(context.Background()).Reasons[0], "exceeds 50ms")
+}
+
+func TestClient_HealthEndpoints(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "light"))
+	defer srv.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithRefreshInterval(0),
+		WithReadiness(0, 2),
+		WithAdminServer(AdminConfig{DisableServer: true}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	handler := client.AdminHandler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz/live").Code)
+
+	rec := get("/healthz/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var body struct {
+		Status  string
+		Ready   bool
+		Sources []map[string]interface{}
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "unhealthy", body.Status)
+	require.Len(t, body.Sources, 1)
+	assert.Equal(t, "live", body.Sources[0]["snapshot"])
+	assert.NotEmpty(t, body.Sources[0]["last_sync"])
+	assert.NotEmpty(t, body.Sources[0]["sync_age"])
+	assert.NotContains(t, body.Sources[0], "flagr", "readiness does not call Flagr")
+
+	rec = get("/health")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "reachable", body.Sources[0]["flagr"])
+}
@@ -1,10 +1,14 @@
 package vexilla
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -376,45 +380,121 @@ func BenchmarkFacade_ResultGetString(b *testing.B) {
 	}
 }
 
-// TestFacade_Integration tests full integration with mock Flagr.
-// This test requires a running Flagr instance or mock.
+// TestFacade_Integration tests full integration against the embedded
+// Flagr-compatible server.
 func TestFacade_Integration(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
+	srv := flagrtest.NewServer(integrationFlags()...)
+	defer srv.Close()
 
-	// This test would require a mock Flagr server
-	// For now, we skip it but provide the structure
-	t.Skip("Integration test requires mock Flagr server")
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithFlagrMaxRetries(0),
+		WithOnlyEnabled(true),
+	)
+	require.NoError(t, err)
 
-	// Example integration test structure:
-	/*
-		// Start mock Flagr server
-		mockServer := httptest.NewServer(...)
-		defer mockServer.Close()
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
 
-		// Create client
-		client, err := New(
-			WithFlagrEndpoint(mockServer.URL),
-			WithOnlyEnabled(true),
-		)
-		require.NoError(t, err)
-
-		// Start client
-		ctx := context.Background()
-		err = client.Start(ctx)
-		require.NoError(t, err)
-		defer client.Stop()
-
-		// Test evaluations
-		evalCtx := NewContext("user-123")
-		enabled := client.Bool(ctx, "test-flag", evalCtx)
-		assert.True(t, enabled)
-
-		// Test metrics
-		metrics := client.Metrics()
-		assert.Greater(t, metrics.Storage.KeysAdded, uint64(0))
-	*/
+	// Local evaluation from the cache
+	assert.True(t, client.Bool(ctx, "test-flag", NewContext("user-123")))
+
+	// Remote evaluation for percentage rollouts
+	result, err := client.Evaluate(ctx, "gradual-rollout", NewContext("user-123"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.VariantKey)
+	assert.Positive(t, srv.Requests("/api/v1/evaluation"))
+
+	// Disabled flags are filtered out of the cache
+	assert.False(t, client.Bool(ctx, "disabled-flag", NewContext("user-123")))
+
+	metrics := client.Metrics()
+	assert.False(t, metrics.CircuitOpen)
+	assert.False(t, metrics.LastRefresh.IsZero())
+}
+
+// TestFacade_CircuitBreakerIntegration opens the circuit breaker by making
+// the embedded Flagr server fail, and checks cached flags keep being served.
+func TestFacade_CircuitBreakerIntegration(t *testing.T) {
+	srv := flagrtest.NewServer(integrationFlags()...)
+	defer srv.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithFlagrMaxRetries(0),
+		WithCircuitBreaker(2, time.Minute),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	srv.FailWith(http.StatusServiceUnavailable)
+	assert.Error(t, client.Sync(ctx))
+	assert.Error(t, client.Sync(ctx))
+	assert.True(t, client.Metrics().CircuitOpen)
+
+	// While open, refreshes fail fast without reaching Flagr
+	requests := srv.TotalRequests()
+	err = client.Sync(ctx)
+	assert.True(t, domain.IsCircuitOpen(err), "got %v", err)
+	assert.Equal(t, requests, srv.TotalRequests())
+
+	// Cached flags are still served
+	assert.True(t, client.Bool(ctx, "test-flag", NewContext("user-123")))
+
+	// Rate limiting is treated as a failure as well
+	srv.Recover()
+	srv.FailNext(1, http.StatusTooManyRequests)
+	other, err := New(WithFlagrEndpoint(srv.URL), WithFlagrMaxRetries(0))
+	require.NoError(t, err)
+	assert.Error(t, other.Sync(ctx))
+	require.NoError(t, other.Sync(ctx))
+}
+
+// integrationFlags is the flag set served by the embedded Flagr server
+func integrationFlags() []domain.Flag {
+	return []domain.Flag{
+		{
+			ID:      1,
+			Key:     "test-flag",
+			Enabled: true,
+			Variants: []domain.Variant{
+				{ID: 1, Key: "enabled", Attachment: map[string]json.RawMessage{"enabled": json.RawMessage("true")}},
+			},
+			Segments: []domain.Segment{
+				{ID: 1, Rank: 1, RolloutPercent: 100, Distributions: []domain.Distribution{{ID: 1, VariantID: 1, Percent: 100}}},
+			},
+		},
+		{
+			ID:      2,
+			Key:     "gradual-rollout",
+			Enabled: true,
+			Variants: []domain.Variant{
+				{ID: 1, Key: "control"},
+				{ID: 2, Key: "treatment"},
+			},
+			Segments: []domain.Segment{
+				{
+					ID:             2,
+					Rank:           1,
+					RolloutPercent: 100,
+					Distributions: []domain.Distribution{
+						{ID: 2, VariantID: 1, Percent: 50},
+						{ID: 3, VariantID: 2, Percent: 50},
+					},
+				},
+			},
+		},
+		{
+			ID:       3,
+			Key:      "disabled-flag",
+			Enabled:  false,
+			Variants: []domain.Variant{{ID: 1, Key: "enabled"}},
+		},
+	}
 }
 
 // TestFacade_ConfigCombinations tests various config combinations.
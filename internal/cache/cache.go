@@ -3,13 +3,17 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
 	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/override"
 	"github.com/OrlandoBitencourt/vexilla/internal/storage"
 )
 
@@ -20,6 +24,12 @@ type Cache struct {
 	storage     storage.Storage
 	evaluator   evaluator.Evaluator
 
+	// Local overrides consulted before storage
+	overrides *override.Store
+
+	// Called after every evaluation (optional)
+	hook EvaluationHook
+
 	// Configuration
 	config Config
 
@@ -33,12 +43,31 @@ type Cache struct {
 	lastRefresh      time.Time
 	consecutiveFails int
 	circuitOpen      bool
+
+	// Where the cached flags came from (see Snapshot*), empty before the
+	// first load
+	snapshot string
+
+	// Keys of the flags written to storage (Ristretto cannot list keys),
+	// with when Flagr last returned each one
+	keys map[string]time.Time
+
+	// Evaluations of flags past MaxStaleness, served or answered by the
+	// fallback strategy
+	staleServed   atomic.Uint64
+	staleFellBack atomic.Uint64
+
+	// Lazy namespaces loaded so far
+	loaded map[string]bool
 }
 
 // New creates a new cache with the given options
 func New(opts ...Option) (*Cache, error) {
 	c := &Cache{
-		config: DefaultConfig(),
+		config:    DefaultConfig(),
+		keys:      make(map[string]time.Time),
+		loaded:    make(map[string]bool),
+		overrides: override.NewStore(),
 	}
 
 	// Apply options
@@ -73,14 +102,23 @@ func (c *Cache) Start(ctx context.Context) error {
 	loadCtx, cancel := context.WithTimeout(c.ctx, c.config.InitialTimeout)
 	defer cancel()
 
-	if err := c.refreshFlags(loadCtx); err != nil {
+	// A partial load serves the flags it fetched; the next refresh completes it
+	if err := c.refreshFlags(loadCtx); err != nil && !flagr.IsPartial(err) {
 		// Try to load from disk cache as fallback
 		if diskStorage, ok := c.storage.(*storage.DiskStorage); ok {
 			snapshot, loadErr := diskStorage.LoadSnapshot(loadCtx)
 			if loadErr == nil && len(snapshot) > 0 {
+				// The flags are as old as the snapshot; an unknown time
+				// makes them stale
+				savedAt, _ := diskStorage.SnapshotTime()
 				for key, flag := range snapshot {
-					c.storage.Set(loadCtx, key, flag, 0)
+					if c.storage.Set(loadCtx, key, flag, storage.NoExpiration) == nil {
+						c.trackKey(key, savedAt)
+					}
 				}
+				c.mu.Lock()
+				c.snapshot = SnapshotBootstrap
+				c.mu.Unlock()
 			} else {
 				return fmt.Errorf("initial flag load failed and no disk cache available: %w", err)
 			}
@@ -113,7 +151,10 @@ func (c *Cache) Sync(ctx context.Context) error {
 
 // Stop gracefully stops the cache
 func (c *Cache) Stop() error {
-	c.cancel()
+	// Stop may be called on a cache that was only synced, never started
+	if c.cancel != nil {
+		c.cancel()
+	}
 	c.wg.Wait()
 
 	// Save snapshot to disk if available
@@ -134,25 +175,253 @@ func (c *Cache) Stop() error {
 	return c.storage.Close()
 }
 
+// EvaluationHook observes the outcome of every evaluation
+type EvaluationHook func(flagKey string, evalCtx domain.EvaluationContext, result *domain.EvaluationResult, err error)
+
 // Evaluate evaluates a flag for the given context
 func (c *Cache) Evaluate(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
-	// Get flag from storage
-	flag, err := c.storage.Get(ctx, flagKey)
+	result, err := c.memoized(ctx, flagKey, evalCtx)
+	if c.hook != nil {
+		c.hook(flagKey, evalCtx, result, err)
+	}
+	return result, err
+}
+
+// memoized evaluates a flag, returning the result pinned in the memo
+// carried by ctx if any. Failed evaluations are not pinned.
+func (c *Cache) memoized(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
+	memo := MemoFrom(ctx)
+	if memo == nil {
+		return c.evaluate(ctx, flagKey, evalCtx)
+	}
+
+	if result, ok := memo.get(c, flagKey, evalCtx.EntityID); ok {
+		return result, nil
+	}
+	result, err := c.evaluate(ctx, flagKey, evalCtx)
 	if err != nil {
-		if domain.IsNotFound(err) {
-			return c.handleMissingFlag(ctx, flagKey, evalCtx)
-		}
 		return nil, err
 	}
+	return memo.pin(c, flagKey, evalCtx.EntityID, result), nil
+}
+
+func (c *Cache) evaluate(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
+	// Local overrides win over everything else
+	if o, ok := c.matchOverride(flagKey, evalCtx); ok {
+		return o.Result(), nil
+	}
+
+	flag, err := c.lookupFlag(ctx, flagKey)
+	if errors.Is(err, errFlagUnavailable) {
+		return c.applyFallbackStrategy(flagKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.IsStale(flagKey) {
+		return c.evaluateStale(ctx, *flag, evalCtx)
+	}
+	return c.evaluateFlag(ctx, *flag, evalCtx)
+}
+
+// IsStale reports whether Flagr has not returned the flag for longer than
+// MaxStaleness
+func (c *Cache) IsStale(flagKey string) bool {
+	if c.config.MaxStaleness <= 0 {
+		return false
+	}
+
+	c.mu.RLock()
+	syncedAt, ok := c.keys[flagKey]
+	c.mu.RUnlock()
 
+	return ok && time.Since(syncedAt) > c.config.MaxStaleness
+}
+
+// evaluateStale evaluates a flag past MaxStaleness following the stale
+// policy
+func (c *Cache) evaluateStale(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
+	switch c.config.StalePolicy {
+	case StaleFallback:
+		c.staleFellBack.Add(1)
+		return c.applyFallbackStrategy(flag.Key)
+
+	case StaleMark:
+		c.staleServed.Add(1)
+		result, err := c.evaluateFlag(ctx, flag, evalCtx)
+		if result != nil {
+			result.EvaluationReason = ReasonStale
+		}
+		return result, err
+
+	default:
+		c.staleServed.Add(1)
+		return c.evaluateFlag(ctx, flag, evalCtx)
+	}
+}
+
+// evaluateFlag evaluates a flag locally when possible, else via Flagr
+func (c *Cache) evaluateFlag(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
 	// Determine evaluation strategy
-	if c.evaluator.CanEvaluateLocally(*flag) {
+	if c.evaluator.CanEvaluateLocally(flag) {
 		// Evaluate locally
-		return c.evaluator.Evaluate(ctx, *flag, evalCtx)
+		return c.evaluator.Evaluate(ctx, flag, evalCtx)
 	}
 
 	// Evaluate remotely via Flagr
-	return c.evaluateRemote(ctx, flagKey, evalCtx)
+	return c.evaluateRemote(ctx, flag.Key, evalCtx)
+}
+
+// Explain evaluates a flag and returns a trace of the decision.
+//
+// Local flags are traced by the evaluator. For remote flags the constraints
+// are still traced against the cached definition, while the result, the
+// selected segment and Flagr's per-segment debug messages come from a
+// debug-enabled remote evaluation.
+func (c *Cache) Explain(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+	if o, ok := c.matchOverride(flagKey, evalCtx); ok {
+		return c.explainOverride(ctx, o), nil
+	}
+
+	flag, err := c.lookupFlag(ctx, flagKey)
+	if err != nil {
+		if !errors.Is(err, errFlagUnavailable) {
+			return nil, err
+		}
+
+		result, fallbackErr := c.applyFallbackStrategy(flagKey)
+		return &domain.EvaluationTrace{
+			FlagKey:        flagKey,
+			StrategyReason: "flag not found in cache",
+			Result:         result,
+		}, fallbackErr
+	}
+
+	if c.IsStale(flagKey) {
+		return c.explainStale(ctx, *flag, evalCtx)
+	}
+	return c.explainFlag(ctx, *flag, evalCtx)
+}
+
+// explainStale explains a flag past MaxStaleness following the stale
+// policy, as evaluateStale answers it
+func (c *Cache) explainStale(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+	if c.config.StalePolicy == StaleFallback {
+		result, err := c.applyFallbackStrategy(flag.Key)
+		return &domain.EvaluationTrace{
+			FlagKey:        flag.Key,
+			StrategyReason: "flag stale in cache, answered by the fallback strategy",
+			Result:         result,
+		}, err
+	}
+
+	trace, err := c.explainFlag(ctx, flag, evalCtx)
+	if trace == nil {
+		return nil, err
+	}
+	trace.StrategyReason += " (stale)"
+	if c.config.StalePolicy == StaleMark && trace.Result != nil {
+		trace.Result.EvaluationReason = ReasonStale
+	}
+	return trace, err
+}
+
+// explainFlag traces a cached flag, locally when possible, else via Flagr
+func (c *Cache) explainFlag(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+	strategy := evaluator.NewStrategyDeterminer()
+
+	trace, err := c.evaluator.Explain(ctx, flag, evalCtx)
+	if trace == nil {
+		return nil, err
+	}
+	trace.StrategyReason = strategy.GetStrategyReason(flag)
+
+	if c.evaluator.CanEvaluateLocally(flag) {
+		return trace, err
+	}
+
+	// Remote flag: the local result is meaningless, ask Flagr
+	trace.Strategy = domain.StrategyRemote
+	trace.Result = nil
+	for i := range trace.Segments {
+		trace.Segments[i].Selected = false
+		trace.Segments[i].Distribution = nil
+	}
+
+	c.mu.RLock()
+	circuitOpen := c.circuitOpen
+	c.mu.RUnlock()
+	if circuitOpen {
+		trace.StrategyReason += " (circuit open, remote evaluation unavailable)"
+		return trace, domain.NewCircuitOpenError("cannot evaluate remotely")
+	}
+
+	explainer, ok := c.flagrClient.(flagr.Explainer)
+	if !ok {
+		result, err := c.flagrClient.EvaluateFlag(ctx, flag.Key, evalCtx)
+		trace.Result = result
+		c.markRemoteSelection(flag, trace, nil)
+		return trace, err
+	}
+
+	remote, err := explainer.ExplainFlag(ctx, flag.Key, evalCtx)
+	if err != nil {
+		return trace, err
+	}
+
+	trace.Result = remote.Result
+	c.markRemoteSelection(flag, trace, remote.Segments)
+	return trace, nil
+}
+
+// matchOverride returns the local override for the evaluation, if any
+func (c *Cache) matchOverride(flagKey string, evalCtx domain.EvaluationContext) (override.Override, bool) {
+	return c.overrides.Match(flagKey, evalCtx)
+}
+
+// explainOverride builds the trace of an evaluation forced by an override.
+// No segment is evaluated, so the trace only carries the flag and result.
+func (c *Cache) explainOverride(ctx context.Context, o override.Override) *domain.EvaluationTrace {
+	trace := &domain.EvaluationTrace{
+		FlagKey:        o.FlagKey,
+		Strategy:       domain.StrategyLocal,
+		StrategyReason: fmt.Sprintf("forced by %s override for %s", o.Source, o.Describe()),
+		Result:         o.Result(),
+	}
+
+	if flag, err := c.storage.Get(ctx, o.FlagKey); err == nil {
+		trace.FlagID = flag.ID
+		trace.Enabled = flag.Enabled
+		trace.Result.FlagID = flag.ID
+	}
+
+	return trace
+}
+
+// markRemoteSelection merges Flagr's debug output into a locally built trace
+func (c *Cache) markRemoteSelection(flag domain.Flag, trace *domain.EvaluationTrace, remoteSegments []domain.SegmentTrace) {
+	messages := make(map[int64]string, len(remoteSegments))
+	for _, seg := range remoteSegments {
+		messages[seg.SegmentID] = seg.Message
+	}
+
+	for i := range trace.Segments {
+		seg := &trace.Segments[i]
+		seg.Message = messages[seg.SegmentID]
+
+		if trace.Result == nil || trace.Result.SegmentID == 0 || seg.SegmentID != trace.Result.SegmentID {
+			continue
+		}
+
+		seg.Selected = true
+		for _, s := range flag.Segments {
+			if s.ID == seg.SegmentID {
+				seg.Distribution = domain.NewDistributionTrace(flag, s, trace.Result.VariantID)
+				break
+			}
+		}
+	}
 }
 
 // EvaluateBool is a convenience method that returns a boolean result
@@ -244,27 +513,199 @@ func (c *Cache) evaluateRemote(ctx context.Context, flagKey string, evalCtx doma
 	return c.flagrClient.EvaluateFlag(ctx, flagKey, evalCtx)
 }
 
-// handleMissingFlag handles the case when a flag is not in cache
-func (c *Cache) handleMissingFlag(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
-	// Try to fetch from Flagr
+// errFlagUnavailable reports a flag that is neither cached nor fetched from
+// Flagr; the fallback strategy applies
+var errFlagUnavailable = errors.New("flag unavailable")
+
+// lookupFlag returns the cached flag, fetching it from Flagr when it is
+// missing. Evaluate and Explain share it so they agree on every flag.
+func (c *Cache) lookupFlag(ctx context.Context, flagKey string) (*domain.Flag, error) {
+	flag, err := c.storage.Get(ctx, flagKey)
+	switch {
+	case err == nil:
+		return flag, nil
+
+	// Flags of lazy namespaces are missing until they are requested
+	case c.config.FilterConfig.Namespaced() && (domain.IsNotFound(err) || errors.Is(err, storage.ErrNotFound)):
+		return c.fetchMissingNamespacedFlag(ctx, flagKey)
+
+	case domain.IsNotFound(err):
+		return c.fetchMissingFlag(ctx, flagKey)
+
+	default:
+		return nil, err
+	}
+}
+
+// fetchMissingFlag refreshes every flag from Flagr to find one that is not
+// in cache
+func (c *Cache) fetchMissingFlag(ctx context.Context, flagKey string) (*domain.Flag, error) {
+	// Do not pile requests on a Flagr that is already failing
+	c.mu.RLock()
+	circuitOpen := c.circuitOpen
+	c.mu.RUnlock()
+	if circuitOpen {
+		return nil, errFlagUnavailable
+	}
+
+	// Try to fetch from Flagr; a partial fetch may still hold the flag
 	flags, err := c.flagrClient.GetAllFlags(ctx)
-	if err != nil {
-		// Apply fallback strategy
-		return c.applyFallbackStrategy(flagKey)
+	if err != nil && !flagr.IsPartial(err) {
+		return nil, errFlagUnavailable
 	}
 
 	// Update cache
-	for _, flag := range flags {
-		c.storage.Set(ctx, flag.Key, flag, c.config.RefreshInterval)
+	if err := c.storeFlags(ctx, flags); err != nil {
+		return nil, errFlagUnavailable
 	}
 
 	// Try again
 	flag, err := c.storage.Get(ctx, flagKey)
 	if err != nil {
-		return c.applyFallbackStrategy(flagKey)
+		return nil, errFlagUnavailable
+	}
+	return flag, nil
+}
+
+// fetchMissingNamespacedFlag looks a missing flag up in Flagr by key. When
+// it belongs to a lazy namespace that is not loaded yet, the whole namespace
+// is loaded; flags outside the namespaces are unavailable.
+func (c *Cache) fetchMissingNamespacedFlag(ctx context.Context, flagKey string) (*domain.Flag, error) {
+	found, err := flagr.FindFlags(ctx, c.flagrClient, flagr.FlagQuery{Key: flagKey})
+	if err != nil || len(found) == 0 {
+		return nil, errFlagUnavailable
+	}
+	flag := found[0]
+
+	inNamespace, pending := c.namespacesOf(flag)
+	if !inNamespace || !c.config.FilterConfig.ShouldCacheFlag(flagMetadata(flag)) {
+		return nil, errFlagUnavailable
+	}
+
+	if len(pending) > 0 {
+		flags, err := c.fetchNamespaces(ctx, pending)
+		if err != nil {
+			return nil, errFlagUnavailable
+		}
+		found = flags
+	}
+	if err := c.storeFlags(ctx, found); err != nil {
+		return nil, errFlagUnavailable
+	}
+	c.markLoaded(pending)
+
+	// Storage writes may not be visible yet, return the fetched flag
+	return &flag, nil
+}
+
+// namespacesOf reports whether the flag belongs to a configured namespace,
+// and returns its lazy namespaces that are not loaded yet
+func (c *Cache) namespacesOf(flag domain.Flag) (bool, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	filter := c.config.FilterConfig
+	inNamespace := false
+	var pending []string
+	for _, tag := range flag.Tags {
+		if contains(filter.Namespaces, tag.Value) {
+			inNamespace = true
+		}
+		if contains(filter.LazyNamespaces, tag.Value) {
+			inNamespace = true
+			if !c.loaded[tag.Value] {
+				pending = append(pending, tag.Value)
+			}
+		}
+	}
+	return inNamespace, pending
+}
+
+// markLoaded records lazy namespaces as loaded
+func (c *Cache) markLoaded(namespaces []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, ns := range namespaces {
+		c.loaded[ns] = true
+	}
+}
+
+// loadedNamespaces returns the eager namespaces followed by the lazy
+// namespaces loaded so far
+func (c *Cache) loadedNamespaces() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.namespaces()
+}
+
+// fetchFlags fetches every flag, or only the flags of the loaded
+// namespaces when the cache is namespaced
+func (c *Cache) fetchFlags(ctx context.Context) ([]domain.Flag, error) {
+	if !c.config.FilterConfig.Namespaced() {
+		return c.flagrClient.GetAllFlags(ctx)
 	}
+	return c.fetchNamespaces(ctx, c.loadedNamespaces())
+}
+
+// fetchNamespaces fetches the flags of the namespaces, one list query per
+// namespace. A flag in several namespaces is returned once.
+func (c *Cache) fetchNamespaces(ctx context.Context, namespaces []string) ([]domain.Flag, error) {
+	flags := []domain.Flag{}
+	seen := make(map[string]bool)
+	for _, ns := range namespaces {
+		found, err := flagr.FindFlags(ctx, c.flagrClient, flagr.FlagQuery{Tag: ns})
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", ns, err)
+		}
+		for _, flag := range found {
+			if !seen[flag.Key] {
+				seen[flag.Key] = true
+				flags = append(flags, flag)
+			}
+		}
+	}
+	return flags, nil
+}
 
-	return c.evaluator.Evaluate(ctx, *flag, evalCtx)
+// storeFlags writes the flags that pass the filter to storage. They do not
+// expire: the last known good definition is served until Flagr replaces or
+// deletes it.
+func (c *Cache) storeFlags(ctx context.Context, flags []domain.Flag) error {
+	now := time.Now()
+	for _, flag := range flags {
+		if !c.config.FilterConfig.ShouldCacheFlag(flagMetadata(flag)) {
+			continue
+		}
+
+		if err := c.storage.Set(ctx, flag.Key, flag, storage.NoExpiration); err != nil {
+			return fmt.Errorf("failed to cache flag %s: %w", flag.Key, err)
+		}
+		c.trackKey(flag.Key, now)
+	}
+	return nil
+}
+
+// pruneFlags removes the cached flags missing from a complete fetch: Flagr
+// deleted them, or the filter now excludes them
+func (c *Cache) pruneFlags(ctx context.Context, flags []domain.Flag) error {
+	kept := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		if c.config.FilterConfig.ShouldCacheFlag(flagMetadata(flag)) {
+			kept[flag.Key] = true
+		}
+	}
+
+	var errs []error
+	for _, key := range c.FlagKeys() {
+		if !kept[key] {
+			if err := c.InvalidateFlag(ctx, key); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // applyFallbackStrategy applies configured fallback strategy
@@ -310,7 +751,9 @@ func (c *Cache) refreshLoop() {
 		case <-ticker.C:
 			ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
 
-			if err := c.refreshFlags(ctx); err != nil {
+			// Flagr answered a partial fetch, so it doesn't trip the
+			// circuit breaker
+			if err := c.refreshFlags(ctx); err != nil && !flagr.IsPartial(err) {
 				c.handleRefreshError(err)
 			} else {
 				c.resetCircuitBreaker()
@@ -330,8 +773,11 @@ func (c *Cache) refreshFlags(ctx context.Context) error {
 	}
 	c.mu.RUnlock()
 
-	flags, err := c.flagrClient.GetAllFlags(ctx)
-	if err != nil {
+	// A partial fetch still reached Flagr: the flags it returned are stored,
+	// but none is pruned since the ones left out may still exist
+	flags, err := c.fetchFlags(ctx)
+	partial := flagr.IsPartial(err)
+	if err != nil && !partial {
 		// erro → incrementa falhas
 		c.mu.Lock()
 		c.consecutiveFails++
@@ -351,30 +797,123 @@ func (c *Cache) refreshFlags(ctx context.Context) error {
 	c.mu.Unlock()
 
 	// Atualiza o cache
-	for _, flag := range flags {
-		meta := FlagMetadata{
-			Key:     flag.Key,
-			Enabled: flag.Enabled,
-			Tags:    extractTagValues(flag.Tags),
+	if err := c.storeFlags(ctx, flags); err != nil {
+		return err
+	}
+	if !partial {
+		if err := c.pruneFlags(ctx, flags); err != nil {
+			return err
 		}
+	}
 
-		if !c.config.FilterConfig.ShouldCacheFlag(meta) {
+	// Atualiza lastRefresh
+	c.mu.Lock()
+	if !partial {
+		c.lastRefresh = time.Now()
+	}
+	c.snapshot = SnapshotLive
+	if _, ok := c.flagrClient.(*flagr.FileSource); ok {
+		c.snapshot = SnapshotFile
+	}
+	c.mu.Unlock()
+
+	if partial {
+		return fmt.Errorf("incomplete flag fetch, no flag pruned: %w", err)
+	}
+	return nil
+}
+
+// RefreshFlagKeys fetches only the flags with the given keys and IDs and
+// updates them in the cache. Flags Flagr no longer has, or that the filter
+// or namespaces now exclude, are removed.
+func (c *Cache) RefreshFlagKeys(ctx context.Context, keys []string, ids []int64) error {
+	var errs []error
+	for _, key := range keys {
+		flags, err := flagr.FindFlags(ctx, c.flagrClient, flagr.FlagQuery{Key: key})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch flag %s: %w", key, err))
+			continue
+		}
+		if len(flags) == 0 {
+			if err := c.removeFlag(ctx, key); err != nil {
+				errs = append(errs, err)
+			}
 			continue
 		}
+		if err := c.updateFlag(ctx, flags[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-		if err := c.storage.Set(ctx, flag.Key, flag, c.config.RefreshInterval); err != nil {
-			return fmt.Errorf("failed to cache flag %s: %w", flag.Key, err)
+	for _, id := range ids {
+		flag, err := c.flagrClient.GetFlag(ctx, id)
+		switch {
+		case flagr.IsFlagNotFound(err):
+			if err := c.invalidateFlagID(ctx, id); err != nil {
+				errs = append(errs, err)
+			}
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to fetch flag %d: %w", id, err))
+		default:
+			if err := c.updateFlag(ctx, *flag); err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
 
-	// Atualiza lastRefresh
-	c.mu.Lock()
-	c.lastRefresh = time.Now()
-	c.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// updateFlag stores a fetched flag, or removes it when the filter or the
+// loaded namespaces exclude it
+func (c *Cache) updateFlag(ctx context.Context, flag domain.Flag) error {
+	keep := c.config.FilterConfig.ShouldCacheFlag(flagMetadata(flag))
+	if keep && c.config.FilterConfig.Namespaced() {
+		keep = c.inLoadedNamespace(flag)
+	}
+
+	if !keep {
+		return c.removeFlag(ctx, flag.Key)
+	}
+	return c.storeFlags(ctx, []domain.Flag{flag})
+}
+
+// inLoadedNamespace reports whether the flag belongs to an eager or an
+// already loaded lazy namespace
+func (c *Cache) inLoadedNamespace(flag domain.Flag) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, tag := range flag.Tags {
+		if contains(c.namespaces(), tag.Value) {
+			return true
+		}
+	}
+	return false
+}
 
+// invalidateFlagID removes the cached flag with the ID, if any
+func (c *Cache) invalidateFlagID(ctx context.Context, id int64) error {
+	for _, flag := range c.Flags(ctx) {
+		if flag.ID == id {
+			return c.removeFlag(ctx, flag.Key)
+		}
+	}
 	return nil
 }
 
+// removeFlag invalidates a flag if it is cached
+func (c *Cache) removeFlag(ctx context.Context, key string) error {
+	c.mu.RLock()
+	_, cached := c.keys[key]
+	c.mu.RUnlock()
+
+	if !cached {
+		return nil
+	}
+	return c.InvalidateFlag(ctx, key)
+}
+
 // handleRefreshError handles refresh failures
 func (c *Cache) handleRefreshError(err error) {
 	c.mu.Lock()
@@ -406,14 +945,77 @@ func (c *Cache) resetCircuitBreaker() {
 
 // InvalidateFlag removes a flag from cache
 func (c *Cache) InvalidateFlag(ctx context.Context, flagKey string) error {
+	c.mu.Lock()
+	delete(c.keys, flagKey)
+	c.mu.Unlock()
+
 	return c.storage.Delete(ctx, flagKey)
 }
 
 // InvalidateAll clears the entire cache
 func (c *Cache) InvalidateAll(ctx context.Context) error {
+	c.mu.Lock()
+	c.keys = make(map[string]time.Time)
+	c.mu.Unlock()
+
 	return c.storage.Clear(ctx)
 }
 
+// Overrides returns the local override store
+func (c *Cache) Overrides() *override.Store {
+	return c.overrides
+}
+
+// GetFlag returns the cached definition of a flag
+func (c *Cache) GetFlag(ctx context.Context, flagKey string) (*domain.Flag, error) {
+	return c.storage.Get(ctx, flagKey)
+}
+
+// FlagKeys returns the sorted keys of every flag written to the cache.
+// A key may still be listed after its entry was evicted from storage.
+func (c *Cache) FlagKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Flags returns the cached definition of every flag, sorted by key.
+// Flags whose entry was evicted from storage are skipped.
+func (c *Cache) Flags(ctx context.Context) []domain.Flag {
+	keys := c.FlagKeys()
+	flags := make([]domain.Flag, 0, len(keys))
+	for _, key := range keys {
+		if flag, err := c.storage.Get(ctx, key); err == nil {
+			flags = append(flags, *flag)
+		}
+	}
+	return flags
+}
+
+// trackKey records a key written to storage and when Flagr returned it
+func (c *Cache) trackKey(key string, syncedAt time.Time) {
+	c.mu.Lock()
+	c.keys[key] = syncedAt
+	c.mu.Unlock()
+}
+
+// FlagSyncedAt returns when Flagr last returned the cached flag, zero when
+// it was restored from a snapshot of unknown age
+func (c *Cache) FlagSyncedAt(flagKey string) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	syncedAt, ok := c.keys[flagKey]
+	return syncedAt, ok
+}
+
 // GetMetrics returns cache metrics
 func (c *Cache) GetMetrics() Metrics {
 	c.mu.RLock()
@@ -421,12 +1023,46 @@ func (c *Cache) GetMetrics() Metrics {
 
 	storageMetrics := c.storage.Metrics()
 
-	return Metrics{
+	m := Metrics{
 		Storage:          storageMetrics,
 		LastRefresh:      c.lastRefresh,
 		ConsecutiveFails: c.consecutiveFails,
 		CircuitOpen:      c.circuitOpen,
+		Namespaces:       c.namespaces(),
+		Snapshot:         c.snapshot,
+		Flags:            len(c.keys),
+		StaleServed:      c.staleServed.Load(),
+		StaleFellBack:    c.staleFellBack.Load(),
+	}
+
+	first := true
+	for _, syncedAt := range c.keys {
+		if first || syncedAt.Before(m.OldestSync) {
+			m.OldestSync = syncedAt
+			first = false
+		}
+		if c.config.MaxStaleness > 0 && time.Since(syncedAt) > c.config.MaxStaleness {
+			m.StaleFlags++
+		}
+	}
+	return m
+}
+
+// HealthCheck checks if Flagr is reachable
+func (c *Cache) HealthCheck(ctx context.Context) error {
+	return c.flagrClient.HealthCheck(ctx)
+}
+
+// namespaces returns the loaded namespaces, the caller holds c.mu
+func (c *Cache) namespaces() []string {
+	var namespaces []string
+	namespaces = append(namespaces, c.config.FilterConfig.Namespaces...)
+	for _, ns := range c.config.FilterConfig.LazyNamespaces {
+		if c.loaded[ns] {
+			namespaces = append(namespaces, ns)
+		}
 	}
+	return namespaces
 }
 
 // Metrics represents cache metrics
@@ -435,13 +1071,65 @@ type Metrics struct {
 	LastRefresh      time.Time
 	ConsecutiveFails int
 	CircuitOpen      bool
+
+	// Namespaces are the loaded namespaces, eager ones first
+	Namespaces []string
+
+	// Snapshot is where the cached flags came from, empty before the first
+	// load; Flags is how many were cached
+	Snapshot string
+	Flags    int
+
+	// StaleFlags is how many cached flags are past MaxStaleness, and
+	// OldestSync when the least recently synced flag was returned by Flagr
+	StaleFlags int
+	OldestSync time.Time
+
+	// StaleServed and StaleFellBack count the evaluations of stale flags
+	// served and answered by the fallback strategy
+	StaleServed   uint64
+	StaleFellBack uint64
 }
 
+// Where the cached flags came from
+const (
+	// SnapshotLive flags were fetched from Flagr
+	SnapshotLive = "live"
+
+	// SnapshotFile flags were read from a flag file
+	SnapshotFile = "file"
+
+	// SnapshotBootstrap flags were restored from the disk snapshot because
+	// Flagr was unreachable at start; the first successful sync makes them
+	// live
+	SnapshotBootstrap = "bootstrap"
+)
+
 // raw marshals a value into json.RawMessage and ignores marshal errors on purpose
 func marshalRaw(v interface{}) json.RawMessage {
 	b, _ := json.Marshal(v)
 	return json.RawMessage(b)
 }
+
+// flagMetadata returns the metadata the filter rules look at
+func flagMetadata(flag domain.Flag) FlagMetadata {
+	return FlagMetadata{
+		Key:     flag.Key,
+		Enabled: flag.Enabled,
+		Tags:    extractTagValues(flag.Tags),
+	}
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func extractTagValues(tags []domain.Tag) []string {
 	out := make([]string, len(tags))
 	for i, t := range tags {
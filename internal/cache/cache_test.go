@@ -3,12 +3,15 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
 	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/override"
 	"github.com/OrlandoBitencourt/vexilla/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -116,6 +119,62 @@ func TestCache_Start(t *testing.T) {
 	c.Stop()
 }
 
+func TestCache_FlagKeys(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(domain.Flag{ID: 1, Key: "b-flag", Enabled: true})
+	mockFlagr.AddFlag(domain.Flag{ID: 2, Key: "a-flag", Enabled: true})
+	mockFlagr.AddFlag(domain.Flag{ID: 3, Key: "disabled", Enabled: false})
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(storage.NewMockStorage()),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Start(ctx))
+	defer c.Stop()
+
+	// Disabled flag is filtered out by the default OnlyEnabled config
+	assert.Equal(t, []string{"a-flag", "b-flag"}, c.FlagKeys())
+
+	require.NoError(t, c.InvalidateFlag(ctx, "a-flag"))
+	assert.Equal(t, []string{"b-flag"}, c.FlagKeys())
+
+	require.NoError(t, c.InvalidateAll(ctx))
+	assert.Empty(t, c.FlagKeys())
+}
+
+func TestCache_Flags(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(domain.Flag{ID: 1, Key: "b-flag", Enabled: true})
+	mockFlagr.AddFlag(domain.Flag{ID: 2, Key: "a-flag", Enabled: true})
+
+	mockStorage := storage.NewMockStorage()
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(mockStorage),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Start(ctx))
+	defer c.Stop()
+
+	flags := c.Flags(ctx)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "a-flag", flags[0].Key)
+	assert.Equal(t, "b-flag", flags[1].Key)
+
+	// Expired entries are skipped
+	require.NoError(t, mockStorage.Delete(ctx, "a-flag"))
+	flags = c.Flags(ctx)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "b-flag", flags[0].Key)
+}
+
 func TestCache_Evaluate_LocalStrategy(t *testing.T) {
 	mockFlagr := flagr.NewMockClient()
 	mockStorage := storage.NewMockStorage()
@@ -227,6 +286,221 @@ func TestCache_Evaluate_RemoteStrategy(t *testing.T) {
 	mockFlagr.AssertCalled(t, "EvaluateFlag", 1)
 }
 
+func TestCache_Explain_Local(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockStorage := storage.NewMockStorage()
+
+	mockStorage.AddFlag(domain.Flag{
+		ID:      1,
+		Key:     "local-flag",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             1,
+				RolloutPercent: 100,
+				Constraints:    []domain.Constraint{{Property: "country", Operator: domain.OperatorEQ, Value: "BR"}},
+				Distributions:  []domain.Distribution{{VariantID: 1, Percent: 100}},
+			},
+		},
+		Variants: []domain.Variant{{ID: 1, Key: "enabled"}},
+	})
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(mockStorage),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	evalCtx := domain.NewEvaluationContext("u1").WithAttribute("country", "BR")
+	trace, err := c.Explain(context.Background(), "local-flag", evalCtx)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StrategyLocal, trace.Strategy)
+	assert.Equal(t, "100% deterministic based on constraints", trace.StrategyReason)
+	assert.Equal(t, "enabled", trace.Result.VariantKey)
+	require.Len(t, trace.Segments, 1)
+	assert.True(t, trace.Segments[0].Selected)
+
+	mockFlagr.AssertCalled(t, "ExplainFlag", 0)
+}
+
+func TestCache_Explain_Remote(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockStorage := storage.NewMockStorage()
+
+	mockStorage.AddFlag(domain.Flag{
+		ID:      1,
+		Key:     "remote-flag",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             5,
+				RolloutPercent: 100,
+				Constraints:    []domain.Constraint{{Property: "country", Operator: domain.OperatorEQ, Value: "BR"}},
+				Distributions: []domain.Distribution{
+					{ID: 50, VariantID: 1, Percent: 50},
+					{ID: 51, VariantID: 2, Percent: 50},
+				},
+			},
+		},
+		Variants: []domain.Variant{{ID: 1, Key: "control"}, {ID: 2, Key: "treatment"}},
+	})
+
+	mockFlagr.ExplainFlagFunc = func(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+		return &domain.EvaluationTrace{
+			FlagKey: flagKey,
+			Result:  &domain.EvaluationResult{FlagKey: flagKey, SegmentID: 5, VariantID: 2, VariantKey: "treatment"},
+			Segments: []domain.SegmentTrace{
+				{SegmentID: 5, Message: "matched all constraints. rollout yes."},
+			},
+		}, nil
+	}
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(mockStorage),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	evalCtx := domain.NewEvaluationContext("u1").WithAttribute("country", "BR")
+	trace, err := c.Explain(context.Background(), "remote-flag", evalCtx)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.StrategyRemote, trace.Strategy)
+	assert.Equal(t, "A/B testing with multiple variants requires consistent assignment", trace.StrategyReason)
+	assert.Equal(t, "treatment", trace.Result.VariantKey)
+
+	require.Len(t, trace.Segments, 1)
+	seg := trace.Segments[0]
+	assert.True(t, seg.Matched)
+	assert.True(t, seg.Selected)
+	assert.Equal(t, "matched all constraints. rollout yes.", seg.Message)
+	require.NotNil(t, seg.Distribution)
+	assert.Equal(t, "treatment", seg.Distribution.VariantKey)
+	assert.Equal(t, int64(51), seg.Distribution.DistributionID)
+
+	mockFlagr.AssertCalled(t, "ExplainFlag", 1)
+}
+
+func TestCache_Explain_MissingFlag(t *testing.T) {
+	c, err := New(
+		WithFlagrClient(flagr.NewMockClient()),
+		WithStorage(storage.NewMockStorage()),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	trace, err := c.Explain(context.Background(), "missing", domain.EvaluationContext{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "flag not found in cache", trace.StrategyReason)
+	assert.Equal(t, "fallback: fail_closed", trace.Result.EvaluationReason)
+}
+
+func TestCache_Evaluate_Override(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockStorage := storage.NewMockStorage()
+
+	flag := domain.Flag{
+		ID:      7,
+		Key:     "remote-flag",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{ID: 1, RolloutPercent: 50, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}},
+		},
+		Variants: []domain.Variant{{ID: 1, Key: "enabled"}},
+	}
+	mockFlagr.AddFlag(flag)
+	mockStorage.AddFlag(flag)
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(mockStorage),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Overrides().Set(override.Override{
+		FlagKey:           "remote-flag",
+		EntityID:          "qa-1",
+		VariantKey:        "forced",
+		VariantAttachment: map[string]json.RawMessage{"enabled": raw(true)},
+	}))
+
+	result, err := c.Evaluate(context.Background(), "remote-flag", domain.NewEvaluationContext("qa-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "forced", result.VariantKey)
+	assert.Equal(t, override.Reason, result.EvaluationReason)
+	assert.True(t, result.IsEnabled())
+	mockFlagr.AssertCalled(t, "EvaluateFlag", 0)
+
+	// Other entities still go through the normal path
+	result, err = c.Evaluate(context.Background(), "remote-flag", domain.NewEvaluationContext("user-2"))
+	require.NoError(t, err)
+	assert.NotEqual(t, override.Reason, result.EvaluationReason)
+	mockFlagr.AssertCalled(t, "EvaluateFlag", 1)
+
+	// Overrides also apply to flags that are not cached
+	require.NoError(t, c.Overrides().Set(override.Override{FlagKey: "unknown", VariantKey: "on"}))
+	result, err = c.Evaluate(context.Background(), "unknown", domain.NewEvaluationContext("anyone"))
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey)
+}
+
+func TestCache_EvaluationHook(t *testing.T) {
+	var calls []string
+	var lastErr error
+
+	c, err := New(
+		WithFlagrClient(flagr.NewMockClient()),
+		WithStorage(storage.NewMockStorage()),
+		WithEvaluator(evaluator.New()),
+		WithEvaluationHook(func(flagKey string, evalCtx domain.EvaluationContext, result *domain.EvaluationResult, err error) {
+			calls = append(calls, flagKey+"/"+evalCtx.EntityID)
+			lastErr = err
+		}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Overrides().Set(override.Override{FlagKey: "forced", VariantKey: "on"}))
+	_, err = c.Evaluate(context.Background(), "forced", domain.NewEvaluationContext("u1"))
+	require.NoError(t, err)
+	assert.NoError(t, lastErr)
+
+	_, _ = c.Evaluate(context.Background(), "missing", domain.NewEvaluationContext("u2"))
+
+	assert.Equal(t, []string{"forced/u1", "missing/u2"}, calls)
+}
+
+func TestCache_Explain_Override(t *testing.T) {
+	mockStorage := storage.NewMockStorage()
+	mockStorage.AddFlag(domain.Flag{ID: 7, Key: "flag", Enabled: true})
+
+	c, err := New(
+		WithFlagrClient(flagr.NewMockClient()),
+		WithStorage(mockStorage),
+		WithEvaluator(evaluator.New()),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Overrides().Set(override.Override{
+		FlagKey:    "flag",
+		Match:      map[string]interface{}{"tier": "qa"},
+		VariantKey: "forced",
+	}))
+
+	evalCtx := domain.NewEvaluationContext("u1").WithAttribute("tier", "qa")
+	trace, err := c.Explain(context.Background(), "flag", evalCtx)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), trace.FlagID)
+	assert.Equal(t, "forced by runtime override for tier=qa", trace.StrategyReason)
+	assert.Empty(t, trace.Segments)
+	assert.Equal(t, override.Reason, trace.Result.EvaluationReason)
+}
+
 func TestCache_EvaluateBool(t *testing.T) {
 	mockFlagr := flagr.NewMockClient()
 	mockStorage := storage.NewMockStorage()
@@ -384,6 +658,384 @@ func TestCache_CircuitBreaker(t *testing.T) {
 	assert.Equal(t, 3, metrics.ConsecutiveFails)
 }
 
+func TestCache_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	flag := domain.Flag{ID: 1, Key: "a", Enabled: true}
+
+	// A live load is saved to disk on Stop
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(flag)
+	disk, err := storage.NewDiskStorage(dir)
+	require.NoError(t, err)
+	c, err := New(WithFlagrClient(mockFlagr), WithStorage(disk), WithEvaluator(evaluator.New()))
+	require.NoError(t, err)
+
+	assert.Empty(t, c.GetMetrics().Snapshot, "nothing loaded before Start")
+	require.NoError(t, c.Start(context.Background()))
+	metrics := c.GetMetrics()
+	assert.Equal(t, SnapshotLive, metrics.Snapshot)
+	assert.Equal(t, 1, metrics.Flags)
+	require.NoError(t, c.Stop())
+
+	// Flagr down: the disk snapshot is served
+	down := flagr.NewMockClient()
+	down.GetAllFlagsFunc = func(ctx context.Context) ([]domain.Flag, error) {
+		return nil, assert.AnError
+	}
+	down.HealthCheckFunc = func(ctx context.Context) error {
+		return assert.AnError
+	}
+	disk, err = storage.NewDiskStorage(dir)
+	require.NoError(t, err)
+	c, err = New(WithFlagrClient(down), WithStorage(disk), WithEvaluator(evaluator.New()))
+	require.NoError(t, err)
+	require.NoError(t, c.Start(context.Background()))
+	defer c.Stop()
+
+	metrics = c.GetMetrics()
+	assert.Equal(t, SnapshotBootstrap, metrics.Snapshot)
+	assert.Equal(t, 1, metrics.Flags)
+	assert.True(t, metrics.LastRefresh.IsZero())
+	assert.ErrorIs(t, c.HealthCheck(context.Background()), assert.AnError)
+
+	syncedAt, ok := c.FlagSyncedAt("a")
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now(), syncedAt, time.Minute, "restored flags are as old as the snapshot")
+}
+
+// staleFlag is evaluated locally to its "on" variant
+func staleFlag(key string) domain.Flag {
+	return domain.Flag{
+		ID:       1,
+		Key:      key,
+		Enabled:  true,
+		Segments: []domain.Segment{{ID: 1, RolloutPercent: 100, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}}},
+		Variants: []domain.Variant{{ID: 1, Key: "on", Attachment: map[string]json.RawMessage{"enabled": raw(true)}}},
+	}
+}
+
+func TestCache_LastKnownGood(t *testing.T) {
+	var down atomic.Bool
+	var fetches atomic.Int64
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.GetAllFlagsFunc = func(ctx context.Context) ([]domain.Flag, error) {
+		fetches.Add(1)
+		if down.Load() {
+			return nil, assert.AnError
+		}
+		return []domain.Flag{staleFlag("a")}, nil
+	}
+	memory, err := storage.NewMemoryStorage(storage.DefaultConfig())
+	require.NoError(t, err)
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(memory),
+		WithEvaluator(evaluator.New()),
+		WithRefreshInterval(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Start(ctx))
+	defer c.Stop()
+
+	// Flagr goes down for several refresh intervals
+	down.Store(true)
+	time.Sleep(150 * time.Millisecond)
+	require.True(t, c.GetMetrics().CircuitOpen)
+	calls := fetches.Load()
+
+	result, err := c.Evaluate(ctx, "a", domain.EvaluationContext{EntityID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey, "the flag did not expire")
+
+	syncedAt, ok := c.FlagSyncedAt("a")
+	require.True(t, ok)
+	assert.Greater(t, time.Since(syncedAt), 100*time.Millisecond)
+
+	// Missing flags are not fetched while the circuit is open
+	assert.False(t, c.EvaluateBool(ctx, "missing", domain.EvaluationContext{}))
+	assert.Equal(t, calls, fetches.Load())
+}
+
+func TestCache_PruneDeletedFlags(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(staleFlag("a"))
+	b := staleFlag("b")
+	b.ID = 2
+	mockFlagr.AddFlag(b)
+
+	c, err := New(WithFlagrClient(mockFlagr), WithStorage(storage.NewMockStorage()), WithEvaluator(evaluator.New()))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Sync(ctx))
+	assert.Equal(t, []string{"a", "b"}, c.FlagKeys())
+
+	// The details of b could not be fetched: b may still exist
+	mockFlagr.GetAllFlagsFunc = func(ctx context.Context) ([]domain.Flag, error) {
+		return []domain.Flag{staleFlag("a")}, &flagr.PartialError{Failed: map[int64]error{2: errors.New("HTTP 500")}}
+	}
+	err = c.Sync(ctx)
+	assert.True(t, flagr.IsPartial(err))
+	assert.Equal(t, []string{"a", "b"}, c.FlagKeys(), "an incomplete fetch prunes nothing")
+
+	mockFlagr.GetAllFlagsFunc = func(ctx context.Context) ([]domain.Flag, error) {
+		return []domain.Flag{staleFlag("a")}, nil
+	}
+	require.NoError(t, c.Sync(ctx))
+	assert.Equal(t, []string{"a"}, c.FlagKeys(), "flags Flagr deleted are removed")
+}
+
+func TestCache_StalePolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantVariant string
+		wantReason  string
+		served      uint64
+		fellBack    uint64
+	}{
+		{policy: StaleServe, wantVariant: "on", served: 1},
+		{policy: StaleMark, wantVariant: "on", wantReason: ReasonStale, served: 1},
+		{policy: StaleFallback, wantVariant: "disabled", wantReason: "fallback: fail_closed", fellBack: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			mockFlagr := flagr.NewMockClient()
+			mockFlagr.AddFlag(staleFlag("a"))
+			b := staleFlag("b")
+			b.ID = 2
+			mockFlagr.AddFlag(b)
+
+			c, err := New(
+				WithFlagrClient(mockFlagr),
+				WithStorage(storage.NewMockStorage()),
+				WithEvaluator(evaluator.New()),
+				WithMaxStaleness(time.Minute, tt.policy),
+			)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			require.NoError(t, c.Sync(ctx))
+
+			fresh, err := c.Evaluate(ctx, "b", domain.EvaluationContext{EntityID: "u1"})
+			require.NoError(t, err)
+			assert.NotEqual(t, ReasonStale, fresh.EvaluationReason)
+
+			// Flagr last returned "a" two minutes ago
+			c.trackKey("a", time.Now().Add(-2*time.Minute))
+			assert.True(t, c.IsStale("a"))
+			assert.False(t, c.IsStale("b"))
+
+			result, err := c.Evaluate(ctx, "a", domain.EvaluationContext{EntityID: "u1"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVariant, result.VariantKey)
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, result.EvaluationReason)
+			}
+
+			metrics := c.GetMetrics()
+			assert.Equal(t, 1, metrics.StaleFlags)
+			assert.Equal(t, tt.served, metrics.StaleServed)
+			assert.Equal(t, tt.fellBack, metrics.StaleFellBack)
+			assert.WithinDuration(t, time.Now().Add(-2*time.Minute), metrics.OldestSync, time.Second)
+
+			// Explain answers as Evaluate does
+			trace, err := c.Explain(ctx, "a", domain.EvaluationContext{EntityID: "u1"})
+			require.NoError(t, err)
+			require.NotNil(t, trace.Result)
+			assert.Equal(t, result.VariantKey, trace.Result.VariantKey)
+			assert.Equal(t, result.EvaluationReason, trace.Result.EvaluationReason)
+			assert.Contains(t, trace.StrategyReason, "stale")
+		})
+	}
+}
+
+func TestCache_RefreshFlagKeys(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(domain.Flag{ID: 1, Key: "a", Enabled: true, Description: "v1"})
+	mockFlagr.AddFlag(domain.Flag{ID: 2, Key: "b", Enabled: true})
+	mockFlagr.AddFlag(domain.Flag{ID: 3, Key: "c", Enabled: true})
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(storage.NewMockStorage()),
+		WithEvaluator(evaluator.New()),
+		WithOnlyEnabled(true),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Start(ctx))
+	defer c.Stop()
+	assert.Equal(t, []string{"a", "b", "c"}, c.FlagKeys())
+
+	mockFlagr.AddFlag(domain.Flag{ID: 1, Key: "a", Enabled: true, Description: "v2"})
+	mockFlagr.AddFlag(domain.Flag{ID: 3, Key: "c", Enabled: false})
+	mockFlagr.GetFlagFunc = func(ctx context.Context, id int64) (*domain.Flag, error) {
+		if id == 2 {
+			return nil, domain.NewNotFoundError("flag", "2")
+		}
+		return &domain.Flag{ID: 3, Key: "c", Enabled: false}, nil
+	}
+
+	require.NoError(t, c.RefreshFlagKeys(ctx, []string{"a"}, []int64{2, 3}))
+
+	flag, err := c.GetFlag(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", flag.Description)
+	assert.Equal(t, []string{"a"}, c.FlagKeys(), "deleted and now filtered flags are removed")
+	assert.Equal(t, 2, mockFlagr.GetFlagCalls)
+
+	// Flagr errors are reported and leave the cache untouched
+	mockFlagr.GetFlagFunc = func(ctx context.Context, id int64) (*domain.Flag, error) {
+		return nil, assert.AnError
+	}
+	assert.ErrorIs(t, c.RefreshFlagKeys(ctx, nil, []int64{1}), assert.AnError)
+	assert.Equal(t, []string{"a"}, c.FlagKeys())
+}
+
+func TestCache_Namespaces(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(domain.Flag{ID: 1, Key: "new_cart", Enabled: true, Tags: []domain.Tag{{Value: "checkout"}}})
+	mockFlagr.AddFlag(domain.Flag{ID: 2, Key: "search_v2", Enabled: true, Tags: []domain.Tag{{Value: "search"}}})
+	mockFlagr.AddFlag(domain.Flag{ID: 3, Key: "fuzzy_search", Enabled: true, Tags: []domain.Tag{{Value: "search"}}})
+	mockFlagr.AddFlag(domain.Flag{ID: 4, Key: "dark_mode", Enabled: true, Tags: []domain.Tag{{Value: "frontend"}}})
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(storage.NewMockStorage()),
+		WithEvaluator(evaluator.New()),
+		WithNamespaces([]string{"checkout"}, []string{"search"}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Sync(ctx))
+	assert.Equal(t, []string{"new_cart"}, c.FlagKeys(), "only eager namespaces are loaded at start")
+	assert.Equal(t, []string{"checkout"}, c.GetMetrics().Namespaces)
+
+	// The first flag of a lazy namespace loads the whole namespace
+	result, err := c.Evaluate(ctx, "search_v2", domain.EvaluationContext{EntityID: "user-1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, "fallback: fail_closed", result.EvaluationReason)
+	assert.Equal(t, []string{"fuzzy_search", "new_cart", "search_v2"}, c.FlagKeys())
+	assert.Equal(t, []string{"checkout", "search"}, c.GetMetrics().Namespaces)
+
+	// Flags outside the namespaces are never cached
+	result, err = c.Evaluate(ctx, "dark_mode", domain.EvaluationContext{EntityID: "user-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "fallback: fail_closed", result.EvaluationReason)
+
+	// Loaded lazy namespaces are refreshed with the eager ones
+	require.NoError(t, c.InvalidateAll(ctx))
+	require.NoError(t, c.Sync(ctx))
+	assert.Equal(t, []string{"fuzzy_search", "new_cart", "search_v2"}, c.FlagKeys())
+}
+
+func TestCache_Explain_LazyNamespace(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockFlagr.AddFlag(domain.Flag{ID: 1, Key: "new_cart", Enabled: true, Tags: []domain.Tag{{Value: "checkout"}}})
+	search := staleFlag("search_v2")
+	search.ID = 2
+	search.Tags = []domain.Tag{{Value: "search"}}
+	mockFlagr.AddFlag(search)
+
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(storage.NewMockStorage()),
+		WithEvaluator(evaluator.New()),
+		WithNamespaces([]string{"checkout"}, []string{"search"}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Sync(ctx))
+
+	// Explain loads the lazy namespace like Evaluate does
+	trace, err := c.Explain(ctx, "search_v2", domain.EvaluationContext{EntityID: "user-1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, "flag not found in cache", trace.StrategyReason)
+	require.NotNil(t, trace.Result)
+	assert.Equal(t, "on", trace.Result.VariantKey)
+	assert.Equal(t, []string{"checkout", "search"}, c.GetMetrics().Namespaces)
+
+	result, err := c.Evaluate(ctx, "search_v2", domain.EvaluationContext{EntityID: "user-1"})
+	require.NoError(t, err)
+	assert.Equal(t, trace.Result.VariantKey, result.VariantKey)
+}
+
+func TestFilterConfig_Validate_Namespaces(t *testing.T) {
+	assert.NoError(t, FilterConfig{Namespaces: []string{"checkout"}, LazyNamespaces: []string{"search"}}.Validate())
+	assert.EqualError(t, FilterConfig{Namespaces: []string{""}}.Validate(), "namespace cannot be empty")
+	assert.EqualError(t, FilterConfig{Namespaces: []string{"search"}, LazyNamespaces: []string{"search"}}.Validate(), "namespace search is configured twice")
+}
+
+func TestCache_Memo(t *testing.T) {
+	mockFlagr := flagr.NewMockClient()
+	mockStorage := storage.NewMockStorage()
+
+	staticFlag := func(variant string) domain.Flag {
+		return domain.Flag{
+			ID:       1,
+			Key:      "theme",
+			Enabled:  true,
+			Segments: []domain.Segment{{ID: 1, RolloutPercent: 100, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}}},
+			Variants: []domain.Variant{{ID: 1, Key: variant}},
+		}
+	}
+	mockStorage.AddFlag(staticFlag("light"))
+	mockStorage.AddFlag(domain.Flag{
+		ID:       2,
+		Key:      "remote-flag",
+		Enabled:  true,
+		Segments: []domain.Segment{{ID: 2, RolloutPercent: 50, Distributions: []domain.Distribution{{VariantID: 2, Percent: 100}}}},
+		Variants: []domain.Variant{{ID: 2, Key: "enabled"}},
+	})
+	mockFlagr.EvaluateFlagFunc = func(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
+		return &domain.EvaluationResult{FlagKey: flagKey, VariantKey: "enabled"}, nil
+	}
+
+	var hooked int
+	c, err := New(
+		WithFlagrClient(mockFlagr),
+		WithStorage(mockStorage),
+		WithEvaluator(evaluator.New()),
+		WithEvaluationHook(func(string, domain.EvaluationContext, *domain.EvaluationResult, error) { hooked++ }),
+	)
+	require.NoError(t, err)
+
+	memo := NewMemo()
+	ctx := WithMemo(context.Background(), memo)
+	user1 := domain.EvaluationContext{EntityID: "user-1"}
+
+	assert.Equal(t, "light", c.EvaluateString(ctx, "theme", user1, ""))
+
+	// A refresh mid-request does not change the pinned result
+	mockStorage.AddFlag(staticFlag("dark"))
+	assert.Equal(t, "light", c.EvaluateString(ctx, "theme", user1, ""))
+	assert.Equal(t, "dark", c.EvaluateString(ctx, "theme", domain.EvaluationContext{EntityID: "user-2"}, ""), "pinned per entity")
+	assert.Equal(t, "dark", c.EvaluateString(context.Background(), "theme", user1, ""), "pinned per memo")
+
+	// Remote flags reach Flagr once
+	assert.True(t, c.EvaluateBool(ctx, "remote-flag", user1))
+	assert.True(t, c.EvaluateBool(ctx, "remote-flag", user1))
+	mockFlagr.AssertCalled(t, "EvaluateFlag", 1)
+
+	assert.Equal(t, 6, hooked, "the hook sees every evaluation")
+
+	entries := memo.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "theme", entries[0].FlagKey)
+	assert.Equal(t, "user-1", entries[0].EntityID)
+	assert.Equal(t, "light", entries[0].Result.VariantKey)
+	assert.Equal(t, "user-2", entries[1].EntityID)
+	assert.Equal(t, "remote-flag", entries[2].FlagKey)
+	assert.Same(t, c, entries[2].Cache)
+}
+
 func TestNewSimple(t *testing.T) {
 	config := SimpleConfig{
 		FlagrEndpoint:   "http://localhost:18000",
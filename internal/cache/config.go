@@ -15,6 +15,13 @@ type Config struct {
 	// Options: "fail_open", "fail_closed", "error"
 	FallbackStrategy string
 
+	// Flags are kept as last-known-good until Flagr replaces or deletes
+	// them. Once a flag was not returned by Flagr for MaxStaleness (zero
+	// disables the limit), StalePolicy applies.
+	// Options: "serve", "mark", "fallback"
+	MaxStaleness time.Duration
+	StalePolicy  string
+
 	// Circuit breaker
 	CircuitBreakerThreshold int
 	CircuitBreakerTimeout   time.Duration
@@ -23,6 +30,21 @@ type Config struct {
 	FilterConfig FilterConfig
 }
 
+// Stale policies, applied to flags past Config.MaxStaleness
+const (
+	// StaleServe evaluates stale flags as usual
+	StaleServe = "serve"
+
+	// StaleMark evaluates stale flags with EvaluationReason ReasonStale
+	StaleMark = "mark"
+
+	// StaleFallback answers stale flags with the fallback strategy
+	StaleFallback = "fallback"
+)
+
+// ReasonStale is the EvaluationReason of stale flags under StaleMark
+const ReasonStale = "STALE"
+
 // FilterConfig defines filtering rules for flags
 type FilterConfig struct {
 	// OnlyEnabled filters out disabled flags
@@ -47,6 +69,15 @@ type FilterConfig struct {
 	// "any": flag must have ANY of the tags
 	// "all": flag must have ALL of the tags
 	TagMatchMode string
+
+	// Namespaces are Flagr tags whose flags are loaded at start and on
+	// every refresh. When set, tag filtering happens in Flagr's list
+	// query, so flags outside the namespaces are never downloaded
+	Namespaces []string
+
+	// LazyNamespaces are Flagr tags whose flags are loaded the first time
+	// one of them is requested, and refreshed from then on
+	LazyNamespaces []string
 }
 
 // DefaultConfig returns default configuration
@@ -55,6 +86,7 @@ func DefaultConfig() Config {
 		RefreshInterval:         5 * time.Minute,
 		InitialTimeout:          10 * time.Second,
 		FallbackStrategy:        "fail_closed",
+		StalePolicy:             StaleServe,
 		CircuitBreakerThreshold: 3,
 		CircuitBreakerTimeout:   30 * time.Second,
 		FilterConfig: FilterConfig{
@@ -86,6 +118,16 @@ func (c Config) Validate() error {
 		return fmt.Errorf("invalid fallback strategy: %s (must be 'fail_open', 'fail_closed', or 'error')", c.FallbackStrategy)
 	}
 
+	if c.MaxStaleness < 0 {
+		return fmt.Errorf("max staleness cannot be negative")
+	}
+
+	switch c.StalePolicy {
+	case "", StaleServe, StaleMark, StaleFallback:
+	default:
+		return fmt.Errorf("invalid stale policy: %s (must be 'serve', 'mark', or 'fallback')", c.StalePolicy)
+	}
+
 	if c.CircuitBreakerThreshold < 1 {
 		return fmt.Errorf("circuit breaker threshold must be at least 1")
 	}
@@ -108,9 +150,25 @@ func (f FilterConfig) Validate() error {
 		return fmt.Errorf("tag_match_mode must be 'any' or 'all'")
 	}
 
+	seen := make(map[string]bool)
+	for _, ns := range append(append([]string{}, f.Namespaces...), f.LazyNamespaces...) {
+		if ns == "" {
+			return fmt.Errorf("namespace cannot be empty")
+		}
+		if seen[ns] {
+			return fmt.Errorf("namespace %s is configured twice", ns)
+		}
+		seen[ns] = true
+	}
+
 	return nil
 }
 
+// Namespaced reports whether flags are loaded per namespace
+func (f FilterConfig) Namespaced() bool {
+	return len(f.Namespaces) > 0 || len(f.LazyNamespaces) > 0
+}
+
 // ShouldCacheFlag determines if a flag should be cached based on filter rules
 func (f FilterConfig) ShouldCacheFlag(flag FlagMetadata) bool {
 	// Rule 1: OnlyEnabled filter
@@ -189,7 +247,7 @@ type FlagMetadata struct {
 
 // String returns a human-readable description of the filter config
 func (f FilterConfig) String() string {
-	if !f.OnlyEnabled && !f.RequireServiceTag && len(f.AdditionalTags) == 0 {
+	if !f.OnlyEnabled && !f.RequireServiceTag && len(f.AdditionalTags) == 0 && !f.Namespaced() {
 		return "no filtering (all flags cached)"
 	}
 
@@ -207,6 +265,14 @@ func (f FilterConfig) String() string {
 		filters = append(filters, fmt.Sprintf("tags=%v (%s)", f.AdditionalTags, f.TagMatchMode))
 	}
 
+	if len(f.Namespaces) > 0 {
+		filters = append(filters, fmt.Sprintf("namespaces=%v", f.Namespaces))
+	}
+
+	if len(f.LazyNamespaces) > 0 {
+		filters = append(filters, fmt.Sprintf("lazy_namespaces=%v", f.LazyNamespaces))
+	}
+
 	return fmt.Sprintf("filtering: %v", filters)
 }
 
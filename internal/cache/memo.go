@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+type memoContextKey struct{}
+
+// Memo pins the first successful result of every flag and entity, so
+// evaluations sharing a context (such as one HTTP request) agree even when
+// a refresh lands in between. Remote flags reach Flagr once per memo.
+type Memo struct {
+	mu      sync.Mutex
+	results map[memoKey]*domain.EvaluationResult
+	order   []MemoEntry
+}
+
+type memoKey struct {
+	cache    *Cache
+	flagKey  string
+	entityID string
+}
+
+// MemoEntry is a pinned evaluation
+type MemoEntry struct {
+	Cache    *Cache
+	FlagKey  string
+	EntityID string
+	Result   *domain.EvaluationResult
+}
+
+// NewMemo creates an empty memo
+func NewMemo() *Memo {
+	return &Memo{results: make(map[memoKey]*domain.EvaluationResult)}
+}
+
+// WithMemo returns a copy of ctx whose evaluations are pinned in memo
+func WithMemo(ctx context.Context, memo *Memo) context.Context {
+	return context.WithValue(ctx, memoContextKey{}, memo)
+}
+
+// MemoFrom returns the memo carried by ctx, or nil
+func MemoFrom(ctx context.Context) *Memo {
+	memo, _ := ctx.Value(memoContextKey{}).(*Memo)
+	return memo
+}
+
+// Entries returns the pinned evaluations in the order they happened
+func (m *Memo) Entries() []MemoEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]MemoEntry(nil), m.order...)
+}
+
+// get returns the pinned result of a flag for an entity
+func (m *Memo) get(c *Cache, flagKey, entityID string) (*domain.EvaluationResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result, ok := m.results[memoKey{c, flagKey, entityID}]
+	return result, ok
+}
+
+// pin stores a result unless another one was pinned meanwhile, and returns
+// the pinned result
+func (m *Memo) pin(c *Cache, flagKey, entityID string, result *domain.EvaluationResult) *domain.EvaluationResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoKey{c, flagKey, entityID}
+	if pinned, ok := m.results[key]; ok {
+		return pinned
+	}
+	m.results[key] = result
+	m.order = append(m.order, MemoEntry{Cache: c, FlagKey: flagKey, EntityID: entityID, Result: result})
+	return result
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
 	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/override"
 	"github.com/OrlandoBitencourt/vexilla/internal/storage"
 )
 
@@ -32,6 +33,22 @@ func WithEvaluator(e evaluator.Evaluator) Option {
 	}
 }
 
+// WithOverrides sets the local override store consulted before storage
+func WithOverrides(store *override.Store) Option {
+	return func(c *Cache) {
+		if store != nil {
+			c.overrides = store
+		}
+	}
+}
+
+// WithEvaluationHook sets a function called after every evaluation
+func WithEvaluationHook(hook EvaluationHook) Option {
+	return func(c *Cache) {
+		c.hook = hook
+	}
+}
+
 // WithConfig sets the configuration
 func WithConfig(config Config) Option {
 	return func(c *Cache) {
@@ -60,6 +77,15 @@ func WithFallbackStrategy(strategy string) Option {
 	}
 }
 
+// WithMaxStaleness sets how long a flag may go without being returned by
+// Flagr before the stale policy applies to it
+func WithMaxStaleness(maxStaleness time.Duration, policy string) Option {
+	return func(c *Cache) {
+		c.config.MaxStaleness = maxStaleness
+		c.config.StalePolicy = policy
+	}
+}
+
 // WithCircuitBreaker configures circuit breaker
 func WithCircuitBreaker(threshold int, timeout time.Duration) Option {
 	return func(c *Cache) {
@@ -93,6 +119,15 @@ func WithAdditionalTags(tags []string, matchMode string) Option {
 	}
 }
 
+// WithNamespaces configures the namespaces loaded at start and the ones
+// loaded the first time one of their flags is requested
+func WithNamespaces(namespaces, lazy []string) Option {
+	return func(c *Cache) {
+		c.config.FilterConfig.Namespaces = namespaces
+		c.config.FilterConfig.LazyNamespaces = lazy
+	}
+}
+
 // WithFilterConfig sets the complete filter configuration
 func WithFilterConfig(config FilterConfig) Option {
 	return func(c *Cache) {
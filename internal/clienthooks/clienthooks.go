@@ -0,0 +1,20 @@
+// Package clienthooks gives in-module tooling, such as the vexillatest
+// package, the client options whose parameter types are internal, so that
+// they stay out of the public API of the vexilla package.
+package clienthooks
+
+import (
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/storage"
+)
+
+// Set by the vexilla package when it is initialized; both return a
+// vexilla.Option
+var (
+	// WithFlagrClient replaces the Flagr HTTP client with another flag
+	// source
+	WithFlagrClient func(client flagr.Client) any
+
+	// WithStorage replaces the in-memory flag storage
+	WithStorage func(s storage.Storage) any
+)
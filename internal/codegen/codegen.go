@@ -0,0 +1,432 @@
+// Package codegen generates a Go package with typed accessors for flags, so
+// flag keys are checked by the compiler instead of failing silently.
+//
+// Each flag gets a method on a Flags type whose return type comes from the
+// variant attachments:
+//
+//   - bool when every variant has a boolean "enabled" or "value", or the
+//     variants are named enabled/disabled, on/off or true/false
+//   - string when every variant has a string "value"
+//   - int when every variant has an integer "value"
+//   - a variant type with one constant per variant otherwise
+//
+// A/B flags (a segment splitting traffic between variants) also get
+// variant constants and a <Flag>Variant method. The default returned when a
+// flag cannot be evaluated is the variant of the flag's catch-all segment,
+// or the zero value when there is none.
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// Kind is the Go type of a generated accessor
+type Kind string
+
+const (
+	KindBool    Kind = "bool"
+	KindString  Kind = "string"
+	KindInt     Kind = "int"
+	KindVariant Kind = "variant"
+)
+
+// Options configures the generated package
+type Options struct {
+	// Package is the package name (default "flags")
+	Package string
+
+	// Source describes where the flags came from, for the header comment
+	Source string
+}
+
+// Accessor describes the method generated for a flag
+type Accessor struct {
+	Key         string
+	Name        string // Go name, such as NewCheckout
+	Description string
+	Kind        Kind
+
+	// Default is the Go literal returned when evaluation fails (empty for
+	// variant flags)
+	Default string
+
+	// Variants are set for variant flags and A/B flags
+	Variants       []VariantConst
+	VariantType    string // such as PricingV2Variant
+	VariantDefault string // Go expression of the default variant
+	ABTest         bool
+}
+
+// VariantConst is a generated variant constant
+type VariantConst struct {
+	Name string // such as PricingV2Control
+	Key  string
+}
+
+// Accessors describes the accessors generated for flags, sorted by key
+func Accessors(flags []domain.Flag) ([]Accessor, error) {
+	sorted := make([]domain.Flag, len(flags))
+	copy(sorted, flags)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	// Generated identifiers, to report flags that would clash
+	names := map[string]string{}
+	declare := func(name, key string) error {
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("flags %s and %s both generate %s", other, key, name)
+		}
+		names[name] = key
+		return nil
+	}
+
+	accessors := make([]Accessor, 0, len(sorted))
+	for _, flag := range sorted {
+		a := Accessor{
+			Key:         flag.Key,
+			Name:        GoName(flag.Key),
+			Description: flag.Description,
+			Kind:        kindOf(flag),
+			ABTest:      isABTest(flag),
+		}
+		if err := declare(a.Name, flag.Key); err != nil {
+			return nil, err
+		}
+		if err := declare("Key"+a.Name, flag.Key); err != nil {
+			return nil, err
+		}
+
+		def := defaultVariant(flag)
+		switch a.Kind {
+		case KindBool:
+			a.Default = strconv.FormatBool(def != nil && isEnabled(*def))
+		case KindString:
+			value := ""
+			if def != nil {
+				_ = json.Unmarshal(def.Attachment["value"], &value)
+			}
+			a.Default = strconv.Quote(value)
+		case KindInt:
+			value := 0
+			if def != nil {
+				_ = json.Unmarshal(def.Attachment["value"], &value)
+			}
+			a.Default = strconv.Itoa(value)
+		}
+
+		if a.Kind == KindVariant || a.ABTest {
+			a.VariantType = a.Name + "Variant"
+			if err := declare(a.VariantType, flag.Key); err != nil {
+				return nil, err
+			}
+			for _, v := range flag.Variants {
+				c := VariantConst{Name: a.Name + GoName(v.Key), Key: v.Key}
+				if err := declare(c.Name, flag.Key); err != nil {
+					return nil, err
+				}
+				a.Variants = append(a.Variants, c)
+			}
+
+			a.VariantDefault = `""`
+			if def != nil {
+				a.VariantDefault = a.Name + GoName(def.Key)
+			}
+		}
+
+		accessors = append(accessors, a)
+	}
+
+	return accessors, nil
+}
+
+// Generate returns the formatted source of the accessor package
+func Generate(flags []domain.Flag, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "flags"
+	}
+	if !token.IsIdentifier(opts.Package) {
+		return nil, fmt.Errorf("invalid package name %q", opts.Package)
+	}
+
+	accessors, err := Accessors(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = packageTemplate.Execute(&buf, map[string]any{
+		"Package":   opts.Package,
+		"Source":    opts.Source,
+		"Accessors": accessors,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go source: %w", err)
+	}
+	return src, nil
+}
+
+// initialisms are written in capitals in Go names
+var initialisms = map[string]bool{
+	"ab": true, "api": true, "cpu": true, "css": true, "dns": true, "eu": true, "html": true,
+	"http": true, "https": true, "id": true, "ip": true, "json": true, "qa": true, "sla": true,
+	"sql": true, "ssl": true, "tcp": true, "tls": true, "ttl": true, "ui": true, "uri": true,
+	"url": true, "us": true, "uuid": true, "xml": true,
+}
+
+// GoName converts a flag or variant key to an exported Go name:
+// "new-checkout" is NewCheckout and "api.rate_limit" is APIRateLimit
+func GoName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Flag" + name
+	}
+	return name
+}
+
+// kindOf picks the accessor type from the variants
+func kindOf(flag domain.Flag) Kind {
+	if len(flag.Variants) == 0 {
+		return KindBool
+	}
+
+	all := func(match func(domain.Variant) bool) bool {
+		for _, v := range flag.Variants {
+			if !match(v) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case all(isBoolVariant):
+		return KindBool
+	case all(func(v domain.Variant) bool { return isValue[string](v) }):
+		return KindString
+	case all(func(v domain.Variant) bool { return isValue[int](v) }):
+		return KindInt
+	}
+	return KindVariant
+}
+
+func isBoolVariant(v domain.Variant) bool {
+	if len(v.Attachment) == 0 {
+		switch strings.ToLower(v.Key) {
+		case "enabled", "disabled", "on", "off", "true", "false":
+			return true
+		}
+		return false
+	}
+
+	var b bool
+	if raw, ok := v.Attachment["enabled"]; ok {
+		return json.Unmarshal(raw, &b) == nil
+	}
+	if raw, ok := v.Attachment["value"]; ok {
+		return json.Unmarshal(raw, &b) == nil
+	}
+	return false
+}
+
+// isValue reports whether the "value" attachment decodes to T
+func isValue[T any](v domain.Variant) bool {
+	raw, ok := v.Attachment["value"]
+	if !ok {
+		return false
+	}
+	var value T
+	return json.Unmarshal(raw, &value) == nil
+}
+
+// isEnabled mirrors Result.IsEnabled for a variant
+func isEnabled(v domain.Variant) bool {
+	if len(v.Attachment) == 0 {
+		switch strings.ToLower(v.Key) {
+		case "enabled", "on", "true":
+			return true
+		}
+		return false
+	}
+
+	for _, key := range []string{"enabled", "value"} {
+		var b bool
+		if json.Unmarshal(v.Attachment[key], &b) == nil && b {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultVariant returns the variant served by the catch-all segment: the
+// last segment, when it has no constraints, a full rollout and a single
+// distribution
+func defaultVariant(flag domain.Flag) *domain.Variant {
+	segments := flag.SortedSegments()
+	if len(segments) == 0 {
+		return nil
+	}
+
+	last := segments[len(segments)-1]
+	if len(last.Constraints) > 0 || last.RolloutPercent != 100 || len(last.Distributions) != 1 {
+		return nil
+	}
+	variant, ok := flag.GetVariantByID(last.Distributions[0].VariantID)
+	if !ok {
+		return nil
+	}
+	return variant
+}
+
+func isABTest(flag domain.Flag) bool {
+	for _, seg := range flag.Segments {
+		if len(seg.Distributions) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// comment formats text as a doc comment
+func comment(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+strings.TrimSpace(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var packageTemplate = template.Must(template.New("flags").Funcs(template.FuncMap{
+	"comment": comment,
+	"quote":   strconv.Quote,
+}).Parse(`// Code generated by vexilla generate; DO NOT EDIT.
+{{- if .Source}}
+// Source: {{.Source}}
+{{- end}}
+
+// Package {{.Package}} provides typed accessors for feature flags.
+package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/OrlandoBitencourt/vexilla"
+)
+
+// Flag keys
+const (
+{{- range .Accessors}}
+	Key{{.Name}} = {{quote .Key}}
+{{- end}}
+)
+
+// Evaluator evaluates flags. It is implemented by *vexilla.Client.
+type Evaluator interface {
+	Evaluate(ctx context.Context, flagKey string, evalCtx vexilla.Context) (*vexilla.Result, error)
+}
+
+// Flags evaluates feature flags with typed accessors.
+type Flags struct {
+	client Evaluator
+}
+
+// New wraps a Vexilla client.
+func New(client Evaluator) *Flags {
+	return &Flags{client: client}
+}
+{{range .Accessors}}
+{{- if .Variants}}
+// {{.VariantType}} is a variant of the {{.Key}} flag.
+type {{.VariantType}} string
+
+// Variants of the {{.Key}} flag
+const (
+{{- $type := .VariantType}}
+{{- range .Variants}}
+	{{.Name}} {{$type}} = {{quote .Key}}
+{{- end}}
+)
+{{end}}
+{{- if .Description}}
+{{comment (printf "%s returns the %s flag: %s" .Name .Key .Description)}}
+{{- else}}
+// {{.Name}} returns the {{.Key}} flag.
+{{- end}}
+//
+// Returns {{or .Default .VariantDefault}} when the flag cannot be evaluated.
+{{- if eq .Kind "bool"}}
+func (f *Flags) {{.Name}}(ctx context.Context, evalCtx vexilla.Context) bool {
+	result, err := f.client.Evaluate(ctx, Key{{.Name}}, evalCtx)
+	if err != nil {
+		return {{.Default}}
+	}
+	return result.IsEnabled()
+}
+{{- else if eq .Kind "string"}}
+func (f *Flags) {{.Name}}(ctx context.Context, evalCtx vexilla.Context) string {
+	result, err := f.client.Evaluate(ctx, Key{{.Name}}, evalCtx)
+	if err != nil {
+		return {{.Default}}
+	}
+	return result.GetString("value", {{.Default}})
+}
+{{- else if eq .Kind "int"}}
+func (f *Flags) {{.Name}}(ctx context.Context, evalCtx vexilla.Context) int {
+	result, err := f.client.Evaluate(ctx, Key{{.Name}}, evalCtx)
+	if err != nil {
+		return {{.Default}}
+	}
+	return result.GetInt("value", {{.Default}})
+}
+{{- else}}
+func (f *Flags) {{.Name}}(ctx context.Context, evalCtx vexilla.Context) {{.VariantType}} {
+	result, err := f.client.Evaluate(ctx, Key{{.Name}}, evalCtx)
+	if err != nil || result.VariantKey == "" {
+		return {{.VariantDefault}}
+	}
+	return {{.VariantType}}(result.VariantKey)
+}
+{{- end}}
+{{- if and .Variants (ne .Kind "variant")}}
+
+// {{.Name}}Variant returns the variant of the {{.Key}} flag, an A/B test.
+//
+// Returns {{.VariantDefault}} when the flag cannot be evaluated.
+func (f *Flags) {{.Name}}Variant(ctx context.Context, evalCtx vexilla.Context) {{.VariantType}} {
+	result, err := f.client.Evaluate(ctx, Key{{.Name}}, evalCtx)
+	if err != nil || result.VariantKey == "" {
+		return {{.VariantDefault}}
+	}
+	return {{.VariantType}}(result.VariantKey)
+}
+{{- end}}
+{{end}}`))
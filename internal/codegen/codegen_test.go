@@ -0,0 +1,220 @@
+package codegen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifest = `flags:
+  new-checkout:
+    description: New checkout flow for Brazil
+    variants:
+      enabled: {enabled: true}
+      disabled: {enabled: false}
+    segments:
+      - constraints:
+          - {property: country, operator: EQ, value: BR}
+        variant: enabled
+    default: disabled
+  ui-theme:
+    variants:
+      dark: {value: dark}
+      light: {value: light}
+    default: light
+  max-items:
+    variants:
+      small: {value: 10}
+      large: {value: 50}
+    segments:
+      - constraints:
+          - {property: tier, operator: EQ, value: gold}
+        variant: large
+    default: small
+  pricing-v2:
+    description: |
+      Pricing experiment.
+      Treatment shows yearly prices.
+    variants:
+      control: {}
+      treatment: {}
+    segments:
+      - distribution: {control: 50, treatment: 50}
+  button-color:
+    variants:
+      "on": {enabled: true}
+      "off": {enabled: false}
+    segments:
+      - distribution: {"on": 50, "off": 50}
+`
+
+func manifestFlags(t *testing.T) []domain.Flag {
+	t.Helper()
+
+	flags, err := flagr.ParseFlagFile("flags.yaml", []byte(manifest))
+	require.NoError(t, err)
+	return flags
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"new-checkout":   "NewCheckout",
+		"api.rate_limit": "APIRateLimit",
+		"ui theme":       "UITheme",
+		"userId":         "UserId",
+		"2fa-enabled":    "Flag2faEnabled",
+		"--":             "Flag",
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, GoName(key), key)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	accessors, err := Accessors(manifestFlags(t))
+	require.NoError(t, err)
+	require.Len(t, accessors, 5)
+
+	byKey := map[string]Accessor{}
+	for _, a := range accessors {
+		byKey[a.Key] = a
+	}
+	assert.Equal(t, "button-color", accessors[0].Key, "sorted by key")
+
+	checkout := byKey["new-checkout"]
+	assert.Equal(t, "NewCheckout", checkout.Name)
+	assert.Equal(t, KindBool, checkout.Kind)
+	assert.Equal(t, "false", checkout.Default)
+	assert.Equal(t, "New checkout flow for Brazil", checkout.Description)
+	assert.Empty(t, checkout.Variants)
+
+	assert.Equal(t, KindString, byKey["ui-theme"].Kind)
+	assert.Equal(t, `"light"`, byKey["ui-theme"].Default)
+
+	assert.Equal(t, KindInt, byKey["max-items"].Kind)
+	assert.Equal(t, "10", byKey["max-items"].Default)
+
+	pricing := byKey["pricing-v2"]
+	assert.Equal(t, KindVariant, pricing.Kind)
+	assert.True(t, pricing.ABTest)
+	assert.Equal(t, "PricingV2Variant", pricing.VariantType)
+	assert.Equal(t, []VariantConst{{Name: "PricingV2Control", Key: "control"}, {Name: "PricingV2Treatment", Key: "treatment"}}, pricing.Variants)
+	assert.Equal(t, `""`, pricing.VariantDefault, "no catch-all segment")
+
+	button := byKey["button-color"]
+	assert.Equal(t, KindBool, button.Kind)
+	assert.True(t, button.ABTest)
+	assert.Len(t, button.Variants, 2, "A/B flags get variant constants")
+}
+
+func TestAccessors_Collisions(t *testing.T) {
+	_, err := Accessors([]domain.Flag{{Key: "new-checkout"}, {Key: "new_checkout"}})
+	assert.EqualError(t, err, "flags new-checkout and new_checkout both generate NewCheckout")
+
+	// A variant constant of one flag clashing with another flag
+	_, err = Accessors([]domain.Flag{
+		{Key: "pricing", Variants: []domain.Variant{{ID: 1, Key: "v2"}}},
+		{Key: "pricing-v2"},
+	})
+	assert.EqualError(t, err, "flags pricing and pricing-v2 both generate PricingV2")
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate(manifestFlags(t), Options{Source: "flags.yaml"})
+	require.NoError(t, err)
+	code := string(src)
+
+	assert.Contains(t, code, "// Code generated by vexilla generate; DO NOT EDIT.\n// Source: flags.yaml\n")
+	assert.Contains(t, code, "package flags\n")
+	assert.Contains(t, code, `KeyNewCheckout = "new-checkout"`)
+	assert.Contains(t, code, "// NewCheckout returns the new-checkout flag: New checkout flow for Brazil\n//\n// Returns false when the flag cannot be evaluated.\nfunc (f *Flags) NewCheckout(ctx context.Context, evalCtx vexilla.Context) bool {")
+	assert.Contains(t, code, "// PricingV2 returns the pricing-v2 flag: Pricing experiment.\n// Treatment shows yearly prices.\n")
+	assert.Contains(t, code, `return result.GetString("value", "light")`)
+	assert.Contains(t, code, `return result.GetInt("value", 10)`)
+	assert.Contains(t, code, `PricingV2Treatment PricingV2Variant = "treatment"`)
+	assert.Contains(t, code, "func (f *Flags) ButtonColorVariant(ctx context.Context, evalCtx vexilla.Context) ButtonColorVariant {")
+
+	src, err = Generate(nil, Options{Package: "features"})
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "package features\n")
+	assert.NotContains(t, string(src), "// Source:")
+
+	_, err = Generate(nil, Options{Package: "my-flags"})
+	assert.EqualError(t, err, `invalid package name "my-flags"`)
+}
+
+const generatedTest = `package flags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla"
+)
+
+func TestFlags(t *testing.T) {
+	client, err := vexilla.New(vexilla.WithFlagFile("flags.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := client.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Stop()
+
+	f := New(client)
+	br := vexilla.NewContext("user-1").WithAttribute("country", "BR").WithAttribute("tier", "gold")
+	us := vexilla.NewContext("user-1").WithAttribute("country", "US")
+
+	if !f.NewCheckout(ctx, br) || f.NewCheckout(ctx, us) {
+		t.Error("NewCheckout")
+	}
+	if got := f.UITheme(ctx, us); got != "light" {
+		t.Errorf("UITheme = %q", got)
+	}
+	if f.MaxItems(ctx, br) != 50 || f.MaxItems(ctx, us) != 10 {
+		t.Error("MaxItems")
+	}
+	if v := f.PricingV2(ctx, us); v != PricingV2Control && v != PricingV2Treatment {
+		t.Errorf("PricingV2 = %q", v)
+	}
+	if v := f.ButtonColorVariant(ctx, us); (v == ButtonColorOn) != f.ButtonColor(ctx, us) {
+		t.Errorf("ButtonColor disagrees with ButtonColorVariant %q", v)
+	}
+}
+`
+
+// TestGenerate_Compiles builds the generated package and evaluates flags
+// through it
+func TestGenerate_Compiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go tool")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	src, err := Generate(manifestFlags(t), Options{})
+	require.NoError(t, err)
+
+	// Inside the module, so the generated package can import Vexilla
+	dir, err := os.MkdirTemp(".", "_generated")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "flags.go"), src, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "flags_test.go"), []byte(generatedTest), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "flags.yaml"), []byte(manifest), 0o644))
+
+	cmd := exec.Command(goTool, "test", "./"+filepath.Base(dir))
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
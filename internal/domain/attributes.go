@@ -0,0 +1,284 @@
+package domain
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+)
+
+// Built-in properties that constraints can reference without the caller
+// copying them into the attribute map
+const (
+	PropertyEntityID   = "entityID"
+	PropertyEntityType = "entityType"
+)
+
+// attributeTag is the struct tag consulted when converting structs to attributes
+const attributeTag = "vexilla"
+
+// Lookup resolves a constraint property against the context.
+//
+// Resolution order:
+//  1. exact key in Context (so flat keys like "account.region" keep working)
+//  2. dotted path into nested maps and structs ("account.region")
+//  3. the built-in entityID / entityType properties
+func (e EvaluationContext) Lookup(property string) (interface{}, bool) {
+	if val, ok := e.Context[property]; ok {
+		return val, true
+	}
+
+	if strings.Contains(property, ".") {
+		parts := strings.Split(property, ".")
+		if root, ok := e.Context[parts[0]]; ok {
+			if val, ok := lookupPath(root, parts[1:]); ok {
+				return val, true
+			}
+		}
+	}
+
+	switch property {
+	case PropertyEntityID:
+		return e.EntityID, e.EntityID != ""
+	case PropertyEntityType:
+		return e.EntityType, e.EntityType != ""
+	}
+
+	return nil, false
+}
+
+// Properties returns every property a constraint can reference as a flat map.
+// Nested maps and structs are flattened into dotted keys and the entity
+// properties are added unless the caller already set them. This is the shape
+// Flagr expects in entityContext, so local and remote evaluation see the same
+// properties.
+func (e EvaluationContext) Properties() map[string]interface{} {
+	props := make(map[string]interface{}, len(e.Context)+2)
+
+	for key, val := range e.Context {
+		flattenInto(props, key, val)
+	}
+
+	if _, ok := props[PropertyEntityID]; !ok && e.EntityID != "" {
+		props[PropertyEntityID] = e.EntityID
+	}
+	if _, ok := props[PropertyEntityType]; !ok && e.EntityType != "" {
+		props[PropertyEntityType] = e.EntityType
+	}
+
+	return props
+}
+
+// StructToAttributes converts a struct (or pointer to struct) into an
+// attribute map.
+//
+// Field names come from the `vexilla` tag, then the `json` tag, then the Go
+// field name. A tag of "-" skips the field and the "omitempty" option skips
+// zero values. Nested structs become nested maps, so they can be addressed
+// with dotted properties. Embedded structs without a tag are inlined.
+func StructToAttributes(v interface{}) map[string]interface{} {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return map[string]interface{}{}
+	}
+	return structToMap(rv)
+}
+
+func structToMap(rv reflect.Value) map[string]interface{} {
+	out := make(map[string]interface{})
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		// Untagged embedded structs are inlined like encoding/json does
+		if field.Anonymous && name == field.Name {
+			if inner := indirect(fv); inner.IsValid() && inner.Kind() == reflect.Struct {
+				for k, val := range structToMap(inner) {
+					if _, exists := out[k]; !exists {
+						out[k] = val
+					}
+				}
+				continue
+			}
+		}
+
+		out[name] = attributeValue(fv)
+	}
+
+	return out
+}
+
+// fieldName resolves the attribute name for a struct field
+func fieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag, ok := field.Tag.Lookup(attributeTag)
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+// attributeValue converts a field value, turning nested structs into maps
+func attributeValue(fv reflect.Value) interface{} {
+	v := indirect(fv)
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Kind() == reflect.Struct && !isLeaf(v) {
+		return structToMap(v)
+	}
+
+	return v.Interface()
+}
+
+// lookupPath walks the remaining path segments through maps and structs
+func lookupPath(root interface{}, path []string) (interface{}, bool) {
+	current := reflect.ValueOf(root)
+
+	for _, segment := range path {
+		current = indirect(current)
+		if !current.IsValid() {
+			return nil, false
+		}
+
+		switch current.Kind() {
+		case reflect.Map:
+			if current.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			next := current.MapIndex(reflect.ValueOf(segment).Convert(current.Type().Key()))
+			if !next.IsValid() {
+				return nil, false
+			}
+			current = next
+
+		case reflect.Struct:
+			next, ok := structField(current, segment)
+			if !ok {
+				return nil, false
+			}
+			current = next
+
+		default:
+			return nil, false
+		}
+	}
+
+	current = indirect(current)
+	if !current.IsValid() {
+		return nil, false
+	}
+
+	return current.Interface(), true
+}
+
+// structField finds a field by attribute name, following embedded structs
+func structField(rv reflect.Value, name string) (reflect.Value, bool) {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldAttr, _, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		if fieldAttr == name {
+			return rv.Field(i), true
+		}
+
+		if field.Anonymous && fieldAttr == field.Name {
+			if inner := indirect(rv.Field(i)); inner.IsValid() && inner.Kind() == reflect.Struct {
+				if v, ok := structField(inner, name); ok {
+					return v, true
+				}
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// flattenInto writes val into out under prefix, expanding maps and structs
+func flattenInto(out map[string]interface{}, prefix string, val interface{}) {
+	rv := indirect(reflect.ValueOf(val))
+	if !rv.IsValid() {
+		out[prefix] = val
+		return
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		iter := rv.MapRange()
+		for iter.Next() {
+			flattenInto(out, prefix+"."+iter.Key().String(), iter.Value().Interface())
+		}
+
+	case rv.Kind() == reflect.Struct && !isLeaf(rv):
+		for k, v := range structToMap(rv) {
+			flattenInto(out, prefix+"."+k, v)
+		}
+
+	default:
+		out[prefix] = rv.Interface()
+	}
+}
+
+// isLeaf reports whether a struct should be treated as a single value
+// (time.Time and other text-marshalable types) rather than expanded
+func isLeaf(rv reflect.Value) bool {
+	if _, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		return true
+	}
+	if rv.CanAddr() {
+		if _, ok := rv.Addr().Interface().(encoding.TextMarshaler); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// indirect dereferences pointers and interfaces
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
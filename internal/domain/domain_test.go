@@ -3,6 +3,7 @@ package domain
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -162,6 +163,20 @@ func TestSegment_Validate_DistributionSum(t *testing.T) {
 	assert.Error(t, seg.Validate())
 }
 
+func TestSegment_Validate_PartialRollout(t *testing.T) {
+	seg := Segment{
+		RolloutPercent: 20,
+		Distributions: []Distribution{
+			{Percent: 50, VariantID: 1},
+			{Percent: 50, VariantID: 2},
+		},
+	}
+	assert.NoError(t, seg.Validate())
+
+	seg.Distributions = []Distribution{{Percent: 20, VariantID: 1}}
+	assert.Error(t, seg.Validate(), "distributions add up to 100, not to the rollout")
+}
+
 func TestFlag_GetVariantByID(t *testing.T) {
 	flag := Flag{
 		Variants: []Variant{
@@ -429,3 +444,134 @@ func TestEvaluationError(t *testing.T) {
 	assert.Contains(t, msg, "flag123")
 	assert.Contains(t, msg, "failed hard")
 }
+
+type lookupAccount struct {
+	Region string `vexilla:"region"`
+	Plan   string `json:"plan"`
+}
+
+type lookupUser struct {
+	Country  string         `vexilla:"country"`
+	Account  lookupAccount  `vexilla:"account"`
+	Backup   *lookupAccount `vexilla:"backup,omitempty"`
+	Email    string         `vexilla:"-"`
+	Verified bool
+	internal string
+}
+
+func TestEvaluationContext_Lookup(t *testing.T) {
+	ctx := NewEvaluationContext("u1").
+		WithAttribute("tier", "gold").
+		WithAttribute("flat.key", "flat").
+		WithAttribute("account", map[string]interface{}{
+			"region": "sa-east-1",
+			"limits": map[string]int{"seats": 10},
+		}).
+		WithAttribute("user", lookupUser{Account: lookupAccount{Region: "us-east-1"}})
+
+	tests := []struct {
+		property string
+		expected interface{}
+		found    bool
+	}{
+		{"tier", "gold", true},
+		{"flat.key", "flat", true},
+		{"account.region", "sa-east-1", true},
+		{"account.limits.seats", 10, true},
+		{"user.account.region", "us-east-1", true},
+		{"user.backup.region", nil, false},
+		{"account.missing", nil, false},
+		{"tier.nested", nil, false},
+		{PropertyEntityID, "u1", true},
+		{PropertyEntityType, "user", true},
+		{"missing", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			val, ok := ctx.Lookup(tt.property)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+}
+
+func TestEvaluationContext_Lookup_AttributeOverridesEntity(t *testing.T) {
+	ctx := NewEvaluationContext("u1").WithAttribute(PropertyEntityID, "override")
+
+	val, ok := ctx.Lookup(PropertyEntityID)
+	assert.True(t, ok)
+	assert.Equal(t, "override", val)
+}
+
+func TestEvaluationContext_Properties(t *testing.T) {
+	ctx := NewEvaluationContext("u1").
+		WithAttribute("tier", "gold").
+		WithAttribute("account", map[string]interface{}{
+			"region": "sa-east-1",
+			"owner":  map[string]interface{}{"id": 7},
+		}).
+		WithAttribute("user", lookupUser{Country: "BR", Account: lookupAccount{Plan: "pro"}})
+
+	props := ctx.Properties()
+
+	assert.Equal(t, "gold", props["tier"])
+	assert.Equal(t, "sa-east-1", props["account.region"])
+	assert.Equal(t, 7, props["account.owner.id"])
+	assert.Equal(t, "BR", props["user.country"])
+	assert.Equal(t, "pro", props["user.account.plan"])
+	assert.Equal(t, "u1", props[PropertyEntityID])
+	assert.Equal(t, "user", props[PropertyEntityType])
+	assert.NotContains(t, props, "account")
+	assert.NotContains(t, props, "user.Email")
+}
+
+func TestStructToAttributes(t *testing.T) {
+	backup := &lookupAccount{Region: "eu-west-1"}
+	attrs := StructToAttributes(&lookupUser{
+		Country:  "BR",
+		Account:  lookupAccount{Region: "sa-east-1", Plan: "pro"},
+		Backup:   backup,
+		Email:    "secret@example.com",
+		Verified: true,
+		internal: "hidden",
+	})
+
+	assert.Equal(t, "BR", attrs["country"])
+	assert.Equal(t, true, attrs["Verified"])
+	assert.Equal(t, map[string]interface{}{"region": "sa-east-1", "plan": "pro"}, attrs["account"])
+	assert.Equal(t, map[string]interface{}{"region": "eu-west-1", "plan": ""}, attrs["backup"])
+	assert.NotContains(t, attrs, "Email")
+	assert.NotContains(t, attrs, "internal")
+
+	withoutBackup := StructToAttributes(lookupUser{})
+	assert.NotContains(t, withoutBackup, "backup")
+
+	assert.Empty(t, StructToAttributes("not a struct"))
+	assert.Empty(t, StructToAttributes(nil))
+}
+
+func TestStructToAttributes_EmbeddedAndLeafTypes(t *testing.T) {
+	type withEmbedded struct {
+		LookupTenant
+		CreatedAt time.Time `vexilla:"created_at"`
+	}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	value := withEmbedded{LookupTenant: LookupTenant{Tenant: "acme"}, CreatedAt: created}
+
+	attrs := StructToAttributes(value)
+	assert.Equal(t, "acme", attrs["tenant"])
+	assert.Equal(t, created, attrs["created_at"])
+
+	// Embedded fields are reachable through dotted lookups too
+	ctx := NewEvaluationContext("u1").WithAttribute("org", value)
+	tenant, ok := ctx.Lookup("org.tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+}
+
+// LookupTenant is exported so it can be embedded in test structs
+type LookupTenant struct {
+	Tenant string `vexilla:"tenant"`
+}
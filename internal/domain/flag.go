@@ -138,9 +138,11 @@ func (s *Segment) Validate() error {
 		total += dist.Percent
 	}
 
-	if len(s.Distributions) > 0 && total != s.RolloutPercent {
+	// As in Flagr, distributions split the rolled-out entities, so they
+	// add up to 100 whatever the rollout percentage
+	if len(s.Distributions) > 0 && total != 100 {
 		return NewValidationError(
-			fmt.Sprintf("distribution percent sum %d must equal rolloutPercent %d", total, s.RolloutPercent),
+			fmt.Sprintf("distribution percent sum %d must equal 100", total),
 		)
 	}
 
@@ -0,0 +1,101 @@
+package domain
+
+// EvaluationTrace records every decision taken while evaluating a flag.
+// It answers "why did this entity get this variant?"
+type EvaluationTrace struct {
+	FlagID  int64
+	FlagKey string
+	Enabled bool
+
+	// Strategy used for this evaluation and why it was chosen
+	Strategy       EvaluationStrategy
+	StrategyReason string
+
+	// Segments in rank order, including the ones that did not match
+	Segments []SegmentTrace
+
+	// Result is the final evaluation result
+	Result *EvaluationResult
+}
+
+// SegmentTrace records how a single segment was evaluated
+type SegmentTrace struct {
+	SegmentID      int64
+	Rank           int
+	Description    string
+	RolloutPercent int
+
+	// Matched is true when every constraint passed
+	Matched bool
+
+	// Selected is true for the segment that produced the result
+	Selected bool
+
+	Constraints []ConstraintTrace
+
+	// Distribution is the distribution chosen in the selected segment
+	Distribution *DistributionTrace
+
+	// Message carries Flagr's debug message for remote evaluations
+	Message string
+}
+
+// ConstraintTrace records how a single constraint was evaluated
+type ConstraintTrace struct {
+	Property string
+	Operator Operator
+	Expected interface{}
+
+	// Actual is the value found in the context (nil when missing)
+	Actual  interface{}
+	Present bool
+
+	Passed bool
+	Error  string
+}
+
+// DistributionTrace describes the distribution that selected the variant
+type DistributionTrace struct {
+	DistributionID int64
+	VariantID      int64
+	VariantKey     string
+	Percent        int
+}
+
+// SelectedSegment returns the segment that produced the result, if any
+func (t *EvaluationTrace) SelectedSegment() (*SegmentTrace, bool) {
+	for i := range t.Segments {
+		if t.Segments[i].Selected {
+			return &t.Segments[i], true
+		}
+	}
+	return nil, false
+}
+
+// NewDistributionTrace describes the distribution of segment that points at
+// variantID, falling back to the segment's first distribution.
+// Returns nil when the segment has no distributions.
+func NewDistributionTrace(flag Flag, segment Segment, variantID int64) *DistributionTrace {
+	if len(segment.Distributions) == 0 {
+		return nil
+	}
+
+	dist := segment.Distributions[0]
+	for _, d := range segment.Distributions {
+		if d.VariantID == variantID {
+			dist = d
+			break
+		}
+	}
+
+	trace := &DistributionTrace{
+		DistributionID: dist.ID,
+		VariantID:      dist.VariantID,
+		Percent:        dist.Percent,
+	}
+	if variant, found := flag.GetVariantByID(dist.VariantID); found {
+		trace.VariantKey = variant.Key
+	}
+
+	return trace
+}
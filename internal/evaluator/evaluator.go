@@ -2,7 +2,11 @@ package evaluator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 	"github.com/expr-lang/expr"
@@ -16,6 +20,9 @@ type Evaluator interface {
 
 	// CanEvaluateLocally determines if a flag can be evaluated locally
 	CanEvaluateLocally(flag domain.Flag) bool
+
+	// Explain evaluates a flag locally and returns a segment-by-segment trace
+	Explain(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error)
 }
 
 // LocalEvaluator implements local flag evaluation using expression engine
@@ -88,6 +95,81 @@ func (e *LocalEvaluator) Evaluate(ctx context.Context, flag domain.Flag, evalCtx
 	return e.defaultResult(flag, "no segments matched"), nil
 }
 
+// Explain evaluates a flag locally and records a trace of every segment and
+// constraint in rank order. Unlike Evaluate it does not stop at the first
+// matching segment, so the trace also shows why later segments would (not)
+// have matched. The returned trace is never nil, even when evaluation fails.
+func (e *LocalEvaluator) Explain(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+	trace := &domain.EvaluationTrace{
+		FlagID:   flag.ID,
+		FlagKey:  flag.Key,
+		Enabled:  flag.Enabled,
+		Strategy: domain.StrategyLocal,
+	}
+
+	result, err := e.Evaluate(ctx, flag, evalCtx)
+	trace.Result = result
+
+	// Disabled flags never reach segment evaluation
+	if !flag.Enabled {
+		return trace, err
+	}
+
+	selected := false
+	for _, segment := range flag.SortedSegments() {
+		segTrace := e.traceSegment(segment, evalCtx)
+
+		if segTrace.Matched && !selected {
+			selected = true
+			segTrace.Selected = true
+			if result != nil {
+				segTrace.Distribution = domain.NewDistributionTrace(flag, segment, result.VariantID)
+			}
+		}
+
+		trace.Segments = append(trace.Segments, segTrace)
+	}
+
+	return trace, err
+}
+
+// traceSegment evaluates every constraint of a segment without short-circuiting
+func (e *LocalEvaluator) traceSegment(segment domain.Segment, evalCtx domain.EvaluationContext) domain.SegmentTrace {
+	segTrace := domain.SegmentTrace{
+		SegmentID:      segment.ID,
+		Rank:           segment.Rank,
+		Description:    segment.Description,
+		RolloutPercent: segment.RolloutPercent,
+		Matched:        true,
+	}
+
+	for _, constraint := range segment.Constraints {
+		actual, present := evalCtx.Lookup(constraint.Property)
+
+		cTrace := domain.ConstraintTrace{
+			Property: constraint.Property,
+			Operator: constraint.Operator,
+			Expected: constraint.Value,
+			Actual:   actual,
+			Present:  present,
+		}
+
+		passed, err := e.evaluateConstraint(constraint, evalCtx)
+		if err != nil {
+			cTrace.Error = err.Error()
+		}
+		cTrace.Passed = passed && err == nil
+
+		if !cTrace.Passed {
+			segTrace.Matched = false
+		}
+
+		segTrace.Constraints = append(segTrace.Constraints, cTrace)
+	}
+
+	return segTrace
+}
+
 // evaluateSegment checks if a segment's constraints match the context
 func (e *LocalEvaluator) evaluateSegment(segment domain.Segment, evalCtx domain.EvaluationContext) (bool, error) {
 	// Empty constraints = always match
@@ -112,8 +194,8 @@ func (e *LocalEvaluator) evaluateSegment(segment domain.Segment, evalCtx domain.
 
 // evaluateConstraint evaluates a single constraint
 func (e *LocalEvaluator) evaluateConstraint(constraint domain.Constraint, evalCtx domain.EvaluationContext) (bool, error) {
-	// Get property value from context
-	propValue, exists := evalCtx.Context[constraint.Property]
+	// Get property value from context (supports dotted paths and entity properties)
+	propValue, exists := evalCtx.Lookup(constraint.Property)
 	if !exists {
 		return false, nil // Missing property = no match
 	}
@@ -136,18 +218,20 @@ func (e *LocalEvaluator) evaluateConstraint(constraint domain.Constraint, evalCt
 		return e.evaluateMatches(propValue, constraint.Value)
 
 	case domain.OperatorLT:
-		return e.evaluateCompare(propValue, constraint.Value, -1), nil
+		cmp, ok := e.evaluateCompare(propValue, constraint.Value)
+		return ok && cmp < 0, nil
 
 	case domain.OperatorLTE:
-		cmp := e.evaluateCompare(propValue, constraint.Value, 0)
-		return cmp, nil
+		cmp, ok := e.evaluateCompare(propValue, constraint.Value)
+		return ok && cmp <= 0, nil
 
 	case domain.OperatorGT:
-		return e.evaluateCompare(propValue, constraint.Value, 1), nil
+		cmp, ok := e.evaluateCompare(propValue, constraint.Value)
+		return ok && cmp > 0, nil
 
 	case domain.OperatorGTE:
-		cmp := e.evaluateCompare(propValue, constraint.Value, 0)
-		return cmp, nil
+		cmp, ok := e.evaluateCompare(propValue, constraint.Value)
+		return ok && cmp >= 0, nil
 
 	default:
 		return false, fmt.Errorf("unsupported operator: %s", constraint.Operator)
@@ -207,26 +291,31 @@ func (e *LocalEvaluator) evaluateMatches(value interface{}, pattern interface{})
 	return matched, nil
 }
 
-// evaluateCompare performs numeric comparison
-func (e *LocalEvaluator) evaluateCompare(a, b interface{}, expectedSign int) bool {
+// evaluateCompare compares two numbers, returning -1, 0 or 1 as a is
+// less than, equal to or greater than b. It returns false when either is
+// not numeric.
+func (e *LocalEvaluator) evaluateCompare(a, b interface{}) (int, bool) {
 	// Convert to float64 for comparison
 	aFloat, aOk := toFloat64(a)
 	bFloat, bOk := toFloat64(b)
 
 	if !aOk || !bOk {
-		return false
+		return 0, false
 	}
 
-	if expectedSign < 0 {
-		return aFloat < bFloat
-	} else if expectedSign > 0 {
-		return aFloat > bFloat
-	} else {
-		return aFloat <= bFloat || aFloat >= bFloat
+	switch {
+	case aFloat < bFloat:
+		return -1, true
+	case aFloat > bFloat:
+		return 1, true
+	default:
+		return 0, true
 	}
 }
 
-// toFloat64 converts various numeric types to float64
+// toFloat64 converts numeric values to float64.
+// Flagr stores constraint values as strings, so numeric strings and
+// json.Number are accepted as well as every int, uint and float kind.
 func toFloat64(v interface{}) (float64, bool) {
 	switch val := v.(type) {
 	case float64:
@@ -239,6 +328,22 @@ func toFloat64(v interface{}) (float64, bool) {
 		return float64(val), true
 	case int32:
 		return float64(val), true
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
 	default:
 		return 0, false
 	}
@@ -3,6 +3,7 @@ package evaluator
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
@@ -236,6 +237,38 @@ func TestEvaluator_EvaluateConstraint_Operators(t *testing.T) {
 			context:  map[string]interface{}{"age": 25},
 			expected: true,
 		},
+		{
+			name: "GT matches string value from Flagr",
+			constraint: domain.Constraint{
+				Property: "age",
+				Operator: domain.OperatorGT,
+				Value:    "18",
+			},
+			context:  map[string]interface{}{"age": uint8(25)},
+			expected: true,
+		},
+		{
+			name: "EQ matches nested map path",
+			constraint: domain.Constraint{
+				Property: "account.region",
+				Operator: domain.OperatorEQ,
+				Value:    "sa-east-1",
+			},
+			context: map[string]interface{}{
+				"account": map[string]interface{}{"region": "sa-east-1"},
+			},
+			expected: true,
+		},
+		{
+			name: "EQ matches entityID property",
+			constraint: domain.Constraint{
+				Property: domain.PropertyEntityID,
+				Operator: domain.OperatorEQ,
+				Value:    "test",
+			},
+			context:  map[string]interface{}{},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -253,6 +286,47 @@ func TestEvaluator_EvaluateConstraint_Operators(t *testing.T) {
 	}
 }
 
+func TestEvaluator_EvaluateConstraint_NumericComparisons(t *testing.T) {
+	eval := New()
+
+	// Flagr stores constraint values as strings
+	tests := []struct {
+		operator domain.Operator
+		value    string
+		age      interface{}
+		expected bool
+	}{
+		{domain.OperatorLT, "18", 5, true},
+		{domain.OperatorLT, "18", 18, false},
+		{domain.OperatorLT, "18", 30, false},
+		{domain.OperatorLTE, "18", 5, true},
+		{domain.OperatorLTE, "18", 18, true},
+		{domain.OperatorLTE, "18", 30, false},
+		{domain.OperatorGT, "18", 5, false},
+		{domain.OperatorGT, "18", 18, false},
+		{domain.OperatorGT, "18", 30, true},
+		{domain.OperatorGTE, "18", 5, false},
+		{domain.OperatorGTE, "18", 18, true},
+		{domain.OperatorGTE, "18", 30, true},
+		{domain.OperatorGTE, "18.5", 18.4, false},
+		{domain.OperatorLTE, " 18 ", "18", true},
+		{domain.OperatorGTE, "18", "adult", false},
+		{domain.OperatorLTE, "eighteen", 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s %s", tt.age, tt.operator, tt.value), func(t *testing.T) {
+			constraint := domain.Constraint{Property: "age", Operator: tt.operator, Value: tt.value}
+			evalCtx := domain.EvaluationContext{Context: map[string]interface{}{"age": tt.age}}
+
+			result, err := eval.evaluateConstraint(constraint, evalCtx)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestEvaluator_EvaluateConstraint_Regex(t *testing.T) {
 	eval := New()
 
@@ -453,3 +527,117 @@ func BenchmarkEvaluator_Evaluate(b *testing.B) {
 		eval.Evaluate(ctx, flag, evalCtx)
 	}
 }
+
+func TestEvaluator_Explain(t *testing.T) {
+	eval := New()
+
+	flag := domain.Flag{
+		ID:      1,
+		Key:     "beta",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             2,
+				Rank:           2,
+				RolloutPercent: 100,
+				Distributions:  []domain.Distribution{{ID: 20, VariantID: 2, Percent: 100}},
+			},
+			{
+				ID:             1,
+				Rank:           1,
+				RolloutPercent: 100,
+				Constraints: []domain.Constraint{
+					{Property: "country", Operator: domain.OperatorEQ, Value: "BR"},
+					{Property: "tier", Operator: domain.OperatorEQ, Value: "premium"},
+				},
+				Distributions: []domain.Distribution{{ID: 10, VariantID: 1, Percent: 100}},
+			},
+		},
+		Variants: []domain.Variant{
+			{ID: 1, Key: "beta"},
+			{ID: 2, Key: "control"},
+		},
+	}
+
+	evalCtx := domain.NewEvaluationContext("user-1").WithAttribute("country", "BR")
+
+	trace, err := eval.Explain(context.Background(), flag, evalCtx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "beta", trace.FlagKey)
+	assert.Equal(t, domain.StrategyLocal, trace.Strategy)
+	require.Len(t, trace.Segments, 2)
+
+	// Rank 1 segment is evaluated first and fails on the missing tier
+	first := trace.Segments[0]
+	assert.Equal(t, int64(1), first.SegmentID)
+	assert.False(t, first.Matched)
+	assert.False(t, first.Selected)
+	require.Len(t, first.Constraints, 2)
+	assert.True(t, first.Constraints[0].Passed)
+	assert.Equal(t, "BR", first.Constraints[0].Actual)
+	assert.False(t, first.Constraints[1].Passed)
+	assert.False(t, first.Constraints[1].Present)
+	assert.Equal(t, "premium", first.Constraints[1].Expected)
+
+	// Catch-all segment is selected
+	second := trace.Segments[1]
+	assert.True(t, second.Matched)
+	assert.True(t, second.Selected)
+	require.NotNil(t, second.Distribution)
+	assert.Equal(t, "control", second.Distribution.VariantKey)
+	assert.Equal(t, 100, second.Distribution.Percent)
+
+	require.NotNil(t, trace.Result)
+	assert.Equal(t, "control", trace.Result.VariantKey)
+
+	selected, ok := trace.SelectedSegment()
+	require.True(t, ok)
+	assert.Equal(t, int64(2), selected.SegmentID)
+}
+
+func TestEvaluator_Explain_DisabledFlag(t *testing.T) {
+	eval := New()
+
+	flag := domain.Flag{
+		ID:       1,
+		Key:      "off",
+		Enabled:  false,
+		Segments: []domain.Segment{{ID: 1, RolloutPercent: 100}},
+	}
+
+	trace, err := eval.Explain(context.Background(), flag, domain.EvaluationContext{})
+	require.NoError(t, err)
+
+	assert.False(t, trace.Enabled)
+	assert.Empty(t, trace.Segments)
+	assert.Equal(t, "flag disabled", trace.Result.EvaluationReason)
+}
+
+func TestEvaluator_Explain_ConstraintError(t *testing.T) {
+	eval := New()
+
+	flag := domain.Flag{
+		ID:      1,
+		Key:     "bad-operator",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             1,
+				RolloutPercent: 100,
+				Constraints:    []domain.Constraint{{Property: "x", Operator: "BOGUS", Value: "1"}},
+				Distributions:  []domain.Distribution{{VariantID: 1, Percent: 100}},
+			},
+		},
+		Variants: []domain.Variant{{ID: 1, Key: "on"}},
+	}
+
+	evalCtx := domain.NewEvaluationContext("u").WithAttribute("x", "1")
+	trace, err := eval.Explain(context.Background(), flag, evalCtx)
+
+	assert.Error(t, err)
+	require.NotNil(t, trace)
+	require.Len(t, trace.Segments, 1)
+	assert.False(t, trace.Segments[0].Matched)
+	assert.Contains(t, trace.Segments[0].Constraints[0].Error, "unsupported operator")
+}
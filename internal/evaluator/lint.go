@@ -0,0 +1,263 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// Severity is the severity of a lint issue
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The flag does not behave as configured
+	SeverityWarning Severity = "warning" // The flag probably does not behave as intended
+	SeverityInfo    Severity = "info"    // The flag works but could be simpler or cheaper
+)
+
+// Lint rules
+const (
+	RuleDistributionSum        = "distribution-sum"
+	RuleUnknownVariant         = "unknown-variant"
+	RuleMissingDistribution    = "missing-distribution"
+	RuleShadowedSegment        = "shadowed-segment"
+	RuleUnknownProperty        = "unknown-property"
+	RuleEnabledWithoutSegments = "enabled-without-segments"
+	RuleUnreachableVariant     = "unreachable-variant"
+	RuleNearDeterministicSplit = "near-deterministic-split"
+)
+
+// nearDeterministicPercent is the share above which a split or rollout is
+// reported as almost deterministic
+const nearDeterministicPercent = 99
+
+// LintIssue is a problem found in a flag
+type LintIssue struct {
+	FlagKey   string   `json:"flag_key"`
+	SegmentID int64    `json:"segment_id,omitempty"`
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Message   string   `json:"message"`
+}
+
+// Linter reports flags that are misconfigured or more expensive to
+// evaluate than they need to be
+type Linter struct {
+	strategy *StrategyDeterminer
+
+	// knownProperties are the context attributes services send; nil
+	// disables the unknown-property rule
+	knownProperties map[string]bool
+}
+
+// NewLinter creates a linter. Constraints on properties other than
+// knownProperties (or their nested attributes, such as "user.country" for
+// "user") are reported; pass nil to skip that check.
+func NewLinter(knownProperties []string) *Linter {
+	l := &Linter{strategy: NewStrategyDeterminer()}
+	if knownProperties != nil {
+		l.knownProperties = make(map[string]bool, len(knownProperties))
+		for _, property := range knownProperties {
+			l.knownProperties[property] = true
+		}
+	}
+	return l
+}
+
+// Lint checks every flag and returns the issues sorted by flag key
+func (l *Linter) Lint(flags []domain.Flag) []LintIssue {
+	sorted := make([]domain.Flag, len(flags))
+	copy(sorted, flags)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	issues := []LintIssue{}
+	for _, flag := range sorted {
+		issues = append(issues, l.LintFlag(flag)...)
+	}
+	return issues
+}
+
+// LintFlag checks a single flag
+func (l *Linter) LintFlag(flag domain.Flag) []LintIssue {
+	var issues []LintIssue
+	report := func(segmentID int64, rule string, severity Severity, format string, args ...any) {
+		issues = append(issues, LintIssue{
+			FlagKey:   flag.Key,
+			SegmentID: segmentID,
+			Rule:      rule,
+			Severity:  severity,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	if flag.Enabled && len(flag.Segments) == 0 {
+		report(0, RuleEnabledWithoutSegments, SeverityWarning,
+			"flag is enabled but has no segments, so it serves no variant")
+	}
+
+	variants := make(map[int64]string, len(flag.Variants))
+	for _, v := range flag.Variants {
+		variants[v.ID] = v.Key
+	}
+	served := make(map[int64]bool, len(flag.Variants))
+
+	segments := flag.SortedSegments()
+	for i, seg := range segments {
+		if len(seg.Distributions) == 0 {
+			if seg.RolloutPercent > 0 {
+				report(seg.ID, RuleMissingDistribution, SeverityWarning,
+					"segment has no distributions, so matching entities get no variant")
+			}
+		} else if sum := distributionSum(seg); sum != 100 {
+			report(seg.ID, RuleDistributionSum, SeverityError,
+				"distributions add up to %d%%, Flagr requires 100%%", sum)
+		}
+
+		for _, dist := range seg.Distributions {
+			if _, ok := variants[dist.VariantID]; !ok {
+				report(seg.ID, RuleUnknownVariant, SeverityError,
+					"distribution references unknown variant %d", dist.VariantID)
+			}
+		}
+
+		if l.knownProperties != nil {
+			for _, c := range seg.Constraints {
+				if !l.isKnownProperty(c.Property) {
+					report(seg.ID, RuleUnknownProperty, SeverityWarning,
+						"constraint on %q, which services never send", c.Property)
+				}
+			}
+		}
+
+		if by := shadowingSegment(segments[:i], seg); by != nil {
+			reason := "a subset of its constraints"
+			if len(by.Constraints) == 0 {
+				reason = "no constraints"
+			}
+			report(seg.ID, RuleShadowedSegment, SeverityWarning,
+				"segment is never evaluated: segment %d before it has %s", by.ID, reason)
+			continue
+		}
+
+		if seg.RolloutPercent > 0 {
+			for _, dist := range seg.Distributions {
+				if dist.Percent > 0 {
+					served[dist.VariantID] = true
+				}
+			}
+		}
+	}
+
+	if len(segments) > 0 {
+		for _, v := range flag.Variants {
+			if !served[v.ID] {
+				report(0, RuleUnreachableVariant, SeverityInfo,
+					"variant %q is not served by any segment", v.Key)
+			}
+		}
+	}
+
+	if seg, why := l.nearDeterministic(flag); seg != nil {
+		report(seg.ID, RuleNearDeterministicSplit, SeverityInfo,
+			"flag is evaluated by Flagr only because of %s; serving 100%% would make it local", why)
+	}
+
+	return issues
+}
+
+// isKnownProperty reports whether services send the property, directly or
+// as a nested attribute of a known property
+func (l *Linter) isKnownProperty(property string) bool {
+	for {
+		if l.knownProperties[property] {
+			return true
+		}
+		i := strings.LastIndex(property, ".")
+		if i < 0 {
+			return false
+		}
+		property = property[:i]
+	}
+}
+
+// nearDeterministic returns the segment making an otherwise local flag
+// remote with an almost deterministic rollout or split, and describes it
+func (l *Linter) nearDeterministic(flag domain.Flag) (*domain.Segment, string) {
+	if l.strategy.Determine(flag) != domain.StrategyRemote {
+		return nil, ""
+	}
+
+	var culprit *domain.Segment
+	var why string
+	for _, seg := range flag.SortedSegments() {
+		if !l.strategy.requiresFlagr(seg) {
+			continue
+		}
+
+		if seg.RolloutPercent < nearDeterministicPercent {
+			return nil, ""
+		}
+
+		largest := 0
+		for _, dist := range seg.Distributions {
+			largest = max(largest, dist.Percent)
+		}
+		if largest < nearDeterministicPercent {
+			return nil, ""
+		}
+
+		if culprit == nil {
+			culprit = &seg
+			if largest < 100 {
+				why = fmt.Sprintf("a %d/%d split in segment %d", largest, 100-largest, seg.ID)
+			} else {
+				why = fmt.Sprintf("a %d%% rollout in segment %d", seg.RolloutPercent, seg.ID)
+			}
+		}
+	}
+	return culprit, why
+}
+
+func distributionSum(seg domain.Segment) int {
+	sum := 0
+	for _, dist := range seg.Distributions {
+		sum += dist.Percent
+	}
+	return sum
+}
+
+// shadowingSegment returns the earlier segment that catches every entity
+// seg matches, so seg is never evaluated. Like Flagr and MatchRollout,
+// evaluation stops at the first segment whose constraints match, even when
+// its rollout leaves the entity out.
+func shadowingSegment(earlier []domain.Segment, seg domain.Segment) *domain.Segment {
+	for i := range earlier {
+		if constraintsSubset(earlier[i].Constraints, seg.Constraints) {
+			return &earlier[i]
+		}
+	}
+	return nil
+}
+
+// constraintsSubset reports whether every constraint of sub is also a
+// constraint of set
+func constraintsSubset(sub, set []domain.Constraint) bool {
+	for _, c := range sub {
+		found := false
+		for _, other := range set {
+			if c.Property == other.Property && c.Operator == other.Operator &&
+				fmt.Sprint(c.Value) == fmt.Sprint(other.Value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lintVariants = []domain.Variant{{ID: 1, Key: "on"}, {ID: 2, Key: "off"}}
+
+func brazilSegment(id int64) domain.Segment {
+	return domain.Segment{
+		ID:             id,
+		Rank:           int(id),
+		RolloutPercent: 100,
+		Constraints:    []domain.Constraint{{Property: "country", Operator: domain.OperatorEQ, Value: "BR"}},
+		Distributions:  []domain.Distribution{{VariantID: 1, Percent: 100}},
+	}
+}
+
+func catchAllSegment(id int64) domain.Segment {
+	return domain.Segment{
+		ID:             id,
+		Rank:           int(id),
+		RolloutPercent: 100,
+		Distributions:  []domain.Distribution{{VariantID: 2, Percent: 100}},
+	}
+}
+
+func rules(issues []LintIssue) []string {
+	out := []string{}
+	for _, issue := range issues {
+		out = append(out, issue.Rule)
+	}
+	return out
+}
+
+func TestLinter_LintFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     domain.Flag
+		expected []string
+	}{
+		{
+			name: "clean flag",
+			flag: domain.Flag{
+				Key: "clean", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{brazilSegment(1), catchAllSegment(2)},
+			},
+			expected: []string{},
+		},
+		{
+			name:     "enabled without segments",
+			flag:     domain.Flag{Key: "empty", Enabled: true},
+			expected: []string{RuleEnabledWithoutSegments},
+		},
+		{
+			name:     "disabled without segments",
+			flag:     domain.Flag{Key: "empty", Enabled: false},
+			expected: []string{},
+		},
+		{
+			name: "distributions not adding up to 100",
+			flag: domain.Flag{
+				Key: "uneven", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{{
+					ID: 1, RolloutPercent: 50,
+					Distributions: []domain.Distribution{{VariantID: 1, Percent: 30}, {VariantID: 2, Percent: 20}},
+				}},
+			},
+			expected: []string{RuleDistributionSum},
+		},
+		{
+			name: "segment without distributions",
+			flag: domain.Flag{
+				Key: "nothing", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{{
+					ID: 1, RolloutPercent: 100,
+					Constraints: []domain.Constraint{{Property: "country", Operator: domain.OperatorEQ, Value: "BR"}},
+				}, catchAllSegment(2)},
+			},
+			expected: []string{RuleMissingDistribution, RuleUnreachableVariant},
+		},
+		{
+			name: "unknown variant",
+			flag: domain.Flag{
+				Key: "dangling", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{{
+					ID: 1, RolloutPercent: 100,
+					Distributions: []domain.Distribution{{VariantID: 9, Percent: 100}},
+				}},
+			},
+			expected: []string{RuleUnknownVariant, RuleUnreachableVariant, RuleUnreachableVariant},
+		},
+		{
+			name: "segment after catch-all",
+			flag: domain.Flag{
+				Key: "shadowed", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{catchAllSegment(1), brazilSegment(2)},
+			},
+			expected: []string{RuleShadowedSegment, RuleUnreachableVariant},
+		},
+		{
+			name: "segment after a broader segment",
+			flag: domain.Flag{
+				Key: "narrower", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{brazilSegment(1), {
+					ID: 2, Rank: 2, RolloutPercent: 100,
+					Constraints: []domain.Constraint{
+						{Property: "country", Operator: domain.OperatorEQ, Value: "BR"},
+						{Property: "tier", Operator: domain.OperatorEQ, Value: "gold"},
+					},
+					Distributions: []domain.Distribution{{VariantID: 2, Percent: 100}},
+				}},
+			},
+			expected: []string{RuleShadowedSegment, RuleUnreachableVariant},
+		},
+		{
+			name: "entities outside a partial rollout do not fall through",
+			flag: domain.Flag{
+				Key: "partial", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{
+					{ID: 1, RolloutPercent: 50, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}},
+					catchAllSegment(2),
+				},
+			},
+			expected: []string{RuleShadowedSegment, RuleUnreachableVariant},
+		},
+		{
+			name: "99/1 split",
+			flag: domain.Flag{
+				Key: "almost", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{brazilSegment(1), {
+					ID: 2, Rank: 2, RolloutPercent: 100,
+					Distributions: []domain.Distribution{{VariantID: 1, Percent: 1}, {VariantID: 2, Percent: 99}},
+				}},
+			},
+			expected: []string{RuleNearDeterministicSplit},
+		},
+		{
+			name: "50/50 split",
+			flag: domain.Flag{
+				Key: "experiment", Enabled: true, Variants: lintVariants,
+				Segments: []domain.Segment{{
+					ID: 1, RolloutPercent: 100,
+					Distributions: []domain.Distribution{{VariantID: 1, Percent: 50}, {VariantID: 2, Percent: 50}},
+				}},
+			},
+			expected: []string{},
+		},
+	}
+
+	linter := NewLinter(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rules(linter.LintFlag(tt.flag)))
+		})
+	}
+}
+
+func TestLinter_ShadowedSegmentsAreNeverEvaluated(t *testing.T) {
+	flags := []domain.Flag{
+		{
+			ID: 1, Key: "partial", Enabled: true, Variants: lintVariants,
+			Segments: []domain.Segment{
+				{ID: 1, Rank: 1, RolloutPercent: 50, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}},
+				catchAllSegment(2),
+			},
+		},
+		{
+			ID: 2, Key: "shadowed", Enabled: true, Variants: lintVariants,
+			Segments: []domain.Segment{catchAllSegment(1), brazilSegment(2)},
+		},
+	}
+
+	linter := NewLinter(nil)
+	eval := New()
+	for _, flag := range flags {
+		shadowed := map[int64]bool{}
+		for _, issue := range linter.LintFlag(flag) {
+			if issue.Rule == RuleShadowedSegment {
+				shadowed[issue.SegmentID] = true
+			}
+		}
+		require.NotEmpty(t, shadowed, flag.Key)
+
+		// The local rollout evaluation agrees with the linter
+		for i := 0; i < 500; i++ {
+			evalCtx := domain.NewEvaluationContext(fmt.Sprintf("user-%d", i)).WithAttribute("country", "BR")
+			result, err := eval.EvaluateRollout(context.Background(), flag, evalCtx)
+			require.NoError(t, err)
+			assert.False(t, shadowed[result.SegmentID], "%s: segment %d was evaluated", flag.Key, result.SegmentID)
+		}
+	}
+}
+
+func TestLinter_Messages(t *testing.T) {
+	linter := NewLinter(nil)
+
+	issues := linter.LintFlag(domain.Flag{
+		Key: "almost", Enabled: true, Variants: lintVariants,
+		Segments: []domain.Segment{{
+			ID: 7, RolloutPercent: 100,
+			Distributions: []domain.Distribution{{VariantID: 1, Percent: 99}, {VariantID: 2, Percent: 1}},
+		}},
+	})
+	assert.Equal(t, []LintIssue{{
+		FlagKey:   "almost",
+		SegmentID: 7,
+		Rule:      RuleNearDeterministicSplit,
+		Severity:  SeverityInfo,
+		Message:   "flag is evaluated by Flagr only because of a 99/1 split in segment 7; serving 100% would make it local",
+	}}, issues)
+
+	issues = linter.LintFlag(domain.Flag{
+		Key: "shadowed", Enabled: true, Variants: lintVariants,
+		Segments: []domain.Segment{catchAllSegment(1), brazilSegment(2)},
+	})
+	assert.Equal(t, "segment is never evaluated: segment 1 before it has no constraints", issues[0].Message)
+	assert.Equal(t, `variant "on" is not served by any segment`, issues[1].Message)
+}
+
+func TestLinter_KnownProperties(t *testing.T) {
+	flag := domain.Flag{
+		Key: "targeted", Enabled: true, Variants: lintVariants,
+		Segments: []domain.Segment{{
+			ID: 1, RolloutPercent: 100,
+			Constraints: []domain.Constraint{
+				{Property: "country", Operator: domain.OperatorEQ, Value: "BR"},
+				{Property: "user.tier", Operator: domain.OperatorEQ, Value: "gold"},
+				{Property: "plan", Operator: domain.OperatorEQ, Value: "pro"},
+			},
+			Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}},
+		}, catchAllSegment(2)},
+	}
+
+	issues := NewLinter([]string{"country", "user"}).LintFlag(flag)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, RuleUnknownProperty, issues[0].Rule)
+	assert.Equal(t, `constraint on "plan", which services never send`, issues[0].Message)
+
+	assert.Empty(t, NewLinter(nil).LintFlag(flag), "unknown properties are only checked when known properties are given")
+}
+
+func TestLinter_Lint(t *testing.T) {
+	issues := NewLinter(nil).Lint([]domain.Flag{
+		{Key: "zeta", Enabled: true},
+		{Key: "alpha", Enabled: true},
+		{Key: "clean", Enabled: false},
+	})
+
+	assert.Len(t, issues, 2)
+	assert.Equal(t, "alpha", issues[0].FlagKey)
+	assert.Equal(t, "zeta", issues[1].FlagKey)
+
+	assert.NotNil(t, NewLinter(nil).Lint(nil), "no issues is an empty list")
+}
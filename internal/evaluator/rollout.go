@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// totalBuckets is the number of buckets entities are hashed into (same as Flagr)
+const totalBuckets = 1000
+
+// EvaluateRollout evaluates a flag without Flagr, including partial
+// rollouts and multi-variant distributions.
+//
+// Entities are bucketed the way Flagr does it: crc32(flagID + entityID)
+// modulo 1000 picks the distribution, and the position inside the
+// distribution's range decides whether the entity is within the rollout.
+// As in Flagr, evaluation stops at the first segment whose constraints
+// match: when its rollout leaves the entity out, the result has no variant.
+func (e *LocalEvaluator) EvaluateRollout(ctx context.Context, flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
+	if !flag.Enabled {
+		return e.Evaluate(ctx, flag, evalCtx)
+	}
+
+	if len(flag.Segments) == 0 {
+		return e.defaultResult(flag, "no segments"), nil
+	}
+
+	segment, variant, err := e.MatchRollout(flag, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil {
+		return e.defaultResult(flag, "no segments matched"), nil
+	}
+	if variant == nil {
+		return &domain.EvaluationResult{
+			FlagID:           flag.ID,
+			FlagKey:          flag.Key,
+			SegmentID:        segment.ID,
+			EvaluationReason: fmt.Sprintf("segment %d rollout excludes entity", segment.ID),
+		}, nil
+	}
+
+	return &domain.EvaluationResult{
+		FlagID:            flag.ID,
+		FlagKey:           flag.Key,
+		SegmentID:         segment.ID,
+		VariantID:         variant.ID,
+		VariantKey:        variant.Key,
+		VariantAttachment: variant.Attachment,
+		EvaluationReason:  fmt.Sprintf("matched segment %d", segment.ID),
+	}, nil
+}
+
+// MatchRollout returns the first segment whose constraints match evalCtx,
+// with the variant it serves. Like Flagr, evaluation stops at that segment:
+// the variant is nil when its rollout leaves the entity out, and both are
+// nil when no segment matches. The flag's Enabled state is not checked.
+func (e *LocalEvaluator) MatchRollout(flag domain.Flag, evalCtx domain.EvaluationContext) (*domain.Segment, *domain.Variant, error) {
+	salt := strconv.FormatInt(flag.ID, 10)
+
+	for _, segment := range flag.SortedSegments() {
+		matched, err := e.evaluateSegment(segment, evalCtx)
+		if err != nil {
+			return nil, nil, domain.NewEvaluationError(flag.Key, "segment evaluation failed", err)
+		}
+		if !matched {
+			continue
+		}
+
+		variantID, ok := rollout(segment, evalCtx.EntityID, salt)
+		if !ok {
+			return &segment, nil, nil
+		}
+
+		variant, found := flag.GetVariantByID(variantID)
+		if !found {
+			return nil, nil, domain.NewEvaluationError(flag.Key, fmt.Sprintf("variant %d not found", variantID), nil)
+		}
+
+		return &segment, variant, nil
+	}
+
+	return nil, nil, nil
+}
+
+// rollout picks the variant of a segment for an entity.
+// Returns false when the entity falls outside the segment's rollout.
+func rollout(segment domain.Segment, entityID, salt string) (int64, bool) {
+	if entityID == "" || segment.RolloutPercent <= 0 || len(segment.Distributions) == 0 {
+		return 0, false
+	}
+
+	// Distribution percents are normalized to their sum, so segments whose
+	// distributions add up to the rollout percent bucket the same way as
+	// segments whose distributions add up to 100
+	total := 0
+	for _, d := range segment.Distributions {
+		total += d.Percent
+	}
+	if total <= 0 {
+		return 0, false
+	}
+
+	accumulated := make([]int, len(segment.Distributions))
+	sum := 0
+	for i, d := range segment.Distributions {
+		sum += d.Percent
+		accumulated[i] = sum * totalBuckets / total
+	}
+
+	bucket := int(crc32.ChecksumIEEE([]byte(salt+entityID)) % totalBuckets)
+	index := sort.SearchInts(accumulated, bucket+1)
+	if index >= len(accumulated) {
+		return 0, false
+	}
+	variantID := segment.Distributions[index].VariantID
+
+	if segment.RolloutPercent >= 100 {
+		return variantID, true
+	}
+
+	lower := 0
+	if index > 0 {
+		lower = accumulated[index-1]
+	}
+	width := accumulated[index] - lower
+	if width <= 0 {
+		return 0, false
+	}
+
+	// The entity's relative position inside its distribution's range
+	if 100*(bucket-lower)/width < segment.RolloutPercent {
+		return variantID, true
+	}
+
+	return 0, false
+}
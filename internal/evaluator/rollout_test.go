@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func abFlag(rollout int) domain.Flag {
+	return domain.Flag{
+		ID:      42,
+		Key:     "ab-test",
+		Enabled: true,
+		Segments: []domain.Segment{
+			{
+				ID:             1,
+				Rank:           1,
+				RolloutPercent: rollout,
+				Distributions: []domain.Distribution{
+					{ID: 1, VariantID: 1, Percent: 50},
+					{ID: 2, VariantID: 2, Percent: 50},
+				},
+			},
+		},
+		Variants: []domain.Variant{{ID: 1, Key: "control"}, {ID: 2, Key: "treatment"}},
+	}
+}
+
+func TestEvaluator_EvaluateRollout_Distribution(t *testing.T) {
+	eval := New()
+	flag := abFlag(100)
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		result, err := eval.EvaluateRollout(context.Background(), flag, domain.NewEvaluationContext(fmt.Sprintf("user-%d", i)))
+		require.NoError(t, err)
+		counts[result.VariantKey]++
+	}
+
+	assert.InDelta(t, 1000, counts["control"], 150)
+	assert.InDelta(t, 1000, counts["treatment"], 150)
+
+	// Deterministic per entity
+	first, _ := eval.EvaluateRollout(context.Background(), flag, domain.NewEvaluationContext("user-7"))
+	second, _ := eval.EvaluateRollout(context.Background(), flag, domain.NewEvaluationContext("user-7"))
+	assert.Equal(t, first.VariantKey, second.VariantKey)
+}
+
+func TestEvaluator_EvaluateRollout_PartialRollout(t *testing.T) {
+	eval := New()
+	flag := abFlag(30)
+
+	matched := 0
+	for i := 0; i < 2000; i++ {
+		result, err := eval.EvaluateRollout(context.Background(), flag, domain.NewEvaluationContext(fmt.Sprintf("user-%d", i)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.SegmentID)
+		if result.VariantKey != "" {
+			matched++
+		}
+	}
+
+	assert.InDelta(t, 600, matched, 120)
+}
+
+func TestEvaluator_EvaluateRollout_StopsAtFirstMatch(t *testing.T) {
+	eval := New()
+	flag := abFlag(20)
+	flag.Segments = append(flag.Segments, domain.Segment{
+		ID:             2,
+		Rank:           2,
+		RolloutPercent: 100,
+		Distributions:  []domain.Distribution{{ID: 3, VariantID: 2, Percent: 100}},
+	})
+
+	// Like Flagr, entities left out of the 20% rollout get no variant
+	// instead of falling through to the catch-all segment
+	excluded := 0
+	for i := 0; i < 1000; i++ {
+		result, err := eval.EvaluateRollout(context.Background(), flag, domain.NewEvaluationContext(fmt.Sprintf("user-%d", i)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.SegmentID)
+		if result.VariantKey == "" {
+			excluded++
+			assert.Equal(t, "segment 1 rollout excludes entity", result.EvaluationReason)
+		}
+	}
+	assert.InDelta(t, 800, excluded, 80)
+
+	// Entities without an ID cannot be bucketed
+	result, err := eval.EvaluateRollout(context.Background(), flag, domain.EvaluationContext{})
+	require.NoError(t, err)
+	assert.Empty(t, result.VariantKey)
+}
+
+func TestEvaluator_EvaluateRollout_DisabledFlag(t *testing.T) {
+	flag := abFlag(100)
+	flag.Enabled = false
+
+	result, err := New().EvaluateRollout(context.Background(), flag, domain.NewEvaluationContext("user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "flag disabled", result.EvaluationReason)
+}
@@ -0,0 +1,143 @@
+// Package filewatch reloads a file whenever it changes on disk.
+package filewatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often the file is checked for changes
+const DefaultPollInterval = 2 * time.Second
+
+// Watcher polls a file for modification time and size changes and calls
+// a load function on every change. A failed reload is reported through
+// OnError; what the load function keeps on failure is up to the caller.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	load     func() error
+
+	// OnError is called when a reload fails (optional)
+	OnError func(error)
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	lastErr error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a watcher for path. A non-positive interval uses
+// DefaultPollInterval.
+func New(path string, interval time.Duration, load func() error) *Watcher {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Watcher{
+		path:     path,
+		interval: interval,
+		load:     load,
+	}
+}
+
+// Start loads the file and begins polling it in the background.
+// The initial load must succeed.
+func (w *Watcher) Start(ctx context.Context) error {
+	if err := w.reload(); err != nil {
+		return err
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	go w.loop(ctx)
+
+	return nil
+}
+
+// Stop stops polling and waits for the background loop to exit
+func (w *Watcher) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+// Path returns the watched file
+func (w *Watcher) Path() string {
+	return w.path
+}
+
+// LastError returns the error of the last reload attempt, if it failed
+func (w *Watcher) LastError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.lastErr
+}
+
+func (w *Watcher) loop(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+			if err := w.reload(); err != nil && w.OnError != nil {
+				w.OnError(err)
+			}
+		}
+	}
+}
+
+// changed reports whether the file differs from the last loaded version
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err != nil {
+		// Report a missing file once
+		return w.lastErr == nil
+	}
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+// reload calls the load function and records the file version
+func (w *Watcher) reload() error {
+	info, statErr := os.Stat(w.path)
+	err := statErr
+	if err == nil {
+		err = w.load()
+	} else {
+		err = fmt.Errorf("failed to read %s: %w", w.path, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastErr = err
+	if statErr == nil {
+		// Remember the version even when it is invalid so that a broken
+		// file is reported once instead of on every tick
+		w.modTime = info.ModTime()
+		w.size = info.Size()
+	}
+
+	return err
+}
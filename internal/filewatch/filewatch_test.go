@@ -0,0 +1,82 @@
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	writeFile(t, path, "first")
+
+	var loads atomic.Int64
+	var content atomic.Value
+	failing := errors.New("invalid file")
+	watcher := New(path, 10*time.Millisecond, func() error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		loads.Add(1)
+		if string(data) == "broken" {
+			return failing
+		}
+		content.Store(string(data))
+		return nil
+	})
+	errs := make(chan error, 10)
+	watcher.OnError = func(err error) { errs <- err }
+
+	require.NoError(t, watcher.Start(context.Background()))
+	defer watcher.Stop()
+	assert.Equal(t, int64(1), loads.Load(), "Start loads the file")
+	assert.Equal(t, "first", content.Load())
+
+	writeFile(t, path, "second version")
+	assert.Eventually(t, func() bool {
+		return content.Load() == "second version"
+	}, time.Second, 10*time.Millisecond)
+
+	// An unchanged file is not reloaded
+	loaded := loads.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, loaded, loads.Load())
+
+	// A failed reload is reported once
+	writeFile(t, path, "broken")
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, failing)
+	case <-time.After(time.Second):
+		t.Fatal("expected reload error")
+	}
+	assert.ErrorIs(t, watcher.LastError(), failing)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, errs)
+}
+
+func TestWatcher_StartFailsOnMissingFile(t *testing.T) {
+	watcher := New(filepath.Join(t.TempDir(), "missing.yaml"), 0, func() error { return nil })
+	assert.Error(t, watcher.Start(context.Background()))
+
+	// Stop is safe when Start failed
+	watcher.Stop()
+}
+
+// writeFile writes content and bumps the modification time so that
+// changes are detected even on filesystems with coarse timestamps
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	stamp := time.Now().Add(time.Duration(len(content)) * time.Second)
+	require.NoError(t, os.Chtimes(path, stamp, stamp))
+}
@@ -1,6 +1,9 @@
 package flagr
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 )
 
@@ -129,6 +132,33 @@ func EvaluationResultToDomain(resp EvaluationResponse) domain.EvaluationResult {
 	}
 }
 
+// EvaluationTraceFromResponse converts a debug-enabled EvaluationResponse to
+// a trace. Flagr only reports a message per segment, so constraint details
+// are left empty; the segment that produced the result is marked as selected.
+func EvaluationTraceFromResponse(resp EvaluationResponse) domain.EvaluationTrace {
+	result := EvaluationResultToDomain(resp)
+
+	trace := domain.EvaluationTrace{
+		FlagID:         resp.FlagID,
+		FlagKey:        resp.FlagKey,
+		Strategy:       domain.StrategyRemote,
+		StrategyReason: resp.EvalDebugLog.Msg,
+		Result:         &result,
+	}
+
+	for _, log := range resp.EvalDebugLog.SegmentDebugLogs {
+		selected := resp.SegmentID != 0 && log.SegmentID == resp.SegmentID
+		trace.Segments = append(trace.Segments, domain.SegmentTrace{
+			SegmentID: log.SegmentID,
+			Matched:   selected,
+			Selected:  selected,
+			Message:   log.Msg,
+		})
+	}
+
+	return trace
+}
+
 // extractEvaluationReason extracts reason from debug log
 func extractEvaluationReason(log EvalDebugLog) string {
 	if log.Msg != "" {
@@ -142,12 +172,93 @@ func extractEvaluationReason(log EvalDebugLog) string {
 
 // FromDomain converts domain models to Flagr API models
 
-// EvaluationContextFromDomain converts domain.EvaluationContext to EvaluationRequest
+// FlagFromDomain converts domain.Flag to the Flagr API model. Constraint
+// values that are not strings are encoded as JSON.
+func FlagFromDomain(f domain.Flag) FlagrFlag {
+	flag := FlagrFlag{
+		ID:                 f.ID,
+		Key:                f.Key,
+		Description:        f.Description,
+		Enabled:            f.Enabled,
+		Segments:           make([]FlagrSegment, len(f.Segments)),
+		Variants:           make([]FlagrVariant, len(f.Variants)),
+		Tags:               make([]Tag, len(f.Tags)),
+		DataRecordsEnabled: f.DataRecordsEnabled,
+		UpdatedAt:          f.UpdatedAt,
+	}
+
+	for i, s := range f.Segments {
+		flag.Segments[i] = SegmentFromDomain(s)
+	}
+
+	for i, v := range f.Variants {
+		flag.Variants[i] = FlagrVariant{ID: v.ID, Key: v.Key, Attachment: v.Attachment}
+	}
+
+	for i, t := range f.Tags {
+		flag.Tags[i] = Tag{Value: t.Value}
+	}
+
+	return flag
+}
+
+// SegmentFromDomain converts a domain.Segment to a FlagrSegment
+func SegmentFromDomain(s domain.Segment) FlagrSegment {
+	segment := FlagrSegment{
+		ID:             s.ID,
+		Rank:           s.Rank,
+		Description:    s.Description,
+		RolloutPercent: int64(s.RolloutPercent),
+		Constraints:    make([]FlagrConstraint, len(s.Constraints)),
+		Distributions:  make([]FlagrDistribution, len(s.Distributions)),
+	}
+	for i, c := range s.Constraints {
+		segment.Constraints[i] = ConstraintFromDomain(c)
+	}
+	for i, d := range s.Distributions {
+		segment.Distributions[i] = FlagrDistribution{
+			ID:        d.ID,
+			Percent:   int64(d.Percent),
+			VariantID: d.VariantID,
+		}
+	}
+	return segment
+}
+
+// ConstraintFromDomain converts a domain.Constraint to a FlagrConstraint
+func ConstraintFromDomain(c domain.Constraint) FlagrConstraint {
+	return FlagrConstraint{
+		ID:       c.ID,
+		Property: c.Property,
+		Operator: string(c.Operator),
+		Value:    constraintValue(c.Value),
+	}
+}
+
+// constraintValue renders a constraint value as Flagr stores it
+func constraintValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(raw)
+	}
+}
+
+// EvaluationContextFromDomain converts domain.EvaluationContext to EvaluationRequest.
+// Nested attributes are flattened into dotted keys so Flagr constraints see
+// the same properties as local evaluation.
 func EvaluationContextFromDomain(flagKey string, ctx domain.EvaluationContext) EvaluationRequest {
 	return EvaluationRequest{
 		FlagKey:       flagKey,
 		EntityID:      ctx.EntityID,
 		EntityType:    ctx.EntityType,
-		EntityContext: ctx.Context,
+		EntityContext: ctx.Properties(),
 	}
 }
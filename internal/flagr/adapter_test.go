@@ -297,6 +297,40 @@ func TestFlagToDomain_RoundTrip(t *testing.T) {
 	assert.Equal(t, original.Tags[0].Value, domainFlag.Tags[0].Value)
 }
 
+func TestFlagFromDomain(t *testing.T) {
+	original := FlagrFlag{
+		ID:      1,
+		Key:     "from_domain",
+		Enabled: true,
+		Segments: []FlagrSegment{
+			{
+				ID:             1,
+				Rank:           1,
+				RolloutPercent: 50,
+				Constraints: []FlagrConstraint{
+					{ID: 1, Property: "country", Operator: "EQ", Value: "BR"},
+				},
+				Distributions: []FlagrDistribution{{ID: 1, Percent: 100, VariantID: 1}},
+			},
+		},
+		Variants: []FlagrVariant{{ID: 1, Key: "on", Attachment: map[string]json.RawMessage{"enabled": json.RawMessage(`true`)}}},
+		Tags:     []Tag{{Value: "checkout"}},
+	}
+
+	assert.Equal(t, original, FlagFromDomain(FlagToDomain(&original)))
+
+	// Non-string constraint values are encoded as JSON
+	flag := domain.Flag{Segments: []domain.Segment{{
+		Constraints: []domain.Constraint{
+			{Property: "country", Operator: domain.OperatorIN, Value: []interface{}{"BR", "US"}},
+			{Property: "age", Operator: domain.OperatorGTE, Value: 18},
+		},
+	}}}
+	converted := FlagFromDomain(flag)
+	assert.Equal(t, `["BR","US"]`, converted.Segments[0].Constraints[0].Value)
+	assert.Equal(t, "18", converted.Segments[0].Constraints[1].Value)
+}
+
 func TestEvaluationContextFromDomain_RoundTrip(t *testing.T) {
 	// Create domain context
 	domainCtx := domain.EvaluationContext{
@@ -323,6 +357,57 @@ func TestEvaluationContextFromDomain_RoundTrip(t *testing.T) {
 	assert.Equal(t, true, request.EntityContext["active"])
 }
 
+func TestEvaluationContextFromDomain_FlattensNestedAttributes(t *testing.T) {
+	domainCtx := domain.EvaluationContext{
+		EntityID:   "test_user",
+		EntityType: "account",
+		Context: map[string]interface{}{
+			"country": "BR",
+			"account": map[string]interface{}{
+				"region": "sa-east-1",
+				"plan":   map[string]interface{}{"tier": "pro"},
+			},
+		},
+	}
+
+	request := EvaluationContextFromDomain("test_flag", domainCtx)
+
+	assert.Equal(t, "BR", request.EntityContext["country"])
+	assert.Equal(t, "sa-east-1", request.EntityContext["account.region"])
+	assert.Equal(t, "pro", request.EntityContext["account.plan.tier"])
+	assert.Equal(t, "test_user", request.EntityContext[domain.PropertyEntityID])
+	assert.Equal(t, "account", request.EntityContext[domain.PropertyEntityType])
+	assert.NotContains(t, request.EntityContext, "account")
+}
+
+func TestEvaluationTraceFromResponse(t *testing.T) {
+	resp := EvaluationResponse{
+		FlagID:     3,
+		FlagKey:    "ab-test",
+		SegmentID:  20,
+		VariantID:  2,
+		VariantKey: "treatment",
+		EvalDebugLog: EvalDebugLog{
+			SegmentDebugLogs: []SegmentDebugLog{
+				{SegmentID: 10, Msg: "constraint not match"},
+				{SegmentID: 20, Msg: "matched all constraints. rollout yes."},
+			},
+		},
+	}
+
+	trace := EvaluationTraceFromResponse(resp)
+
+	assert.Equal(t, "ab-test", trace.FlagKey)
+	assert.Equal(t, domain.StrategyRemote, trace.Strategy)
+	require.NotNil(t, trace.Result)
+	assert.Equal(t, "treatment", trace.Result.VariantKey)
+	require.Len(t, trace.Segments, 2)
+	assert.False(t, trace.Segments[0].Selected)
+	assert.Equal(t, "constraint not match", trace.Segments[0].Message)
+	assert.True(t, trace.Segments[1].Selected)
+	assert.True(t, trace.Segments[1].Matched)
+}
+
 func BenchmarkFlagToDomain(b *testing.B) {
 	flagrFlag := &FlagrFlag{
 		ID:          1,
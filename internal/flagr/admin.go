@@ -0,0 +1,283 @@
+package flagr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// AdminClient creates, updates and deletes flags through Flagr's API.
+// Reads and idempotent writes (PUT, DELETE) are retried like HTTPClient
+// requests; creations (POST) are sent once so a retry cannot create
+// duplicates.
+type AdminClient struct {
+	*HTTPClient
+}
+
+// NewAdminClient creates a Flagr admin client
+func NewAdminClient(config Config) *AdminClient {
+	return &AdminClient{HTTPClient: NewHTTPClient(config)}
+}
+
+// CreateFlagRequest is the body of POST /flags
+type CreateFlagRequest struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// UpdateFlagRequest is the body of PUT /flags/{flagID}
+type UpdateFlagRequest struct {
+	Key                string `json:"key,omitempty"`
+	Description        string `json:"description"`
+	DataRecordsEnabled *bool  `json:"dataRecordsEnabled,omitempty"`
+}
+
+// VariantRequest is the body used to create or update a variant
+type VariantRequest struct {
+	Key        string                     `json:"key"`
+	Attachment map[string]json.RawMessage `json:"attachment,omitempty"`
+}
+
+// SegmentRequest is the body used to create or update a segment
+type SegmentRequest struct {
+	Description    string `json:"description"`
+	RolloutPercent int64  `json:"rolloutPercent"`
+}
+
+// ConstraintRequest is the body used to create or update a constraint
+type ConstraintRequest struct {
+	Property string `json:"property"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// DistributionRequest is a distribution of PUT .../distributions.
+// The percentages of a segment must add up to 100.
+type DistributionRequest struct {
+	VariantID  int64  `json:"variantID"`
+	VariantKey string `json:"variantKey"`
+	Percent    int64  `json:"percent"`
+}
+
+// -----------------------------------------------------------------------------
+// Flags
+// -----------------------------------------------------------------------------
+
+// ListFlags returns every flag with its segments, variants and tags
+func (c *AdminClient) ListFlags(ctx context.Context) ([]FlagrFlag, error) {
+	var flags []FlagrFlag
+	if err := c.doRequest(ctx, http.MethodGet, c.flagsURL()+"?preload=true", nil, &flags); err != nil {
+		return nil, fmt.Errorf("failed to list flags: %w", err)
+	}
+	return flags, nil
+}
+
+// FindFlag returns the flag with the given key, or nil if there is none
+func (c *AdminClient) FindFlag(ctx context.Context, key string) (*FlagrFlag, error) {
+	var flags []FlagrFlag
+	if err := c.doRequest(ctx, http.MethodGet, c.flagsURL()+"?preload=true&key="+url.QueryEscape(key), nil, &flags); err != nil {
+		return nil, fmt.Errorf("failed to find flag %s: %w", key, err)
+	}
+
+	for i := range flags {
+		if flags[i].Key == key {
+			return &flags[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateFlag creates a disabled flag without segments or variants
+func (c *AdminClient) CreateFlag(ctx context.Context, req CreateFlagRequest) (*FlagrFlag, error) {
+	var flag FlagrFlag
+	if err := c.doSingleRequest(ctx, http.MethodPost, c.flagsURL(), req, &flag); err != nil {
+		return nil, fmt.Errorf("failed to create flag %s: %w", req.Key, err)
+	}
+	return &flag, nil
+}
+
+// UpdateFlag updates a flag's key, description and data records setting
+func (c *AdminClient) UpdateFlag(ctx context.Context, flagID int64, req UpdateFlagRequest) (*FlagrFlag, error) {
+	var flag FlagrFlag
+	if err := c.doRequest(ctx, http.MethodPut, c.flagURL(flagID), req, &flag); err != nil {
+		return nil, fmt.Errorf("failed to update flag %d: %w", flagID, err)
+	}
+	return &flag, nil
+}
+
+// DeleteFlag deletes a flag
+func (c *AdminClient) DeleteFlag(ctx context.Context, flagID int64) error {
+	if err := c.doRequest(ctx, http.MethodDelete, c.flagURL(flagID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete flag %d: %w", flagID, err)
+	}
+	return nil
+}
+
+// SetFlagEnabled enables or disables a flag
+func (c *AdminClient) SetFlagEnabled(ctx context.Context, flagID int64, enabled bool) (*FlagrFlag, error) {
+	var flag FlagrFlag
+	body := map[string]bool{"enabled": enabled}
+	if err := c.doRequest(ctx, http.MethodPut, c.flagURL(flagID)+"/enabled", body, &flag); err != nil {
+		return nil, fmt.Errorf("failed to set flag %d enabled=%t: %w", flagID, enabled, err)
+	}
+	return &flag, nil
+}
+
+// EnableFlag enables a flag
+func (c *AdminClient) EnableFlag(ctx context.Context, flagID int64) error {
+	_, err := c.SetFlagEnabled(ctx, flagID, true)
+	return err
+}
+
+// DisableFlag disables a flag
+func (c *AdminClient) DisableFlag(ctx context.Context, flagID int64) error {
+	_, err := c.SetFlagEnabled(ctx, flagID, false)
+	return err
+}
+
+// -----------------------------------------------------------------------------
+// Tags
+// -----------------------------------------------------------------------------
+
+// AddTag tags a flag
+func (c *AdminClient) AddTag(ctx context.Context, flagID int64, value string) (*Tag, error) {
+	var tag Tag
+	if err := c.doSingleRequest(ctx, http.MethodPost, c.flagURL(flagID)+"/tags", Tag{Value: value}, &tag); err != nil {
+		return nil, fmt.Errorf("failed to tag flag %d with %s: %w", flagID, value, err)
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag from a flag
+func (c *AdminClient) DeleteTag(ctx context.Context, flagID, tagID int64) error {
+	if err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("%s/tags/%d", c.flagURL(flagID), tagID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete tag %d of flag %d: %w", tagID, flagID, err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Variants
+// -----------------------------------------------------------------------------
+
+// CreateVariant adds a variant to a flag
+func (c *AdminClient) CreateVariant(ctx context.Context, flagID int64, req VariantRequest) (*FlagrVariant, error) {
+	var variant FlagrVariant
+	if err := c.doSingleRequest(ctx, http.MethodPost, c.flagURL(flagID)+"/variants", req, &variant); err != nil {
+		return nil, fmt.Errorf("failed to create variant %s of flag %d: %w", req.Key, flagID, err)
+	}
+	return &variant, nil
+}
+
+// UpdateVariant updates a variant's key and attachment
+func (c *AdminClient) UpdateVariant(ctx context.Context, flagID, variantID int64, req VariantRequest) (*FlagrVariant, error) {
+	var variant FlagrVariant
+	if err := c.doRequest(ctx, http.MethodPut, fmt.Sprintf("%s/variants/%d", c.flagURL(flagID), variantID), req, &variant); err != nil {
+		return nil, fmt.Errorf("failed to update variant %d of flag %d: %w", variantID, flagID, err)
+	}
+	return &variant, nil
+}
+
+// DeleteVariant deletes a variant. Flagr refuses to delete variants that
+// are still used by a distribution.
+func (c *AdminClient) DeleteVariant(ctx context.Context, flagID, variantID int64) error {
+	if err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("%s/variants/%d", c.flagURL(flagID), variantID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete variant %d of flag %d: %w", variantID, flagID, err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Segments
+// -----------------------------------------------------------------------------
+
+// CreateSegment appends a segment to a flag
+func (c *AdminClient) CreateSegment(ctx context.Context, flagID int64, req SegmentRequest) (*FlagrSegment, error) {
+	var segment FlagrSegment
+	if err := c.doSingleRequest(ctx, http.MethodPost, c.flagURL(flagID)+"/segments", req, &segment); err != nil {
+		return nil, fmt.Errorf("failed to create segment of flag %d: %w", flagID, err)
+	}
+	return &segment, nil
+}
+
+// UpdateSegment updates a segment's description and rollout percentage
+func (c *AdminClient) UpdateSegment(ctx context.Context, flagID, segmentID int64, req SegmentRequest) (*FlagrSegment, error) {
+	var segment FlagrSegment
+	if err := c.doRequest(ctx, http.MethodPut, c.segmentURL(flagID, segmentID), req, &segment); err != nil {
+		return nil, fmt.Errorf("failed to update segment %d of flag %d: %w", segmentID, flagID, err)
+	}
+	return &segment, nil
+}
+
+// DeleteSegment deletes a segment with its constraints and distributions
+func (c *AdminClient) DeleteSegment(ctx context.Context, flagID, segmentID int64) error {
+	if err := c.doRequest(ctx, http.MethodDelete, c.segmentURL(flagID, segmentID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete segment %d of flag %d: %w", segmentID, flagID, err)
+	}
+	return nil
+}
+
+// ReorderSegments sets the evaluation order of a flag's segments
+func (c *AdminClient) ReorderSegments(ctx context.Context, flagID int64, segmentIDs []int64) error {
+	body := map[string][]int64{"segmentIDs": segmentIDs}
+	if err := c.doRequest(ctx, http.MethodPut, c.flagURL(flagID)+"/segments/reorder", body, nil); err != nil {
+		return fmt.Errorf("failed to reorder segments of flag %d: %w", flagID, err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Constraints and distributions
+// -----------------------------------------------------------------------------
+
+// CreateConstraint adds a constraint to a segment
+func (c *AdminClient) CreateConstraint(ctx context.Context, flagID, segmentID int64, req ConstraintRequest) (*FlagrConstraint, error) {
+	var constraint FlagrConstraint
+	if err := c.doSingleRequest(ctx, http.MethodPost, c.segmentURL(flagID, segmentID)+"/constraints", req, &constraint); err != nil {
+		return nil, fmt.Errorf("failed to create constraint of segment %d: %w", segmentID, err)
+	}
+	return &constraint, nil
+}
+
+// UpdateConstraint updates a constraint
+func (c *AdminClient) UpdateConstraint(ctx context.Context, flagID, segmentID, constraintID int64, req ConstraintRequest) (*FlagrConstraint, error) {
+	var constraint FlagrConstraint
+	url := fmt.Sprintf("%s/constraints/%d", c.segmentURL(flagID, segmentID), constraintID)
+	if err := c.doRequest(ctx, http.MethodPut, url, req, &constraint); err != nil {
+		return nil, fmt.Errorf("failed to update constraint %d of segment %d: %w", constraintID, segmentID, err)
+	}
+	return &constraint, nil
+}
+
+// DeleteConstraint deletes a constraint
+func (c *AdminClient) DeleteConstraint(ctx context.Context, flagID, segmentID, constraintID int64) error {
+	url := fmt.Sprintf("%s/constraints/%d", c.segmentURL(flagID, segmentID), constraintID)
+	if err := c.doRequest(ctx, http.MethodDelete, url, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete constraint %d of segment %d: %w", constraintID, segmentID, err)
+	}
+	return nil
+}
+
+// SetDistributions replaces the distributions of a segment
+func (c *AdminClient) SetDistributions(ctx context.Context, flagID, segmentID int64, distributions []DistributionRequest) ([]FlagrDistribution, error) {
+	var result []FlagrDistribution
+	body := map[string][]DistributionRequest{"distributions": distributions}
+	if err := c.doRequest(ctx, http.MethodPut, c.segmentURL(flagID, segmentID)+"/distributions", body, &result); err != nil {
+		return nil, fmt.Errorf("failed to set distributions of segment %d: %w", segmentID, err)
+	}
+	return result, nil
+}
+
+func (c *AdminClient) flagsURL() string {
+	return c.endpoint + "/api/v1/flags"
+}
+
+func (c *AdminClient) flagURL(flagID int64) string {
+	return fmt.Sprintf("%s/api/v1/flags/%d", c.endpoint, flagID)
+}
+
+func (c *AdminClient) segmentURL(flagID, segmentID int64) string {
+	return fmt.Sprintf("%s/segments/%d", c.flagURL(flagID), segmentID)
+}
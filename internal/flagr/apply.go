@@ -0,0 +1,603 @@
+package flagr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// ChangeAction is the kind of a planned change
+type ChangeAction string
+
+const (
+	ChangeCreate ChangeAction = "create"
+	ChangeUpdate ChangeAction = "update"
+	ChangeDelete ChangeAction = "delete"
+)
+
+// Change is a single modification made by Apply
+type Change struct {
+	Action   ChangeAction `json:"action"`
+	FlagKey  string       `json:"flag_key"`
+	Resource string       `json:"resource"`
+	Detail   string       `json:"detail,omitempty"`
+}
+
+// String renders the change as "+ flag-key resource: detail"
+func (c Change) String() string {
+	symbol := "~"
+	switch c.Action {
+	case ChangeCreate:
+		symbol = "+"
+	case ChangeDelete:
+		symbol = "-"
+	}
+
+	s := fmt.Sprintf("%s %s %s", symbol, c.FlagKey, c.Resource)
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// Plan lists the changes needed to bring Flagr to the desired flags, in
+// the order Apply makes them
+type Plan struct {
+	Changes []Change `json:"changes"`
+
+	// Applied is the number of changes made by Apply
+	Applied int `json:"applied"`
+
+	steps []func(ctx context.Context, c *AdminClient) error
+}
+
+// Empty reports whether Flagr already matches the desired flags
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Counts returns the number of planned creations, updates and deletions
+func (p *Plan) Counts() (create, update, del int) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ChangeCreate:
+			create++
+		case ChangeUpdate:
+			update++
+		case ChangeDelete:
+			del++
+		}
+	}
+	return create, update, del
+}
+
+// String renders one change per line followed by a summary
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes. Flagr matches the definitions.\n"
+	}
+
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+
+	create, update, del := p.Counts()
+	fmt.Fprintf(&b, "\nPlan: %d to create, %d to update, %d to delete.\n", create, update, del)
+	return b.String()
+}
+
+// ApplyOptions controls Plan and Apply
+type ApplyOptions struct {
+	// Prune deletes flags that exist in Flagr but are not defined
+	Prune bool
+}
+
+// Plan compares the definitions with the flags in Flagr and returns the
+// changes Apply would make. Flags are matched by key, variants by key and
+// segments by position. Nothing is modified.
+func (c *AdminClient) Plan(ctx context.Context, defs []FlagDefinition, opts ApplyOptions) (*Plan, error) {
+	desired, err := DefinitionsToDomain(defs)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := c.ListFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff(desired, actual, opts), nil
+}
+
+// Apply makes the changes needed for Flagr to match the definitions, like
+// Plan followed by ApplyPlan.
+func (c *AdminClient) Apply(ctx context.Context, defs []FlagDefinition, opts ApplyOptions) (*Plan, error) {
+	plan, err := c.Plan(ctx, defs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, c.ApplyPlan(ctx, plan)
+}
+
+// ApplyPlan makes the changes of a plan returned by Plan. It stops at the
+// first failing change; the plan's Applied field tells how many changes
+// were made. Plans are applied once.
+func (c *AdminClient) ApplyPlan(ctx context.Context, plan *Plan) error {
+	if len(plan.steps) != len(plan.Changes) {
+		return fmt.Errorf("plan cannot be applied: it was not made against a Flagr server")
+	}
+
+	for plan.Applied < len(plan.steps) {
+		i := plan.Applied
+		if err := plan.steps[i](ctx, c); err != nil {
+			return fmt.Errorf("failed to apply %q: %w", plan.Changes[i].String(), err)
+		}
+		plan.Applied++
+	}
+
+	return nil
+}
+
+// PlanOffline compares the definitions with a list of Flagr flags, such as
+// an export of GET /api/v1/flags?preload=true, without contacting Flagr.
+// The plan cannot be applied.
+func PlanOffline(defs []FlagDefinition, actual []FlagrFlag, opts ApplyOptions) (*Plan, error) {
+	desired, err := DefinitionsToDomain(defs)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make([]FlagrFlag, len(actual))
+	copy(flags, actual)
+
+	plan := diff(desired, flags, opts)
+	plan.steps = nil
+	return plan, nil
+}
+
+// flagState carries Flagr IDs between the steps of a flag, since IDs of
+// created resources are only known once the previous steps ran
+type flagState struct {
+	id       int64
+	variants map[string]int64 // variant key -> Flagr variant ID
+}
+
+func (p *Plan) add(change Change, step func(ctx context.Context, c *AdminClient) error) {
+	p.Changes = append(p.Changes, change)
+	p.steps = append(p.steps, step)
+}
+
+// diff builds the plan turning actual into desired
+func diff(desired []domain.Flag, actual []FlagrFlag, opts ApplyOptions) *Plan {
+	plan := &Plan{}
+
+	byKey := make(map[string]*FlagrFlag, len(actual))
+	for i := range actual {
+		byKey[actual[i].Key] = &actual[i]
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, d := range desired {
+		wanted[d.Key] = true
+		if a, ok := byKey[d.Key]; ok {
+			plan.updateFlag(d, a)
+		} else {
+			plan.createFlag(d)
+		}
+	}
+
+	if opts.Prune {
+		sort.Slice(actual, func(i, j int) bool { return actual[i].Key < actual[j].Key })
+		for _, a := range actual {
+			if wanted[a.Key] {
+				continue
+			}
+			flagID := a.ID
+			plan.add(Change{Action: ChangeDelete, FlagKey: a.Key, Resource: "flag"}, func(ctx context.Context, c *AdminClient) error {
+				return c.DeleteFlag(ctx, flagID)
+			})
+		}
+	}
+
+	return plan
+}
+
+func (p *Plan) createFlag(d domain.Flag) {
+	st := &flagState{variants: make(map[string]int64)}
+
+	p.add(Change{Action: ChangeCreate, FlagKey: d.Key, Resource: "flag", Detail: d.Description}, func(ctx context.Context, c *AdminClient) error {
+		flag, err := c.CreateFlag(ctx, CreateFlagRequest{Key: d.Key, Description: d.Description})
+		if err != nil {
+			return err
+		}
+		st.id = flag.ID
+		return nil
+	})
+
+	for _, v := range d.Variants {
+		p.createVariant(st, d.Key, v)
+	}
+
+	for i, segment := range d.SortedSegments() {
+		p.createSegment(st, d, i+1, segment)
+	}
+
+	for _, tag := range d.Tags {
+		p.addTag(st, d.Key, tag.Value)
+	}
+
+	if d.Enabled {
+		p.setEnabled(st, d.Key, true)
+	}
+}
+
+func (p *Plan) updateFlag(d domain.Flag, a *FlagrFlag) {
+	st := &flagState{id: a.ID, variants: make(map[string]int64)}
+	for _, v := range a.Variants {
+		st.variants[v.Key] = v.ID
+	}
+
+	if d.Description != a.Description {
+		p.add(Change{
+			Action:   ChangeUpdate,
+			FlagKey:  d.Key,
+			Resource: "flag",
+			Detail:   fmt.Sprintf("description %q -> %q", a.Description, d.Description),
+		}, func(ctx context.Context, c *AdminClient) error {
+			_, err := c.UpdateFlag(ctx, st.id, UpdateFlagRequest{Key: d.Key, Description: d.Description})
+			return err
+		})
+	}
+
+	// Variants are created and updated first so segments can use them
+	actualVariants := make(map[string]FlagrVariant, len(a.Variants))
+	for _, v := range a.Variants {
+		actualVariants[v.Key] = v
+	}
+	for _, v := range d.Variants {
+		existing, ok := actualVariants[v.Key]
+		switch {
+		case !ok:
+			p.createVariant(st, d.Key, v)
+		case !sameAttachment(existing.Attachment, v.Attachment):
+			variant := v
+			p.add(Change{
+				Action:   ChangeUpdate,
+				FlagKey:  d.Key,
+				Resource: "variant " + v.Key,
+				Detail:   "attachment " + describeAttachment(v.Attachment),
+			}, func(ctx context.Context, c *AdminClient) error {
+				_, err := c.UpdateVariant(ctx, st.id, st.variants[variant.Key], VariantRequest{Key: variant.Key, Attachment: variant.Attachment})
+				return err
+			})
+		}
+	}
+
+	// Segments are matched by position
+	desiredSegments := d.SortedSegments()
+	actualSegments := make([]FlagrSegment, len(a.Segments))
+	copy(actualSegments, a.Segments)
+	sort.SliceStable(actualSegments, func(i, j int) bool { return actualSegments[i].Rank < actualSegments[j].Rank })
+
+	for i, segment := range desiredSegments {
+		if i < len(actualSegments) {
+			p.updateSegment(st, d, a, i+1, segment, actualSegments[i])
+		} else {
+			p.createSegment(st, d, i+1, segment)
+		}
+	}
+	for i := len(desiredSegments); i < len(actualSegments); i++ {
+		segmentID := actualSegments[i].ID
+		p.add(Change{
+			Action:   ChangeDelete,
+			FlagKey:  d.Key,
+			Resource: fmt.Sprintf("segment %d", i+1),
+			Detail:   actualSegments[i].Description,
+		}, func(ctx context.Context, c *AdminClient) error {
+			return c.DeleteSegment(ctx, st.id, segmentID)
+		})
+	}
+
+	// Variants are deleted last, once no distribution uses them
+	desiredVariants := make(map[string]bool, len(d.Variants))
+	for _, v := range d.Variants {
+		desiredVariants[v.Key] = true
+	}
+	for _, v := range a.Variants {
+		if desiredVariants[v.Key] {
+			continue
+		}
+		variantID := v.ID
+		p.add(Change{Action: ChangeDelete, FlagKey: d.Key, Resource: "variant " + v.Key}, func(ctx context.Context, c *AdminClient) error {
+			return c.DeleteVariant(ctx, st.id, variantID)
+		})
+	}
+
+	// Tags
+	desiredTags := make(map[string]bool, len(d.Tags))
+	for _, t := range d.Tags {
+		desiredTags[t.Value] = true
+	}
+	actualTags := make(map[string]bool, len(a.Tags))
+	for _, t := range a.Tags {
+		actualTags[t.Value] = true
+		if desiredTags[t.Value] {
+			continue
+		}
+		tagID := t.ID
+		p.add(Change{Action: ChangeDelete, FlagKey: d.Key, Resource: "tag", Detail: t.Value}, func(ctx context.Context, c *AdminClient) error {
+			return c.DeleteTag(ctx, st.id, tagID)
+		})
+	}
+	for _, t := range d.Tags {
+		if !actualTags[t.Value] {
+			p.addTag(st, d.Key, t.Value)
+		}
+	}
+
+	if d.Enabled != a.Enabled {
+		p.setEnabled(st, d.Key, d.Enabled)
+	}
+}
+
+func (p *Plan) createVariant(st *flagState, flagKey string, v domain.Variant) {
+	p.add(Change{
+		Action:   ChangeCreate,
+		FlagKey:  flagKey,
+		Resource: "variant " + v.Key,
+		Detail:   describeAttachment(v.Attachment),
+	}, func(ctx context.Context, c *AdminClient) error {
+		variant, err := c.CreateVariant(ctx, st.id, VariantRequest{Key: v.Key, Attachment: v.Attachment})
+		if err != nil {
+			return err
+		}
+		st.variants[v.Key] = variant.ID
+		return nil
+	})
+}
+
+func (p *Plan) createSegment(st *flagState, d domain.Flag, position int, segment domain.Segment) {
+	var segmentID int64
+	resource := fmt.Sprintf("segment %d", position)
+
+	p.add(Change{
+		Action:   ChangeCreate,
+		FlagKey:  d.Key,
+		Resource: resource,
+		Detail:   describeSegment(segment.Description, int64(segment.RolloutPercent)),
+	}, func(ctx context.Context, c *AdminClient) error {
+		created, err := c.CreateSegment(ctx, st.id, SegmentRequest{
+			Description:    segment.Description,
+			RolloutPercent: int64(segment.RolloutPercent),
+		})
+		if err != nil {
+			return err
+		}
+		segmentID = created.ID
+		return nil
+	})
+
+	for _, constraint := range segment.Constraints {
+		req := constraintRequest(constraint)
+		p.add(Change{
+			Action:   ChangeCreate,
+			FlagKey:  d.Key,
+			Resource: resource + " constraint",
+			Detail:   describeConstraint(req),
+		}, func(ctx context.Context, c *AdminClient) error {
+			_, err := c.CreateConstraint(ctx, st.id, segmentID, req)
+			return err
+		})
+	}
+
+	p.setDistributions(st, d.Key, resource, distributionPercents(d.Variants, segment.Distributions), &segmentID)
+}
+
+func (p *Plan) updateSegment(st *flagState, d domain.Flag, a *FlagrFlag, position int, segment domain.Segment, actual FlagrSegment) {
+	resource := fmt.Sprintf("segment %d", position)
+	segmentID := actual.ID
+
+	if segment.Description != actual.Description || int64(segment.RolloutPercent) != actual.RolloutPercent {
+		p.add(Change{
+			Action:   ChangeUpdate,
+			FlagKey:  d.Key,
+			Resource: resource,
+			Detail: fmt.Sprintf("%s -> %s",
+				describeSegment(actual.Description, actual.RolloutPercent),
+				describeSegment(segment.Description, int64(segment.RolloutPercent))),
+		}, func(ctx context.Context, c *AdminClient) error {
+			_, err := c.UpdateSegment(ctx, st.id, segmentID, SegmentRequest{
+				Description:    segment.Description,
+				RolloutPercent: int64(segment.RolloutPercent),
+			})
+			return err
+		})
+	}
+
+	// Constraints are compared as a multiset; changed ones are replaced
+	desired := make([]ConstraintRequest, len(segment.Constraints))
+	for i, constraint := range segment.Constraints {
+		desired[i] = constraintRequest(constraint)
+	}
+	kept := make([]bool, len(desired))
+
+	for _, existing := range actual.Constraints {
+		current := ConstraintRequest{Property: existing.Property, Operator: strings.ToUpper(existing.Operator), Value: existing.Value}
+
+		found := false
+		for i, want := range desired {
+			if !kept[i] && want == current {
+				kept[i] = true
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		constraintID := existing.ID
+		p.add(Change{
+			Action:   ChangeDelete,
+			FlagKey:  d.Key,
+			Resource: resource + " constraint",
+			Detail:   describeConstraint(current),
+		}, func(ctx context.Context, c *AdminClient) error {
+			return c.DeleteConstraint(ctx, st.id, segmentID, constraintID)
+		})
+	}
+
+	for i, req := range desired {
+		if kept[i] {
+			continue
+		}
+		p.add(Change{
+			Action:   ChangeCreate,
+			FlagKey:  d.Key,
+			Resource: resource + " constraint",
+			Detail:   describeConstraint(req),
+		}, func(ctx context.Context, c *AdminClient) error {
+			_, err := c.CreateConstraint(ctx, st.id, segmentID, req)
+			return err
+		})
+	}
+
+	want := distributionPercents(d.Variants, segment.Distributions)
+
+	variantKeys := make(map[int64]string, len(a.Variants))
+	for _, v := range a.Variants {
+		variantKeys[v.ID] = v.Key
+	}
+	have := make(map[string]int64, len(actual.Distributions))
+	for _, dist := range actual.Distributions {
+		key, ok := variantKeys[dist.VariantID]
+		if !ok {
+			key = fmt.Sprintf("#%d", dist.VariantID)
+		}
+		have[key] += dist.Percent
+	}
+
+	if !reflect.DeepEqual(want, have) {
+		p.setDistributions(st, d.Key, resource, want, &segmentID)
+	}
+}
+
+// setDistributions plans replacing a segment's distributions. segmentID
+// is read when the step runs, after the segment has been created.
+func (p *Plan) setDistributions(st *flagState, flagKey, resource string, percents map[string]int64, segmentID *int64) {
+	keys := make([]string, 0, len(percents))
+	for key := range percents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%d%%", key, percents[key])
+	}
+
+	p.add(Change{
+		Action:   ChangeUpdate,
+		FlagKey:  flagKey,
+		Resource: resource + " distribution",
+		Detail:   strings.Join(parts, ", "),
+	}, func(ctx context.Context, c *AdminClient) error {
+		distributions := make([]DistributionRequest, len(keys))
+		for i, key := range keys {
+			distributions[i] = DistributionRequest{
+				VariantID:  st.variants[key],
+				VariantKey: key,
+				Percent:    percents[key],
+			}
+		}
+		_, err := c.SetDistributions(ctx, st.id, *segmentID, distributions)
+		return err
+	})
+}
+
+func (p *Plan) addTag(st *flagState, flagKey, value string) {
+	p.add(Change{Action: ChangeCreate, FlagKey: flagKey, Resource: "tag", Detail: value}, func(ctx context.Context, c *AdminClient) error {
+		_, err := c.AddTag(ctx, st.id, value)
+		return err
+	})
+}
+
+func (p *Plan) setEnabled(st *flagState, flagKey string, enabled bool) {
+	p.add(Change{Action: ChangeUpdate, FlagKey: flagKey, Resource: "flag", Detail: fmt.Sprintf("enabled -> %t", enabled)}, func(ctx context.Context, c *AdminClient) error {
+		_, err := c.SetFlagEnabled(ctx, st.id, enabled)
+		return err
+	})
+}
+
+// distributionPercents maps variant keys to percentages
+func distributionPercents(variants []domain.Variant, distributions []domain.Distribution) map[string]int64 {
+	keys := make(map[int64]string, len(variants))
+	for _, v := range variants {
+		keys[v.ID] = v.Key
+	}
+
+	percents := make(map[string]int64, len(distributions))
+	for _, d := range distributions {
+		percents[keys[d.VariantID]] += int64(d.Percent)
+	}
+	return percents
+}
+
+func constraintRequest(c domain.Constraint) ConstraintRequest {
+	return ConstraintRequest{
+		Property: c.Property,
+		Operator: strings.ToUpper(string(c.Operator)),
+		Value:    constraintValue(c.Value),
+	}
+}
+
+// sameAttachment compares attachments by their decoded JSON values
+func sameAttachment(a, b map[string]json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, rawA := range a {
+		rawB, ok := b[key]
+		if !ok {
+			return false
+		}
+		var valA, valB interface{}
+		if json.Unmarshal(rawA, &valA) != nil || json.Unmarshal(rawB, &valB) != nil {
+			return false
+		}
+		if !reflect.DeepEqual(valA, valB) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeAttachment(attachment map[string]json.RawMessage) string {
+	if len(attachment) == 0 {
+		return ""
+	}
+	raw, err := json.Marshal(attachment)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+func describeSegment(description string, rollout int64) string {
+	if description == "" {
+		return fmt.Sprintf("rollout %d%%", rollout)
+	}
+	return fmt.Sprintf("%q rollout %d%%", description, rollout)
+}
+
+func describeConstraint(c ConstraintRequest) string {
+	return fmt.Sprintf("%s %s %s", c.Property, c.Operator, c.Value)
+}
@@ -0,0 +1,250 @@
+package flagr_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdmin(srv *flagrtest.Server) *flagr.AdminClient {
+	return flagr.NewAdminClient(flagr.Config{Endpoint: srv.URL, Timeout: time.Second})
+}
+
+func definitions() []flagr.FlagDefinition {
+	disabled := false
+	half := 50
+
+	return []flagr.FlagDefinition{
+		{
+			Key:         "new-checkout",
+			Description: "new checkout flow",
+			Tags:        []string{"checkout"},
+			Variants: map[string]map[string]interface{}{
+				"on":  {"enabled": true},
+				"off": {"enabled": false},
+			},
+			Segments: []flagr.SegmentDefinition{
+				{
+					Description: "brazil",
+					Constraints: []flagr.ConstraintDefinition{{Property: "country", Operator: "eq", Value: "BR"}},
+					Variant:     "on",
+				},
+				{
+					Description:  "everyone else",
+					Rollout:      &half,
+					Distribution: map[string]int{"on": 20, "off": 80},
+				},
+			},
+		},
+		{
+			Key:      "maintenance",
+			Enabled:  &disabled,
+			Variants: map[string]map[string]interface{}{"on": nil},
+			Default:  "on",
+		},
+	}
+}
+
+func TestAdminClient_CRUD(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+	admin := newAdmin(srv)
+	ctx := context.Background()
+
+	flag, err := admin.CreateFlag(ctx, flagr.CreateFlagRequest{Key: "dark-mode", Description: "dark theme"})
+	require.NoError(t, err)
+	assert.False(t, flag.Enabled, "flags are created disabled")
+
+	_, err = admin.CreateFlag(ctx, flagr.CreateFlagRequest{Key: "dark-mode"})
+	assert.Error(t, err, "keys are unique")
+
+	variant, err := admin.CreateVariant(ctx, flag.ID, flagr.VariantRequest{
+		Key:        "on",
+		Attachment: map[string]json.RawMessage{"enabled": json.RawMessage("true")},
+	})
+	require.NoError(t, err)
+
+	segment, err := admin.CreateSegment(ctx, flag.ID, flagr.SegmentRequest{Description: "beta", RolloutPercent: 100})
+	require.NoError(t, err)
+
+	_, err = admin.CreateConstraint(ctx, flag.ID, segment.ID, flagr.ConstraintRequest{Property: "plan", Operator: "EQ", Value: "beta"})
+	require.NoError(t, err)
+
+	_, err = admin.SetDistributions(ctx, flag.ID, segment.ID, []flagr.DistributionRequest{{VariantID: variant.ID, Percent: 50}})
+	assert.Error(t, err, "percentages must add up to 100")
+
+	distributions, err := admin.SetDistributions(ctx, flag.ID, segment.ID, []flagr.DistributionRequest{{VariantID: variant.ID, Percent: 100}})
+	require.NoError(t, err)
+	require.Len(t, distributions, 1)
+
+	_, err = admin.AddTag(ctx, flag.ID, "ui")
+	require.NoError(t, err)
+	require.NoError(t, admin.EnableFlag(ctx, flag.ID))
+
+	found, err := admin.FindFlag(ctx, "dark-mode")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.True(t, found.Enabled)
+	require.Len(t, found.Segments, 1)
+	assert.Len(t, found.Segments[0].Constraints, 1)
+	require.Len(t, found.Tags, 1)
+
+	result, err := admin.EvaluateFlag(ctx, "dark-mode", domain.NewEvaluationContext("u1").WithAttribute("plan", "beta"))
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey)
+
+	assert.Error(t, admin.DeleteVariant(ctx, flag.ID, variant.ID), "variant is used by a distribution")
+
+	require.NoError(t, admin.DeleteTag(ctx, flag.ID, found.Tags[0].ID))
+	require.NoError(t, admin.DeleteSegment(ctx, flag.ID, segment.ID))
+	require.NoError(t, admin.DeleteVariant(ctx, flag.ID, variant.ID))
+	require.NoError(t, admin.DeleteFlag(ctx, flag.ID))
+
+	found, err = admin.FindFlag(ctx, "dark-mode")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestAdminClient_Apply(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+	admin := newAdmin(srv)
+	ctx := context.Background()
+
+	plan, err := admin.Plan(ctx, definitions(), flagr.ApplyOptions{})
+	require.NoError(t, err)
+	create, _, _ := plan.Counts()
+	assert.Positive(t, create)
+	assert.Zero(t, srv.TotalRequests()-srv.Requests("/api/v1/flags"), "Plan only reads")
+
+	plan, err = admin.Apply(ctx, definitions(), flagr.ApplyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, len(plan.Changes), plan.Applied)
+
+	flags := srv.Flags()
+	require.Len(t, flags, 2)
+	maintenance, checkout := flags[0], flags[1]
+	assert.Equal(t, "new-checkout", checkout.Key)
+	assert.True(t, checkout.Enabled)
+	assert.Len(t, checkout.Segments, 2)
+	assert.False(t, maintenance.Enabled)
+
+	result, err := admin.EvaluateFlag(ctx, "new-checkout", domain.NewEvaluationContext("u1").WithAttribute("country", "BR"))
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey)
+
+	// A second apply has nothing to do
+	plan, err = admin.Plan(ctx, definitions(), flagr.ApplyOptions{})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+	assert.Contains(t, plan.String(), "No changes")
+}
+
+func TestAdminClient_ApplyChanges(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+	admin := newAdmin(srv)
+	ctx := context.Background()
+
+	_, err := admin.Apply(ctx, definitions(), flagr.ApplyOptions{})
+	require.NoError(t, err)
+	_, err = admin.CreateFlag(ctx, flagr.CreateFlagRequest{Key: "orphan"})
+	require.NoError(t, err)
+
+	defs := definitions()
+	checkout := &defs[0]
+	checkout.Description = "new checkout flow v2"
+	checkout.Tags = []string{"payments"}
+	checkout.Variants["on"] = map[string]interface{}{"enabled": true, "theme": "dark"}
+	delete(checkout.Variants, "off")
+	checkout.Segments = checkout.Segments[:1]
+	checkout.Segments[0].Constraints[0].Value = "PT"
+
+	plan, err := admin.Plan(ctx, defs, flagr.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+
+	changes := make([]string, len(plan.Changes))
+	for i, c := range plan.Changes {
+		changes[i] = c.String()
+	}
+	assert.Equal(t, []string{
+		`~ new-checkout flag: description "new checkout flow" -> "new checkout flow v2"`,
+		`~ new-checkout variant on: attachment {"enabled":true,"theme":"dark"}`,
+		`- new-checkout segment 1 constraint: country EQ BR`,
+		`+ new-checkout segment 1 constraint: country EQ PT`,
+		`- new-checkout segment 2: everyone else`,
+		`- new-checkout variant off`,
+		`- new-checkout tag: checkout`,
+		`+ new-checkout tag: payments`,
+		`- orphan flag`,
+	}, changes)
+
+	create, update, del := plan.Counts()
+	assert.Equal(t, []int{2, 2, 5}, []int{create, update, del})
+	assert.Contains(t, plan.String(), "Plan: 2 to create, 2 to update, 5 to delete.")
+
+	_, err = admin.Apply(ctx, defs, flagr.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+
+	plan, err = admin.Plan(ctx, defs, flagr.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+
+	result, err := admin.EvaluateFlag(ctx, "new-checkout", domain.NewEvaluationContext("u1").WithAttribute("country", "PT"))
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey)
+}
+
+func TestAdminClient_ApplyStopsAtFailure(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+	admin := newAdmin(srv)
+	ctx := context.Background()
+
+	_, err := admin.Apply(ctx, []flagr.FlagDefinition{{Key: "broken", Segments: []flagr.SegmentDefinition{{}}}}, flagr.ApplyOptions{})
+	assert.Error(t, err, "definitions are validated before planning")
+
+	// Flagr rejects operators it does not know
+	defs := []flagr.FlagDefinition{{
+		Key: "unknown-operator",
+		Segments: []flagr.SegmentDefinition{{
+			Constraints: []flagr.ConstraintDefinition{{Property: "email", Operator: "LIKE", Value: "%@example.com"}},
+			Variant:     "on",
+		}},
+	}}
+	plan, err := admin.Apply(ctx, defs, flagr.ApplyOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "segment 1 constraint")
+	assert.Positive(t, plan.Applied)
+	assert.Less(t, plan.Applied, len(plan.Changes))
+	assert.Len(t, srv.Flags(), 1, "changes made before the failure are kept")
+}
+
+func TestPlanOffline(t *testing.T) {
+	srv := flagrtest.NewServer()
+	defer srv.Close()
+	admin := newAdmin(srv)
+	ctx := context.Background()
+
+	_, err := admin.Apply(ctx, definitions(), flagr.ApplyOptions{})
+	require.NoError(t, err)
+	export, err := admin.ListFlags(ctx)
+	require.NoError(t, err)
+
+	plan, err := flagr.PlanOffline(definitions(), export, flagr.ApplyOptions{})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+
+	plan, err = flagr.PlanOffline(definitions(), nil, flagr.ApplyOptions{})
+	require.NoError(t, err)
+	assert.False(t, plan.Empty())
+	assert.Error(t, admin.ApplyPlan(ctx, plan), "offline plans cannot be applied")
+	assert.Len(t, srv.Flags(), 2)
+}
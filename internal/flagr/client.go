@@ -8,7 +8,8 @@ import (
 
 // Client defines the interface for Flagr communication (HTTP, mock, etc.)
 type Client interface {
-	// GetAllFlags fetches all flags with full details
+	// GetAllFlags fetches all flags with full details. A *PartialError
+	// comes with the flags fetched when some were left out.
 	GetAllFlags(ctx context.Context) ([]domain.Flag, error)
 
 	// GetFlag fetches a single flag by ID with full details
@@ -20,3 +21,63 @@ type Client interface {
 	// HealthCheck checks if Flagr is reachable
 	HealthCheck(ctx context.Context) error
 }
+
+// Explainer is implemented by clients that can return Flagr's evaluation
+// debug log alongside the result
+type Explainer interface {
+	// ExplainFlag remotely evaluates a flag with debugging enabled
+	ExplainFlag(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error)
+}
+
+// FlagQuery narrows the flags listed by Flagr
+type FlagQuery struct {
+	// Tag lists the flags with the tag
+	Tag string
+
+	// Key lists the flag with the key
+	Key string
+}
+
+// Finder is implemented by clients that filter flags in Flagr's list
+// query, so flags outside the query are never downloaded
+type Finder interface {
+	// FindFlags fetches the flags matching the query with full details
+	FindFlags(ctx context.Context, query FlagQuery) ([]domain.Flag, error)
+}
+
+// FindFlags fetches the flags matching the query, through the client's
+// Finder when it has one or else by filtering every flag locally
+func FindFlags(ctx context.Context, client Client, query FlagQuery) ([]domain.Flag, error) {
+	if finder, ok := client.(Finder); ok {
+		return finder.FindFlags(ctx, query)
+	}
+
+	flags, err := client.GetAllFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []domain.Flag{}
+	for _, flag := range flags {
+		if query.matches(flag) {
+			matched = append(matched, flag)
+		}
+	}
+	return matched, nil
+}
+
+// matches reports whether the flag satisfies the query
+func (q FlagQuery) matches(flag domain.Flag) bool {
+	if q.Key != "" && flag.Key != q.Key {
+		return false
+	}
+	if q.Tag == "" {
+		return true
+	}
+	for _, tag := range flag.Tags {
+		if tag.Value == q.Tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package flagr
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// FlagDefinition is the hand-written description of a flag, used by flag
+// files and by AdminClient.Apply. Variants are referenced by key, so a
+// definition carries no Flagr IDs.
+type FlagDefinition struct {
+	Key         string                            `json:"key,omitempty" yaml:"key,omitempty"`
+	Description string                            `json:"description,omitempty" yaml:"description,omitempty"`
+	Enabled     *bool                             `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Tags        []string                          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Variants    map[string]map[string]interface{} `json:"variants,omitempty" yaml:"variants,omitempty"`
+	Segments    []SegmentDefinition               `json:"segments,omitempty" yaml:"segments,omitempty"`
+
+	// Default is the variant served when no segment matches
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// SegmentDefinition is a segment of a FlagDefinition. It serves either a
+// single Variant or a Distribution of variant keys to percentages.
+type SegmentDefinition struct {
+	Description  string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Constraints  []ConstraintDefinition `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Variant      string                 `json:"variant,omitempty" yaml:"variant,omitempty"`
+	Distribution map[string]int         `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+
+	// Rollout is the rollout percentage (default 100)
+	Rollout *int `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+}
+
+// ConstraintDefinition is a constraint of a SegmentDefinition
+type ConstraintDefinition struct {
+	Property string      `json:"property" yaml:"property"`
+	Operator string      `json:"operator" yaml:"operator"`
+	Value    interface{} `json:"value" yaml:"value"`
+}
+
+// DefinitionsToDomain converts definitions to flags. IDs are assigned in
+// key order so they are stable across conversions of the same set.
+func DefinitionsToDomain(defs []FlagDefinition) ([]domain.Flag, error) {
+	sorted := make([]FlagDefinition, len(defs))
+	copy(sorted, defs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	flags := make([]domain.Flag, 0, len(sorted))
+	var segmentID, constraintID, distributionID int64
+
+	for i, def := range sorted {
+		key := def.Key
+		if key == "" {
+			return nil, fmt.Errorf("flag %d: key is required", i+1)
+		}
+		if i > 0 && sorted[i-1].Key == key {
+			return nil, fmt.Errorf("flag %s: defined more than once", key)
+		}
+
+		flag := domain.Flag{
+			ID:          int64(i + 1),
+			Key:         key,
+			Description: def.Description,
+			Enabled:     def.Enabled == nil || *def.Enabled,
+		}
+		for _, tag := range def.Tags {
+			flag.Tags = append(flag.Tags, domain.Tag{Value: tag})
+		}
+
+		// Declared variants first, in key order
+		variantIDs := make(map[string]int64)
+		variantKeys := make([]string, 0, len(def.Variants))
+		for vk := range def.Variants {
+			variantKeys = append(variantKeys, vk)
+		}
+		sort.Strings(variantKeys)
+
+		variantID := func(vk string) int64 {
+			if id, ok := variantIDs[vk]; ok {
+				return id
+			}
+			id := int64(len(flag.Variants) + 1)
+			variantIDs[vk] = id
+			flag.Variants = append(flag.Variants, domain.Variant{ID: id, Key: vk})
+			return id
+		}
+
+		for _, vk := range variantKeys {
+			id := variantID(vk)
+			attachment, err := toAttachment(def.Variants[vk])
+			if err != nil {
+				return nil, fmt.Errorf("flag %s variant %s: %w", key, vk, err)
+			}
+			flag.Variants[id-1].Attachment = attachment
+		}
+
+		segments := def.Segments
+		if def.Default != "" {
+			segments = append(segments, SegmentDefinition{Description: "default", Variant: def.Default})
+		}
+
+		for rank, seg := range segments {
+			segmentID++
+			segment := domain.Segment{
+				ID:             segmentID,
+				Rank:           rank + 1,
+				Description:    seg.Description,
+				RolloutPercent: 100,
+			}
+			if seg.Rollout != nil {
+				segment.RolloutPercent = *seg.Rollout
+			}
+
+			for _, c := range seg.Constraints {
+				if c.Property == "" || c.Operator == "" {
+					return nil, fmt.Errorf("flag %s segment %d: constraint property and operator are required", key, rank+1)
+				}
+				constraintID++
+				segment.Constraints = append(segment.Constraints, domain.Constraint{
+					ID:       constraintID,
+					Property: c.Property,
+					Operator: domain.Operator(strings.ToUpper(c.Operator)),
+					Value:    c.Value,
+				})
+			}
+
+			switch {
+			case seg.Variant != "" && len(seg.Distribution) > 0:
+				return nil, fmt.Errorf("flag %s segment %d: use either variant or distribution", key, rank+1)
+
+			case seg.Variant != "":
+				distributionID++
+				segment.Distributions = []domain.Distribution{
+					{ID: distributionID, VariantID: variantID(seg.Variant), Percent: 100},
+				}
+
+			case len(seg.Distribution) > 0:
+				distKeys := make([]string, 0, len(seg.Distribution))
+				for vk := range seg.Distribution {
+					distKeys = append(distKeys, vk)
+				}
+				sort.Strings(distKeys)
+
+				for _, vk := range distKeys {
+					distributionID++
+					segment.Distributions = append(segment.Distributions, domain.Distribution{
+						ID:        distributionID,
+						VariantID: variantID(vk),
+						Percent:   seg.Distribution[vk],
+					})
+				}
+
+			default:
+				return nil, fmt.Errorf("flag %s segment %d: variant or distribution is required", key, rank+1)
+			}
+
+			flag.Segments = append(flag.Segments, segment)
+		}
+
+		if err := flag.Validate(); err != nil {
+			return nil, fmt.Errorf("flag %s: %w", key, err)
+		}
+
+		flags = append(flags, flag)
+	}
+
+	return flags, nil
+}
+
+// LoadDefinitions reads the definitions of a flag file in the simple
+// layout, or of every .yaml, .yml and .json file under a directory. A key
+// may only be defined once across files.
+func LoadDefinitions(path string) ([]FlagDefinition, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read definitions: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".yaml", ".yml", ".json":
+				if !d.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read definitions: %w", err)
+		}
+	}
+
+	var defs []FlagDefinition
+	definedIn := make(map[string]string)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read definitions: %w", err)
+		}
+
+		fileDefs, err := ParseDefinitions(file, data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, def := range fileDefs {
+			if other, ok := definedIn[def.Key]; ok {
+				return nil, fmt.Errorf("flag %s: defined in both %s and %s", def.Key, other, file)
+			}
+			definedIn[def.Key] = file
+			defs = append(defs, def)
+		}
+	}
+
+	return defs, nil
+}
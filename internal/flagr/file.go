@@ -0,0 +1,212 @@
+package flagr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+)
+
+// FileSource is a Client that serves flags from a local JSON or YAML file
+// instead of a Flagr server. Flags are evaluated locally, including partial
+// rollouts and A/B distributions (see evaluator.EvaluateRollout).
+//
+// The file may use Flagr's format (a list of flags as returned by
+// GET /api/v1/flags?preload=true, optionally wrapped in {"flags": [...]})
+// or the simpler hand-written format (see FlagDefinition):
+//
+//	flags:
+//	  new-checkout:
+//	    enabled: true
+//	    variants:
+//	      enabled: {enabled: true}
+//	      disabled: {enabled: false}
+//	    segments:
+//	      - constraints:
+//	          - {property: country, operator: EQ, value: BR}
+//	        variant: enabled
+//	      - constraints:
+//	          - {property: country, operator: EQ, value: US}
+//	        rollout: 50
+//	        distribution: {enabled: 50, disabled: 50}
+//	    default: disabled
+//
+// FileSource is safe for concurrent use.
+type FileSource struct {
+	*StaticSource
+	path string
+}
+
+// NewFileSource creates a source for path. The file is read by Load.
+func NewFileSource(path string) *FileSource {
+	return &FileSource{
+		StaticSource: &StaticSource{evaluator: evaluator.New()},
+		path:         path,
+	}
+}
+
+// Path returns the flag file path
+func (s *FileSource) Path() string {
+	return s.path
+}
+
+// Load reads the file and replaces the served flags.
+// On error the previously loaded flags are kept.
+func (s *FileSource) Load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read flag file: %w", err)
+	}
+
+	flags, err := ParseFlagFile(s.path, data)
+	if err != nil {
+		return err
+	}
+
+	s.SetFlags(flags)
+	return nil
+}
+
+// ParseFlagFile decodes flags from JSON or YAML. The syntax is picked from
+// the file extension (".json" is JSON, anything else is YAML) and the
+// layout (Flagr or simple) from the content.
+func ParseFlagFile(path string, data []byte) ([]domain.Flag, error) {
+	list, normalized, err := decodeFlagFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch list.(type) {
+	case []interface{}:
+		var flags []FlagrFlag
+		if err := json.Unmarshal(normalized, &flags); err != nil {
+			return nil, fmt.Errorf("failed to parse flag file %s: %w", path, err)
+		}
+		return FlagsToDomain(flags), nil
+
+	case map[string]interface{}:
+		defs, err := definitionsByKey(path, normalized)
+		if err != nil {
+			return nil, err
+		}
+		return DefinitionsToDomain(defs)
+
+	default:
+		return nil, fmt.Errorf("failed to parse flag file %s: expected a list of flags or a \"flags\" section", path)
+	}
+}
+
+// ParseDefinitions decodes the definitions of a flag file in the simple
+// layout
+func ParseDefinitions(path string, data []byte) ([]FlagDefinition, error) {
+	list, normalized, err := decodeFlagFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := list.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("failed to parse flag file %s: expected a \"flags\" section mapping keys to definitions", path)
+	}
+	return definitionsByKey(path, normalized)
+}
+
+// ParseFlagrExport decodes flags in Flagr's layout, such as the output of
+// GET /api/v1/flags?preload=true
+func ParseFlagrExport(path string, data []byte) ([]FlagrFlag, error) {
+	list, normalized, err := decodeFlagFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := list.([]interface{}); !ok {
+		return nil, fmt.Errorf("failed to parse flag file %s: expected a list of Flagr flags", path)
+	}
+
+	var flags []FlagrFlag
+	if err := json.Unmarshal(normalized, &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse flag file %s: %w", path, err)
+	}
+	return flags, nil
+}
+
+// decodeFlagFile returns the flags of a file (a list in Flagr's layout, a
+// map in the simple one) and their JSON encoding
+func decodeFlagFile(path string, data []byte) (interface{}, []byte, error) {
+	var doc interface{}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse flag file %s: %w", path, err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse flag file %s: %w", path, err)
+		}
+	}
+
+	// Flagr's layout is a list of flags, optionally under a "flags" key;
+	// the simple layout maps flag keys to definitions
+	list := doc
+	if m, ok := doc.(map[string]interface{}); ok {
+		list = m["flags"]
+		if list == nil {
+			list = m["Flags"]
+		}
+	}
+
+	// Normalize YAML and JSON through encoding/json
+	normalized, err := json.Marshal(list)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse flag file %s: %w", path, err)
+	}
+
+	return list, normalized, nil
+}
+
+func definitionsByKey(path string, normalized []byte) ([]FlagDefinition, error) {
+	var byKey map[string]FlagDefinition
+	if err := json.Unmarshal(normalized, &byKey); err != nil {
+		return nil, fmt.Errorf("failed to parse flag file %s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	defs := make([]FlagDefinition, 0, len(byKey))
+	for _, key := range keys {
+		def := byKey[key]
+		def.Key = key
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// toAttachment marshals each attachment value to JSON
+func toAttachment(values map[string]interface{}) (map[string]json.RawMessage, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	attachment := make(map[string]json.RawMessage, len(values))
+	for k, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attachment %q: %w", k, err)
+		}
+		attachment[k] = raw
+	}
+	return attachment, nil
+}
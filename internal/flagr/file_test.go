@@ -0,0 +1,223 @@
+package flagr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const simpleFlagFile = `
+flags:
+  new-checkout:
+    description: New checkout flow
+    tags: [checkout]
+    variants:
+      enabled: {enabled: true}
+      disabled: {enabled: false}
+    segments:
+      - constraints:
+          - {property: country, operator: eq, value: BR}
+        variant: enabled
+      - rollout: 50
+        distribution: {enabled: 50, disabled: 50}
+    default: disabled
+  kill-switch:
+    enabled: false
+    segments:
+      - variant: "on"
+`
+
+func TestParseFlagFile_Simple(t *testing.T) {
+	flags, err := ParseFlagFile("flags.yaml", []byte(simpleFlagFile))
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+
+	// Flags are ordered by key
+	kill := flags[0]
+	assert.Equal(t, "kill-switch", kill.Key)
+	assert.False(t, kill.Enabled)
+	require.Len(t, kill.Variants, 1)
+	assert.Equal(t, "on", kill.Variants[0].Key)
+
+	checkout := flags[1]
+	assert.Equal(t, "new-checkout", checkout.Key)
+	assert.True(t, checkout.Enabled)
+	assert.Equal(t, []domain.Tag{{Value: "checkout"}}, checkout.Tags)
+	require.Len(t, checkout.Variants, 2)
+	assert.JSONEq(t, "false", string(checkout.Variants[0].Attachment["enabled"]))
+	require.Len(t, checkout.Segments, 3)
+
+	assert.Equal(t, domain.OperatorEQ, checkout.Segments[0].Constraints[0].Operator)
+	assert.Equal(t, 100, checkout.Segments[0].RolloutPercent)
+	assert.Equal(t, 50, checkout.Segments[1].RolloutPercent)
+	assert.Len(t, checkout.Segments[1].Distributions, 2)
+	assert.Equal(t, "default", checkout.Segments[2].Description)
+	assert.Equal(t, 3, checkout.Segments[2].Rank)
+
+	// IDs are stable across parses
+	again, err := ParseFlagFile("flags.yaml", []byte(simpleFlagFile))
+	require.NoError(t, err)
+	assert.Equal(t, flags, again)
+}
+
+func TestParseFlagFile_FlagrFormat(t *testing.T) {
+	export := `[{
+		"id": 3, "key": "beta", "enabled": true,
+		"segments": [{"id": 9, "rank": 1, "rolloutPercent": 100,
+			"constraints": [{"id": 1, "property": "tier", "operator": "EQ", "value": "gold"}],
+			"distributions": [{"id": 1, "percent": 100, "variantID": 5}]}],
+		"variants": [{"id": 5, "key": "on", "attachment": {"enabled": true}}]
+	}]`
+
+	flags, err := ParseFlagFile("flags.json", []byte(export))
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, int64(3), flags[0].ID)
+	assert.Equal(t, int64(5), flags[0].Segments[0].Distributions[0].VariantID)
+
+	wrapped, err := ParseFlagFile("flags.json", []byte(`{"flags": `+export+`}`))
+	require.NoError(t, err)
+	assert.Equal(t, flags, wrapped)
+}
+
+func TestParseFlagFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data string
+	}{
+		{"invalid json", "flags.json", "{"},
+		{"invalid yaml", "flags.yaml", "flags: ["},
+		{"no flags section", "flags.yaml", "other: 1"},
+		{"segment without variant", "flags.yaml", "flags:\n  f:\n    segments:\n      - rollout: 10\n"},
+		{"variant and distribution", "flags.yaml", "flags:\n  f:\n    segments:\n      - variant: a\n        distribution: {b: 100}\n"},
+		{"constraint without operator", "flags.yaml", "flags:\n  f:\n    segments:\n      - variant: a\n        constraints:\n          - {property: x}\n"},
+		{"invalid rollout", "flags.yaml", "flags:\n  f:\n    segments:\n      - {variant: a, rollout: 150}\n"},
+		{"distribution not adding up to 100", "flags.yaml", "flags:\n  f:\n    segments:\n      - distribution: {a: 50, b: 40}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFlagFile(tt.path, []byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "team"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "checkout.yaml"), []byte(simpleFlagFile), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team", "pricing.json"), []byte(`{"flags": {"pricing": {"segments": [{"variant": "v2"}]}}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	defs, err := LoadDefinitions(dir)
+	require.NoError(t, err)
+	keys := make([]string, len(defs))
+	for i, def := range defs {
+		keys[i] = def.Key
+	}
+	assert.Equal(t, []string{"kill-switch", "new-checkout", "pricing"}, keys)
+
+	defs, err = LoadDefinitions(filepath.Join(dir, "checkout.yaml"))
+	require.NoError(t, err)
+	assert.Len(t, defs, 2)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team", "dup.yaml"), []byte("flags:\n  pricing:\n    segments: [{variant: a}]\n"), 0o644))
+	_, err = LoadDefinitions(dir)
+	assert.ErrorContains(t, err, "defined in both")
+
+	_, err = LoadDefinitions(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	// Flagr exports are not definitions
+	_, err = ParseDefinitions("export.json", []byte(`[{"id": 1, "key": "f"}]`))
+	assert.Error(t, err)
+
+	flags, err := ParseFlagrExport("export.json", []byte(`[{"id": 1, "key": "f"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "f", flags[0].Key)
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(simpleFlagFile), 0o644))
+
+	source := NewFileSource(path)
+	ctx := context.Background()
+
+	// Not loaded yet
+	assert.Error(t, source.HealthCheck(ctx))
+	_, err := source.GetAllFlags(ctx)
+	assert.Error(t, err)
+
+	require.NoError(t, source.Load())
+	assert.NoError(t, source.HealthCheck(ctx))
+	assert.ElementsMatch(t, []string{"kill-switch", "new-checkout"}, source.Keys())
+
+	flags, err := source.GetAllFlags(ctx)
+	require.NoError(t, err)
+	assert.Len(t, flags, 2)
+
+	flag, err := source.GetFlag(ctx, flags[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-checkout", flag.Key)
+
+	_, err = source.GetFlag(ctx, 999)
+	assert.True(t, domain.IsNotFound(err))
+
+	result, err := source.EvaluateFlag(ctx, "new-checkout", domain.NewEvaluationContext("u1").WithAttribute("country", "BR"))
+	require.NoError(t, err)
+	assert.Equal(t, "enabled", result.VariantKey)
+
+	// Outside Brazil the entity matches the 50% rollout, which stops
+	// evaluation whether or not it includes the entity
+	result, err = source.EvaluateFlag(ctx, "new-checkout", domain.NewEvaluationContext("u1").WithAttribute("country", "US"))
+	require.NoError(t, err)
+	assert.Contains(t, []string{"enabled", "disabled", ""}, result.VariantKey)
+	assert.Equal(t, flag.Segments[1].ID, result.SegmentID)
+
+	_, err = source.EvaluateFlag(ctx, "missing", domain.NewEvaluationContext("u1"))
+	assert.True(t, domain.IsNotFound(err))
+
+	// A broken file keeps the previous flags
+	require.NoError(t, os.WriteFile(path, []byte("flags: ["), 0o644))
+	assert.Error(t, source.Load())
+	assert.Len(t, source.Keys(), 2)
+}
+
+func TestFileSource_PartialRolloutStopsEvaluation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+flags:
+  gradual:
+    variants:
+      "new": {}
+      "old": {}
+    segments:
+      - rollout: 20
+        variant: "new"
+    default: "old"
+`), 0o644))
+
+	source := NewFileSource(path)
+	require.NoError(t, source.Load())
+
+	// As in Flagr, the default segment is never reached: the entities the
+	// 20% rollout leaves out get no variant
+	variants := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		result, err := source.EvaluateFlag(context.Background(), "gradual", domain.NewEvaluationContext(fmt.Sprintf("user-%d", i)))
+		require.NoError(t, err)
+		variants[result.VariantKey]++
+	}
+	assert.Zero(t, variants["old"])
+	assert.InDelta(t, 200, variants["new"], 60)
+	assert.InDelta(t, 800, variants[""], 60)
+}
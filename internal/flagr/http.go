@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
@@ -32,7 +35,8 @@ func NewHTTPClient(config Config) *HTTPClient {
 	}
 }
 
-// GetAllFlags fetches all flags from Flagr
+// GetAllFlags fetches all flags from Flagr. When the details of some flags
+// can't be fetched, the other flags are returned with a *PartialError.
 func (c *HTTPClient) GetAllFlags(ctx context.Context) ([]domain.Flag, error) {
 	url := fmt.Sprintf("%s/api/v1/flags", c.endpoint)
 
@@ -43,19 +47,51 @@ func (c *HTTPClient) GetAllFlags(ctx context.Context) ([]domain.Flag, error) {
 
 	// Fetch detailed info for each flag (includes segments, constraints, etc.)
 	detailedFlags := []domain.Flag{}
+	var partial *PartialError
 	for _, flag := range flagrFlags {
 		detailedFlag, err := c.GetFlag(ctx, flag.ID)
 		if err != nil {
-			// Log but continue with partial data
-			fmt.Printf("warning: failed to fetch details for flag %d: %v\n", flag.ID, err)
+			if partial == nil {
+				partial = &PartialError{Failed: make(map[int64]error)}
+			}
+			partial.Failed[flag.ID] = err
 			continue
 		}
 		detailedFlags = append(detailedFlags, *detailedFlag)
 	}
 
+	if partial != nil {
+		return detailedFlags, partial
+	}
 	return detailedFlags, nil
 }
 
+// FindFlags fetches the flags matching the query in a single request,
+// letting Flagr filter by tag and key and preload the details
+func (c *HTTPClient) FindFlags(ctx context.Context, query FlagQuery) ([]domain.Flag, error) {
+	params := url.Values{"preload": {"true"}}
+	if query.Tag != "" {
+		params.Set("tags", query.Tag)
+	}
+	if query.Key != "" {
+		params.Set("key", query.Key)
+	}
+
+	var flagrFlags []FlagrFlag
+	if err := c.doRequest(ctx, "GET", c.endpoint+"/api/v1/flags?"+params.Encode(), nil, &flagrFlags); err != nil {
+		return nil, fmt.Errorf("failed to find flags: %w", err)
+	}
+
+	// Flagr matches keys by substring, keep only the exact key
+	flags := []domain.Flag{}
+	for _, flag := range FlagsToDomain(flagrFlags) {
+		if query.matches(flag) {
+			flags = append(flags, flag)
+		}
+	}
+	return flags, nil
+}
+
 // GetFlag fetches a single flag by ID with full details
 func (c *HTTPClient) GetFlag(ctx context.Context, flagID int64) (*domain.Flag, error) {
 	url := fmt.Sprintf("%s/api/v1/flags/%d", c.endpoint, flagID)
@@ -86,6 +122,23 @@ func (c *HTTPClient) EvaluateFlag(ctx context.Context, flagKey string, evalCtx d
 	return &result, nil
 }
 
+// ExplainFlag evaluates a flag remotely with enableDebug set and maps
+// Flagr's segment debug logs into a trace
+func (c *HTTPClient) ExplainFlag(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+	url := fmt.Sprintf("%s/api/v1/evaluation", c.endpoint)
+
+	req := EvaluationContextFromDomain(flagKey, evalCtx)
+	req.EnableDebug = true
+
+	var resp EvaluationResponse
+	if err := c.doRequest(ctx, "POST", url, req, &resp); err != nil {
+		return nil, domain.NewEvaluationError(flagKey, "remote evaluation failed", err)
+	}
+
+	trace := EvaluationTraceFromResponse(resp)
+	return &trace, nil
+}
+
 // HealthCheck verifies Flagr is reachable
 func (c *HTTPClient) HealthCheck(ctx context.Context) error {
 	url := fmt.Sprintf("%s/api/v1/health", c.endpoint)
@@ -200,3 +253,36 @@ type HTTPError struct {
 func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
 }
+
+// PartialError is returned by GetAllFlags, along with the other flags,
+// when the details of some flags could not be fetched
+type PartialError struct {
+	// Failed maps the ID of every flag left out to its error
+	Failed map[int64]error
+}
+
+func (e *PartialError) Error() string {
+	ids := make([]int64, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return fmt.Sprintf("failed to fetch details of flags %v: flag %d: %v", ids, ids[0], e.Failed[ids[0]])
+}
+
+// IsPartial reports whether err means the flags returned with it are
+// incomplete, some flags having been left out
+func IsPartial(err error) bool {
+	var partial *PartialError
+	return errors.As(err, &partial)
+}
+
+// IsFlagNotFound reports whether err means the requested flag does not
+// exist: a 404 from Flagr or a not found error from another client
+func IsFlagNotFound(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusNotFound
+	}
+	return domain.IsNotFound(err)
+}
@@ -125,6 +125,38 @@ func TestHTTPClient_EvaluateFlag(t *testing.T) {
 	assert.Equal(t, raw(true), result.VariantAttachment["enabled"])
 }
 
+func TestHTTPClient_ExplainFlag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EvaluationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		assert.True(t, req.EnableDebug)
+		assert.Equal(t, "test-flag", req.FlagKey)
+
+		json.NewEncoder(w).Encode(EvaluationResponse{
+			FlagID:     1,
+			FlagKey:    "test-flag",
+			SegmentID:  7,
+			VariantID:  1,
+			VariantKey: "on",
+			EvalDebugLog: EvalDebugLog{
+				SegmentDebugLogs: []SegmentDebugLog{{SegmentID: 7, Msg: "matched"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(Config{Endpoint: server.URL, Timeout: 5 * time.Second})
+
+	trace, err := client.ExplainFlag(context.Background(), "test-flag", domain.NewEvaluationContext("user123"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "on", trace.Result.VariantKey)
+	require.Len(t, trace.Segments, 1)
+	assert.Equal(t, "matched", trace.Segments[0].Message)
+	assert.True(t, trace.Segments[0].Selected)
+}
+
 func TestHTTPClient_HealthCheck(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -348,6 +380,78 @@ func TestGetAllFlags_Success(t *testing.T) {
 	}
 }
 
+func TestGetAllFlags_PartialFailure(t *testing.T) {
+	flagList := []FlagrFlag{
+		{ID: 1, Key: "flag1"},
+		{ID: 2, Key: "flag2"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/flags":
+			json.NewEncoder(w).Encode(flagList)
+		case "/api/v1/flags/1":
+			json.NewEncoder(w).Encode(flagList[0])
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	flags, err := client.GetAllFlags(context.Background())
+	require.Error(t, err)
+	assert.True(t, IsPartial(err))
+	assert.Contains(t, err.Error(), "flags [2]")
+
+	var partial *PartialError
+	require.ErrorAs(t, err, &partial)
+	assert.Contains(t, partial.Failed, int64(2))
+
+	require.Len(t, flags, 1, "the other flags are still returned")
+	assert.Equal(t, "flag1", flags[0].Key)
+}
+
+func TestHTTPClient_FindFlags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/flags", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("preload"))
+		assert.Equal(t, "checkout", r.URL.Query().Get("tags"))
+		assert.Equal(t, "new_cart", r.URL.Query().Get("key"))
+
+		// Flagr matches keys by substring
+		json.NewEncoder(w).Encode([]FlagrFlag{
+			{ID: 1, Key: "new_cart", Enabled: true, Tags: []Tag{{Value: "checkout"}}},
+			{ID: 2, Key: "new_cart_v2", Tags: []Tag{{Value: "checkout"}}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	flags, err := client.FindFlags(context.Background(), FlagQuery{Tag: "checkout", Key: "new_cart"})
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "new_cart", flags[0].Key)
+	assert.True(t, flags[0].Enabled)
+}
+
+func TestFindFlags_WithoutFinder(t *testing.T) {
+	mock := NewMockClient()
+	mock.AddFlag(domain.Flag{ID: 1, Key: "new_cart", Tags: []domain.Tag{{Value: "checkout"}}})
+	mock.AddFlag(domain.Flag{ID: 2, Key: "search_v2", Tags: []domain.Tag{{Value: "search"}}})
+
+	flags, err := FindFlags(context.Background(), mock, FlagQuery{Tag: "search"})
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "search_v2", flags[0].Key)
+
+	flags, err = FindFlags(context.Background(), mock, FlagQuery{Key: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+}
+
 //
 // ────────────────────────────────────────────────
 //   Test: EvaluateFlag
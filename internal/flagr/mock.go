@@ -19,12 +19,14 @@ type MockClient struct {
 	GetFlagFunc      func(ctx context.Context, flagID int64) (*domain.Flag, error)
 	EvaluateFlagFunc func(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error)
 	HealthCheckFunc  func(ctx context.Context) error
+	ExplainFlagFunc  func(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error)
 
 	// Call tracking
 	GetAllFlagsCalls  int
 	GetFlagCalls      int
 	EvaluateFlagCalls int
 	HealthCheckCalls  int
+	ExplainFlagCalls  int
 }
 
 // NewMockClient creates a new mock client
@@ -119,6 +121,30 @@ func (m *MockClient) EvaluateFlag(ctx context.Context, flagKey string, evalCtx d
 	return nil, domain.NewNotFoundError("flag", flagKey)
 }
 
+// ExplainFlag evaluates a flag and wraps the result in a remote trace
+func (m *MockClient) ExplainFlag(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationTrace, error) {
+	m.mu.Lock()
+	m.ExplainFlagCalls++
+	m.mu.Unlock()
+
+	if m.ExplainFlagFunc != nil {
+		return m.ExplainFlagFunc(ctx, flagKey, evalCtx)
+	}
+
+	result, err := m.EvaluateFlag(ctx, flagKey, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.EvaluationTrace{
+		FlagID:         result.FlagID,
+		FlagKey:        result.FlagKey,
+		Strategy:       domain.StrategyRemote,
+		StrategyReason: "mock evaluation",
+		Result:         result,
+	}, nil
+}
+
 // HealthCheck performs health check
 func (m *MockClient) HealthCheck(ctx context.Context) error {
 	m.mu.Lock()
@@ -142,6 +168,7 @@ func (m *MockClient) Reset() {
 	m.GetFlagCalls = 0
 	m.EvaluateFlagCalls = 0
 	m.HealthCheckCalls = 0
+	m.ExplainFlagCalls = 0
 }
 
 // AssertCalled asserts methods were called expected times
@@ -159,6 +186,8 @@ func (m *MockClient) AssertCalled(t interface{ Errorf(string, ...interface{}) },
 		actual = m.EvaluateFlagCalls
 	case "HealthCheck":
 		actual = m.HealthCheckCalls
+	case "ExplainFlag":
+		actual = m.ExplainFlagCalls
 	default:
 		t.Errorf("unknown method: %s", method)
 		return
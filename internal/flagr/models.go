@@ -24,6 +24,7 @@ type FlagrFlag struct {
 
 // Tag represents a Flagr API tag
 type Tag struct {
+	ID    int64  `json:"id,omitempty"`
 	Value string `json:"value"`
 }
 
@@ -80,6 +81,7 @@ type EvaluationRequest struct {
 	EntityID      string                 `json:"entityID"`
 	EntityType    string                 `json:"entityType"`
 	EntityContext map[string]interface{} `json:"entityContext"`
+	EnableDebug   bool                   `json:"enableDebug,omitempty"`
 }
 
 // Full evaluation response from Flagr
@@ -92,6 +94,32 @@ type EvaluationResponse struct {
 	VariantAttachment map[string]json.RawMessage `json:"variantAttachment"`
 	Timestamp         time.Time                  `json:"timestamp"`
 	EvalDebugLog      EvalDebugLog               `json:"evalDebugLog"`
+
+	// EvalContext echoes the evaluated entity (used by batch evaluation)
+	EvalContext *EvaluationRequest `json:"evalContext,omitempty"`
+}
+
+// EvaluationEntity is an entity evaluated by a batch request
+type EvaluationEntity struct {
+	EntityID      string                 `json:"entityID"`
+	EntityType    string                 `json:"entityType"`
+	EntityContext map[string]interface{} `json:"entityContext"`
+}
+
+// EvaluationBatchRequest evaluates several flags for several entities.
+// Flags are selected by ID, key or tag.
+type EvaluationBatchRequest struct {
+	Entities         []EvaluationEntity `json:"entities"`
+	EnableDebug      bool               `json:"enableDebug,omitempty"`
+	FlagIDs          []int64            `json:"flagIDs,omitempty"`
+	FlagKeys         []string           `json:"flagKeys,omitempty"`
+	FlagTags         []string           `json:"flagTags,omitempty"`
+	FlagTagsOperator string             `json:"flagTagsOperator,omitempty"`
+}
+
+// EvaluationBatchResponse holds one result per entity and flag
+type EvaluationBatchResponse struct {
+	EvaluationResults []EvaluationResponse `json:"evaluationResults"`
 }
 
 // Debug logs used to extract evaluation reason
@@ -101,7 +129,8 @@ type EvalDebugLog struct {
 }
 
 type SegmentDebugLog struct {
-	Msg string `json:"msg"`
+	SegmentID int64  `json:"segmentID"`
+	Msg       string `json:"msg"`
 }
 
 // -----------------------------------------------------------------------------
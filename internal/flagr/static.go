@@ -0,0 +1,131 @@
+package flagr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+)
+
+// errNotLoaded is returned until a source has been given its flags
+var errNotLoaded = errors.New("flags not loaded")
+
+// StaticSource is a Client that serves flags held in memory and evaluates
+// them locally, including partial rollouts and A/B distributions.
+// StaticSource is safe for concurrent use.
+type StaticSource struct {
+	evaluator *evaluator.LocalEvaluator
+
+	mu     sync.RWMutex
+	flags  []domain.Flag
+	loaded bool
+}
+
+// NewStaticSource creates a source serving flags
+func NewStaticSource(flags ...domain.Flag) *StaticSource {
+	s := &StaticSource{evaluator: evaluator.New()}
+	s.SetFlags(flags)
+	return s
+}
+
+// SetFlags replaces the served flags
+func (s *StaticSource) SetFlags(flags []domain.Flag) {
+	copied := make([]domain.Flag, len(flags))
+	copy(copied, flags)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flags = copied
+	s.loaded = true
+}
+
+// SetFlag adds a flag, replacing the flag with the same key
+func (s *StaticSource) SetFlag(flag domain.Flag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loaded = true
+	for i := range s.flags {
+		if s.flags[i].Key == flag.Key {
+			s.flags[i] = flag
+			return
+		}
+	}
+	s.flags = append(s.flags, flag)
+}
+
+// Keys returns the keys of the served flags
+func (s *StaticSource) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.flags))
+	for _, f := range s.flags {
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
+
+// GetAllFlags returns every served flag
+func (s *StaticSource) GetAllFlags(ctx context.Context) ([]domain.Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.loaded {
+		return nil, errNotLoaded
+	}
+
+	flags := make([]domain.Flag, len(s.flags))
+	copy(flags, s.flags)
+	return flags, nil
+}
+
+// GetFlag returns a flag by ID
+func (s *StaticSource) GetFlag(ctx context.Context, flagID int64) (*domain.Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, f := range s.flags {
+		if f.ID == flagID {
+			flag := f
+			return &flag, nil
+		}
+	}
+
+	return nil, domain.NewNotFoundError("flag", fmt.Sprintf("%d", flagID))
+}
+
+// EvaluateFlag evaluates a flag locally
+func (s *StaticSource) EvaluateFlag(ctx context.Context, flagKey string, evalCtx domain.EvaluationContext) (*domain.EvaluationResult, error) {
+	s.mu.RLock()
+	var flag *domain.Flag
+	for i := range s.flags {
+		if s.flags[i].Key == flagKey {
+			f := s.flags[i]
+			flag = &f
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if flag == nil {
+		return nil, domain.NewNotFoundError("flag", flagKey)
+	}
+
+	return s.evaluator.EvaluateRollout(ctx, *flag, evalCtx)
+}
+
+// HealthCheck reports whether the source has flags to serve
+func (s *StaticSource) HealthCheck(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.loaded {
+		return errNotLoaded
+	}
+	return nil
+}
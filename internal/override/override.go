@@ -0,0 +1,333 @@
+// Package override provides a local layer of forced flag results that is
+// consulted before the cache, so a variant can be pinned for a test account
+// or an environment without touching Flagr.
+package override
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// Reason is the evaluation reason of results produced by an override
+const Reason = "OVERRIDE"
+
+// Source tells where an override came from
+type Source string
+
+const (
+	SourceRuntime Source = "runtime" // Set through the API or the admin server
+	SourceFile    Source = "file"    // Loaded from an override file
+)
+
+// Override forces the result of a flag for the entities it matches.
+//
+// An override with an EntityID only matches that entity. An override with
+// Match only matches contexts whose properties equal every entry (a list
+// value matches any of its elements). An override with neither matches
+// every evaluation of the flag.
+type Override struct {
+	FlagKey  string                 `json:"flag_key" yaml:"flag_key"`
+	EntityID string                 `json:"entity_id,omitempty" yaml:"entity_id,omitempty"`
+	Match    map[string]interface{} `json:"match,omitempty" yaml:"match,omitempty"`
+
+	VariantKey        string                     `json:"variant_key" yaml:"variant_key"`
+	VariantAttachment map[string]json.RawMessage `json:"attachment,omitempty" yaml:"-"`
+
+	Source Source `json:"source,omitempty" yaml:"-"`
+}
+
+// Validate checks that the override can be applied
+func (o Override) Validate() error {
+	if o.FlagKey == "" {
+		return fmt.Errorf("override flag_key is required")
+	}
+	if o.VariantKey == "" {
+		return fmt.Errorf("override for %s: variant_key is required", o.FlagKey)
+	}
+	return nil
+}
+
+// ID identifies the override: two overrides with the same flag, entity and
+// matcher replace each other
+func (o Override) ID() string {
+	match, _ := json.Marshal(o.Match) // map keys are sorted by encoding/json
+	return o.FlagKey + "|" + o.EntityID + "|" + string(match)
+}
+
+// Matches reports whether the override applies to the evaluation context
+func (o Override) Matches(evalCtx domain.EvaluationContext) bool {
+	if o.EntityID != "" && o.EntityID != evalCtx.EntityID {
+		return false
+	}
+
+	for property, expected := range o.Match {
+		actual, ok := evalCtx.Lookup(property)
+		if !ok || !matchValue(expected, actual) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Result builds the evaluation result returned for a matched override
+func (o Override) Result() *domain.EvaluationResult {
+	return &domain.EvaluationResult{
+		FlagKey:           o.FlagKey,
+		VariantKey:        o.VariantKey,
+		VariantAttachment: o.VariantAttachment,
+		EvaluationReason:  Reason,
+		Timestamp:         time.Now(),
+	}
+}
+
+// Describe returns a short human-readable description of what is matched
+func (o Override) Describe() string {
+	var parts []string
+	if o.EntityID != "" {
+		parts = append(parts, "entity "+o.EntityID)
+	}
+	if len(o.Match) > 0 {
+		keys := make([]string, 0, len(o.Match))
+		for k := range o.Match {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, o.Match[k]))
+		}
+	}
+	if len(parts) == 0 {
+		return "all entities"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// specificity orders overrides: entity-specific ones win over attribute
+// matchers, which win over flag-wide overrides
+func (o Override) specificity() int {
+	score := len(o.Match)
+	if o.EntityID != "" {
+		score += 1000
+	}
+	return score
+}
+
+// matchValue compares a matcher value with a context value
+func matchValue(expected, actual interface{}) bool {
+	if list, ok := expected.([]interface{}); ok {
+		for _, item := range list {
+			if matchValue(item, actual) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+// Store holds the active overrides. Runtime overrides take precedence over
+// file overrides; reloading the file only replaces file overrides.
+// Store is safe for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	runtime []Override
+	file    []Override
+}
+
+// NewStore creates an empty override store
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Set adds an override, replacing the runtime override with the same ID
+func (s *Store) Set(o Override) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	o.Source = SourceRuntime
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runtime = upsert(s.runtime, o)
+	return nil
+}
+
+// Remove deletes the runtime override with the same ID as o.
+// Returns false when there was no such override.
+func (s *Store) Remove(o Override) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := o.ID()
+	for i, existing := range s.runtime {
+		if existing.ID() == id {
+			s.runtime = append(s.runtime[:i:i], s.runtime[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes every runtime override. File overrides stay until the
+// file changes.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runtime = nil
+}
+
+// Replace swaps the file overrides for the given set
+func (s *Store) Replace(overrides []Override) error {
+	loaded := make([]Override, 0, len(overrides))
+	for _, o := range overrides {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		o.Source = SourceFile
+		loaded = upsert(loaded, o)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.file = loaded
+	return nil
+}
+
+// List returns every override, runtime ones first
+func (s *Store) List() []Override {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]Override, 0, len(s.runtime)+len(s.file))
+	out = append(out, s.runtime...)
+	out = append(out, s.file...)
+	return out
+}
+
+// Len returns the number of active overrides
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.runtime) + len(s.file)
+}
+
+// Match returns the override to apply for a flag evaluation.
+// The most specific matching override wins; ties go to runtime overrides
+// and then to declaration order.
+func (s *Store) Match(flagKey string, evalCtx domain.EvaluationContext) (Override, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best Override
+	found := false
+
+	for _, layer := range [][]Override{s.runtime, s.file} {
+		for _, o := range layer {
+			if o.FlagKey != flagKey || !o.Matches(evalCtx) {
+				continue
+			}
+			if !found || o.specificity() > best.specificity() {
+				best = o
+				found = true
+			}
+		}
+	}
+
+	return best, found
+}
+
+// upsert replaces the override with the same ID or appends it
+func upsert(list []Override, o Override) []Override {
+	id := o.ID()
+	for i, existing := range list {
+		if existing.ID() == id {
+			list[i] = o
+			return list
+		}
+	}
+	return append(list, o)
+}
+
+// fileFormat is the layout of an override file:
+//
+//	overrides:
+//	  - flag_key: new-checkout
+//	    entity_id: qa-account-1
+//	    variant_key: enabled
+//	    attachment:
+//	      enabled: true
+type fileFormat struct {
+	Overrides []fileOverride `json:"overrides" yaml:"overrides"`
+}
+
+type fileOverride struct {
+	Override   `yaml:",inline"`
+	Attachment map[string]interface{} `json:"attachment,omitempty" yaml:"attachment,omitempty"`
+}
+
+// Parse decodes overrides from JSON or YAML. The format is picked from the
+// file extension (".json" is JSON, anything else is YAML).
+func Parse(path string, data []byte) ([]Override, error) {
+	var file fileFormat
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse override file %s: %w", path, err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse override file %s: %w", path, err)
+		}
+	}
+
+	overrides := make([]Override, 0, len(file.Overrides))
+	for i, fo := range file.Overrides {
+		o := fo.Override
+		if len(fo.Attachment) > 0 {
+			o.VariantAttachment = make(map[string]json.RawMessage, len(fo.Attachment))
+			for k, v := range fo.Attachment {
+				raw, err := json.Marshal(v)
+				if err != nil {
+					return nil, fmt.Errorf("override %d (%s): invalid attachment %q: %w", i, o.FlagKey, k, err)
+				}
+				o.VariantAttachment[k] = raw
+			}
+		}
+		if err := o.Validate(); err != nil {
+			return nil, fmt.Errorf("override %d: %w", i, err)
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
+
+// LoadFile reads an override file and replaces the store's file overrides
+func (s *Store) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read override file: %w", err)
+	}
+
+	overrides, err := Parse(path, data)
+	if err != nil {
+		return err
+	}
+
+	return s.Replace(overrides)
+}
@@ -0,0 +1,208 @@
+package override
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverride_Matches(t *testing.T) {
+	evalCtx := domain.NewEvaluationContext("user-1").
+		WithAttribute("country", "BR").
+		WithAttribute("age", 30).
+		WithAttribute("account", map[string]interface{}{"tier": "qa"})
+
+	tests := []struct {
+		name     string
+		override Override
+		want     bool
+	}{
+		{"flag wide", Override{}, true},
+		{"entity match", Override{EntityID: "user-1"}, true},
+		{"entity mismatch", Override{EntityID: "user-2"}, false},
+		{"attribute match", Override{Match: map[string]interface{}{"country": "BR"}}, true},
+		{"attribute mismatch", Override{Match: map[string]interface{}{"country": "US"}}, false},
+		{"missing attribute", Override{Match: map[string]interface{}{"plan": "pro"}}, false},
+		{"numeric attribute", Override{Match: map[string]interface{}{"age": 30}}, true},
+		{"dotted path", Override{Match: map[string]interface{}{"account.tier": "qa"}}, true},
+		{"entity property", Override{Match: map[string]interface{}{"entityType": "user"}}, true},
+		{"list value", Override{Match: map[string]interface{}{"country": []interface{}{"US", "BR"}}}, true},
+		{"entity and attribute", Override{EntityID: "user-1", Match: map[string]interface{}{"country": "US"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.override.Matches(evalCtx))
+		})
+	}
+}
+
+func TestOverride_Validate(t *testing.T) {
+	assert.Error(t, Override{VariantKey: "on"}.Validate())
+	assert.Error(t, Override{FlagKey: "f"}.Validate())
+	assert.NoError(t, Override{FlagKey: "f", VariantKey: "on"}.Validate())
+}
+
+func TestStore_MatchPrecedence(t *testing.T) {
+	store := NewStore()
+
+	require.NoError(t, store.Replace([]Override{
+		{FlagKey: "f", VariantKey: "file-wide"},
+		{FlagKey: "f", EntityID: "qa-1", VariantKey: "file-entity"},
+	}))
+	require.NoError(t, store.Set(Override{FlagKey: "f", Match: map[string]interface{}{"tier": "qa"}, VariantKey: "runtime-match"}))
+
+	o, ok := store.Match("f", domain.NewEvaluationContext("qa-1").WithAttribute("tier", "qa"))
+	require.True(t, ok)
+	assert.Equal(t, "file-entity", o.VariantKey)
+	assert.Equal(t, SourceFile, o.Source)
+
+	o, ok = store.Match("f", domain.NewEvaluationContext("user-2").WithAttribute("tier", "qa"))
+	require.True(t, ok)
+	assert.Equal(t, "runtime-match", o.VariantKey)
+
+	o, ok = store.Match("f", domain.NewEvaluationContext("user-3"))
+	require.True(t, ok)
+	assert.Equal(t, "file-wide", o.VariantKey)
+
+	// Runtime wins ties
+	require.NoError(t, store.Set(Override{FlagKey: "f", VariantKey: "runtime-wide"}))
+	o, _ = store.Match("f", domain.NewEvaluationContext("user-3"))
+	assert.Equal(t, "runtime-wide", o.VariantKey)
+
+	_, ok = store.Match("other", domain.NewEvaluationContext("qa-1"))
+	assert.False(t, ok)
+}
+
+func TestStore_SetRemoveClear(t *testing.T) {
+	store := NewStore()
+	require.NoError(t, store.Replace([]Override{{FlagKey: "f", VariantKey: "file"}}))
+
+	require.NoError(t, store.Set(Override{FlagKey: "f", EntityID: "u", VariantKey: "a"}))
+	require.NoError(t, store.Set(Override{FlagKey: "f", EntityID: "u", VariantKey: "b"}))
+	assert.Equal(t, 2, store.Len())
+
+	o, _ := store.Match("f", domain.NewEvaluationContext("u"))
+	assert.Equal(t, "b", o.VariantKey)
+
+	assert.Error(t, store.Set(Override{FlagKey: "f"}))
+
+	assert.True(t, store.Remove(Override{FlagKey: "f", EntityID: "u"}))
+	assert.False(t, store.Remove(Override{FlagKey: "f", EntityID: "u"}))
+
+	// File overrides cannot be removed at runtime
+	assert.False(t, store.Remove(Override{FlagKey: "f"}))
+
+	require.NoError(t, store.Set(Override{FlagKey: "g", VariantKey: "on"}))
+	store.Clear()
+
+	list := store.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, SourceFile, list[0].Source)
+}
+
+func TestOverride_Result(t *testing.T) {
+	o := Override{FlagKey: "f", VariantKey: "on"}
+	result := o.Result()
+
+	assert.Equal(t, "f", result.FlagKey)
+	assert.Equal(t, "on", result.VariantKey)
+	assert.Equal(t, Reason, result.EvaluationReason)
+	assert.False(t, result.Timestamp.IsZero())
+}
+
+func TestParse(t *testing.T) {
+	yamlData := []byte(`
+overrides:
+  - flag_key: new-checkout
+    entity_id: qa-account-1
+    variant_key: enabled
+    attachment:
+      enabled: true
+      color: blue
+  - flag_key: pricing
+    match:
+      tier: [qa, internal]
+    variant_key: control
+`)
+
+	overrides, err := Parse("overrides.yaml", yamlData)
+	require.NoError(t, err)
+	require.Len(t, overrides, 2)
+	assert.Equal(t, "qa-account-1", overrides[0].EntityID)
+	assert.JSONEq(t, "true", string(overrides[0].VariantAttachment["enabled"]))
+	assert.JSONEq(t, `"blue"`, string(overrides[0].VariantAttachment["color"]))
+	assert.True(t, overrides[1].Matches(domain.NewEvaluationContext("u").WithAttribute("tier", "internal")))
+
+	jsonData := []byte(`{"overrides":[{"flag_key":"f","variant_key":"on","attachment":{"value":3}}]}`)
+	overrides, err = Parse("overrides.json", jsonData)
+	require.NoError(t, err)
+	require.Len(t, overrides, 1)
+	assert.JSONEq(t, "3", string(overrides[0].VariantAttachment["value"]))
+
+	_, err = Parse("overrides.json", []byte(`{`))
+	assert.Error(t, err)
+
+	_, err = Parse("overrides.yaml", []byte("overrides:\n  - flag_key: f\n"))
+	assert.Error(t, err)
+}
+
+func TestFileWatcher_HotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	writeFile(t, path, "overrides:\n  - flag_key: f\n    variant_key: first\n")
+
+	store := NewStore()
+	errs := make(chan error, 10)
+	watcher := NewFileWatcher(store, path, 10*time.Millisecond)
+	watcher.OnError = func(err error) { errs <- err }
+
+	require.NoError(t, watcher.Start(context.Background()))
+	defer watcher.Stop()
+
+	o, ok := store.Match("f", domain.NewEvaluationContext("u"))
+	require.True(t, ok)
+	assert.Equal(t, "first", o.VariantKey)
+
+	writeFile(t, path, "overrides:\n  - flag_key: f\n    variant_key: second-version\n")
+	assert.Eventually(t, func() bool {
+		o, _ := store.Match("f", domain.NewEvaluationContext("u"))
+		return o.VariantKey == "second-version"
+	}, time.Second, 10*time.Millisecond)
+
+	// An invalid file keeps the previous overrides
+	writeFile(t, path, "overrides: [")
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected reload error")
+	}
+	assert.Error(t, watcher.LastError())
+
+	o, _ = store.Match("f", domain.NewEvaluationContext("u"))
+	assert.Equal(t, "second-version", o.VariantKey)
+}
+
+func TestFileWatcher_StartFailsOnMissingFile(t *testing.T) {
+	watcher := NewFileWatcher(NewStore(), filepath.Join(t.TempDir(), "missing.yaml"), 0)
+	assert.Error(t, watcher.Start(context.Background()))
+
+	// Stop is safe when Start failed
+	watcher.Stop()
+}
+
+// writeFile writes content and bumps the modification time so that
+// changes are detected even on filesystems with coarse timestamps
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	stamp := time.Now().Add(time.Duration(len(content)) * time.Second)
+	require.NoError(t, os.Chtimes(path, stamp, stamp))
+}
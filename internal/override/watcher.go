@@ -0,0 +1,17 @@
+package override
+
+import (
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/filewatch"
+)
+
+// NewFileWatcher keeps the store's file overrides in sync with path.
+// An invalid file is reported through the watcher's OnError and the
+// previous overrides are kept. A non-positive interval uses
+// filewatch.DefaultPollInterval.
+func NewFileWatcher(store *Store, path string, interval time.Duration) *filewatch.Watcher {
+	return filewatch.New(path, interval, func() error {
+		return store.LoadFile(path)
+	})
+}
@@ -0,0 +1,182 @@
+package scan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// Finding kinds
+const (
+	// KindMissing is a flag key evaluated in code that does not exist
+	KindMissing = "missing"
+
+	// KindUnused is a flag that no code evaluates
+	KindUnused = "unused"
+
+	// KindFallbackDefault is a String default that the fallback strategy
+	// replaces when the flag is missing
+	KindFallbackDefault = "fallback-default"
+
+	// KindInconsistentDefault is a flag evaluated with different defaults
+	KindInconsistentDefault = "inconsistent-default"
+)
+
+// kindOrder sorts findings by kind
+var kindOrder = map[string]int{
+	KindMissing:             0,
+	KindFallbackDefault:     1,
+	KindInconsistentDefault: 2,
+	KindUnused:              3,
+}
+
+// fallbackVariants are the variants the fallback strategies serve for
+// missing flags; String returns the variant key
+var fallbackVariants = map[string]string{
+	"fail_open":   "enabled",
+	"fail_closed": "disabled",
+}
+
+// Finding is a difference between code and Flagr
+type Finding struct {
+	Kind    string `json:"kind"`
+	FlagKey string `json:"flag_key"`
+	Message string `json:"message"`
+
+	// References are the positions of the code involved (none for unused
+	// flags)
+	References []string `json:"references,omitempty"`
+}
+
+// Compare checks the references against flags. fallback is the fallback
+// strategy of the client ("fail_open", "fail_closed" or "error"); an empty
+// fallback skips the fallback-default check. Dynamic references are not
+// checked.
+func Compare(result *Result, flags []domain.Flag, fallback string) []Finding {
+	known := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		known[flag.Key] = true
+	}
+
+	byKey := map[string][]Reference{}
+	for _, ref := range result.References {
+		if !ref.Dynamic {
+			byKey[ref.FlagKey] = append(byKey[ref.FlagKey], ref)
+		}
+	}
+
+	findings := []Finding{}
+	for key, refs := range byKey {
+		if !known[key] {
+			findings = append(findings, Finding{
+				Kind:       KindMissing,
+				FlagKey:    key,
+				Message:    fmt.Sprintf("flag %s is evaluated in code but does not exist", key),
+				References: positions(refs),
+			})
+		}
+
+		defaults := map[string][]Reference{}
+		for _, ref := range refs {
+			if ref.Default != nil {
+				value := fmt.Sprintf("%#v", ref.Default)
+				defaults[value] = append(defaults[value], ref)
+			}
+		}
+
+		if served, ok := fallbackVariants[fallback]; ok {
+			for _, ref := range refs {
+				if def, isString := ref.Default.(string); isString && ref.Method == "String" && def != served {
+					findings = append(findings, Finding{
+						Kind:    KindFallbackDefault,
+						FlagKey: key,
+						Message: fmt.Sprintf("default %q is never returned for a missing flag: with fallback %s, String returns %q",
+							def, fallback, served),
+						References: []string{ref.Position()},
+					})
+				}
+			}
+		}
+
+		if len(defaults) > 1 {
+			values := make([]string, 0, len(defaults))
+			var all []Reference
+			for value, refs := range defaults {
+				values = append(values, value)
+				all = append(all, refs...)
+			}
+			sort.Strings(values)
+			sortReferences(all)
+			findings = append(findings, Finding{
+				Kind:       KindInconsistentDefault,
+				FlagKey:    key,
+				Message:    fmt.Sprintf("flag %s is evaluated with different defaults: %s", key, strings.Join(values, ", ")),
+				References: positions(all),
+			})
+		}
+	}
+
+	for _, flag := range flags {
+		if _, ok := byKey[flag.Key]; !ok {
+			findings = append(findings, Finding{
+				Kind:    KindUnused,
+				FlagKey: flag.Key,
+				Message: fmt.Sprintf("flag %s is not evaluated by any scanned code", flag.Key),
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		if a.FlagKey != b.FlagKey {
+			return a.FlagKey < b.FlagKey
+		}
+		return strings.Join(a.References, ",") < strings.Join(b.References, ",")
+	})
+
+	return findings
+}
+
+// FallbackStrategy returns the fallback strategy configured in code: the
+// library default ("fail_closed") when none is, and an error when the code
+// configures different ones
+func (r *Result) FallbackStrategy() (string, error) {
+	if len(r.Fallbacks) == 0 {
+		return "fail_closed", nil
+	}
+
+	strategy := r.Fallbacks[0].Strategy
+	for _, s := range r.Fallbacks[1:] {
+		if s.Strategy != strategy {
+			return "", fmt.Errorf("code configures fallback strategies %s (%s:%d) and %s (%s:%d)",
+				strategy, r.Fallbacks[0].File, r.Fallbacks[0].Line, s.Strategy, s.File, s.Line)
+		}
+	}
+	return strategy, nil
+}
+
+func positions(refs []Reference) []string {
+	out := make([]string, len(refs))
+	for i, ref := range refs {
+		out[i] = ref.Position()
+	}
+	return out
+}
+
+func sortReferences(refs []Reference) {
+	sort.SliceStable(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
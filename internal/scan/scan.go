@@ -0,0 +1,422 @@
+// Package scan finds the flag keys Go code evaluates and compares them with
+// the flags in Flagr.
+//
+// The scanner is syntactic: it parses Go files with go/ast, without type
+// checking, and reports calls shaped like the Client evaluation methods
+// (Bool, String, Int, Evaluate and Explain) in packages that import Vexilla.
+// Flag keys and defaults are resolved when they are constants: literals,
+// constant expressions and package-level constants, including constants of
+// other scanned packages.
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// vexillaPath is the import path of the Vexilla module
+const vexillaPath = "github.com/OrlandoBitencourt/vexilla"
+
+// evalMethods maps the Client evaluation methods to their argument count
+var evalMethods = map[string]int{
+	"Bool":     3, // Bool(ctx, flagKey, evalCtx)
+	"String":   4, // String(ctx, flagKey, evalCtx, defaultVal)
+	"Int":      4, // Int(ctx, flagKey, evalCtx, defaultVal)
+	"Evaluate": 3, // Evaluate(ctx, flagKey, evalCtx)
+	"Explain":  3, // Explain(ctx, flagKey, evalCtx)
+}
+
+// Reference is a flag evaluation found in code
+type Reference struct {
+	// FlagKey is the constant flag key (empty when Dynamic)
+	FlagKey string `json:"flag_key,omitempty"`
+
+	// Method is the evaluation method, such as "Bool"
+	Method string `json:"method"`
+
+	// Default is the constant default of String and Int (a string or an
+	// int64), nil when there is none or it is not a constant
+	Default any `json:"default,omitempty"`
+
+	// Dynamic is true when the flag key is not a constant; Expr is its
+	// source
+	Dynamic bool   `json:"dynamic,omitempty"`
+	Expr    string `json:"expr,omitempty"`
+
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Position returns the file:line:column of the reference
+func (r Reference) Position() string {
+	return fmt.Sprintf("%s:%d:%d", r.File, r.Line, r.Column)
+}
+
+// Setting is a fallback strategy configured in code, with
+// WithFallbackStrategy or the FallbackStrategy field of Config
+type Setting struct {
+	Strategy string `json:"strategy"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Result is what a scan found
+type Result struct {
+	// Packages is the number of scanned packages that import Vexilla
+	Packages int `json:"packages"`
+
+	// References are sorted by position
+	References []Reference `json:"references"`
+
+	Fallbacks []Setting `json:"fallbacks,omitempty"`
+}
+
+// Options configures a scan
+type Options struct {
+	// Tests includes _test.go files
+	Tests bool
+}
+
+// pkg is a parsed package directory
+type pkg struct {
+	path    string // import path
+	files   []*ast.File
+	imports bool // some file imports Vexilla
+
+	consts   map[string]constSpec
+	resolved map[string]constant.Value
+}
+
+type constSpec struct {
+	expr ast.Expr
+	file *ast.File
+}
+
+// Dir scans Go packages. A pattern is a directory, or a directory followed by
+// "/..." for the directory and all directories below it, like the go tool.
+// Hidden directories, vendor and testdata are skipped.
+func Dir(patterns []string, opts Options) (*Result, error) {
+	fset := token.NewFileSet()
+	pkgs := map[string]*pkg{}
+	var order []*pkg
+
+	addDir := func(dir string) error {
+		p, err := parseDir(fset, dir, opts)
+		if err != nil || p == nil {
+			return err
+		}
+		if _, ok := pkgs[p.path]; !ok {
+			pkgs[p.path] = p
+			order = append(order, p)
+		}
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(pattern, "/...")
+		if pattern == "..." {
+			root, recursive = ".", true
+		}
+		if root == "" {
+			root = "."
+		}
+
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", root)
+		}
+
+		if !recursive {
+			if err := addDir(root); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return addDir(path)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Result{References: []Reference{}}
+	for _, p := range order {
+		if !p.imports {
+			continue
+		}
+		result.Packages++
+		for _, file := range p.files {
+			scanFile(fset, pkgs, p, file, result)
+		}
+	}
+
+	sortReferences(result.References)
+
+	return result, nil
+}
+
+// parseDir parses the Go files of a directory; it returns nil when there
+// are none
+func parseDir(fset *token.FileSet, dir string, opts Options) (*pkg, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pkg{
+		path:     importPath(dir),
+		consts:   map[string]constSpec{},
+		resolved: map[string]constant.Value{},
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if !opts.Tests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		p.files = append(p.files, file)
+
+		for _, imp := range file.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			if path == vexillaPath || strings.HasPrefix(path, vexillaPath+"/") {
+				p.imports = true
+			}
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i < len(vs.Values) {
+						p.consts[name.Name] = constSpec{expr: vs.Values[i], file: file}
+					}
+				}
+			}
+		}
+	}
+
+	if len(p.files) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// importPath derives the import path of a directory from the nearest go.mod;
+// directories outside a module are identified by their absolute path
+func importPath(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+
+	for root := abs; ; {
+		if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					rel, _ := filepath.Rel(root, abs)
+					module = strings.Trim(strings.TrimSpace(module), `"`)
+					if rel == "." {
+						return module
+					}
+					return module + "/" + filepath.ToSlash(rel)
+				}
+			}
+		}
+
+		parent := filepath.Dir(root)
+		if parent == root {
+			return abs
+		}
+		root = parent
+	}
+}
+
+// scanFile records the evaluation calls and fallback settings of a file
+func scanFile(fset *token.FileSet, pkgs map[string]*pkg, p *pkg, file *ast.File, result *Result) {
+	imports := fileImports(file)
+	eval := func(expr ast.Expr) (constant.Value, bool) {
+		return evalConst(pkgs, p, imports, expr, map[string]bool{})
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.KeyValueExpr:
+			if key, ok := n.Key.(*ast.Ident); ok && key.Name == "FallbackStrategy" {
+				if v, ok := eval(n.Value); ok && v.Kind() == constant.String {
+					pos := fset.Position(n.Pos())
+					result.Fallbacks = append(result.Fallbacks, Setting{Strategy: constant.StringVal(v), File: pos.Filename, Line: pos.Line})
+				}
+			}
+
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			if sel.Sel.Name == "WithFallbackStrategy" && len(n.Args) == 1 {
+				if v, ok := eval(n.Args[0]); ok && v.Kind() == constant.String {
+					pos := fset.Position(n.Pos())
+					result.Fallbacks = append(result.Fallbacks, Setting{Strategy: constant.StringVal(v), File: pos.Filename, Line: pos.Line})
+				}
+				return true
+			}
+
+			args, ok := evalMethods[sel.Sel.Name]
+			if !ok || len(n.Args) != args {
+				return true
+			}
+			// The first argument is a context: a constant means another
+			// method, such as flag.String(name, value, usage)
+			if _, ok := eval(n.Args[0]); ok {
+				return true
+			}
+
+			ref := Reference{Method: sel.Sel.Name}
+			if v, ok := eval(n.Args[1]); ok {
+				if v.Kind() != constant.String {
+					return true
+				}
+				ref.FlagKey = constant.StringVal(v)
+			} else {
+				ref.Dynamic = true
+				ref.Expr = types.ExprString(n.Args[1])
+			}
+
+			if args == 4 {
+				if v, ok := eval(n.Args[3]); ok {
+					switch v.Kind() {
+					case constant.String:
+						ref.Default = constant.StringVal(v)
+					case constant.Int:
+						ref.Default, _ = constant.Int64Val(v)
+					}
+				}
+			}
+
+			pos := fset.Position(n.Pos())
+			ref.File, ref.Line, ref.Column = pos.Filename, pos.Line, pos.Column
+			result.References = append(result.References, ref)
+		}
+		return true
+	})
+}
+
+// fileImports maps the package names used in a file to import paths
+func fileImports(file *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+// evalConst evaluates a constant expression; seen guards against cycles
+func evalConst(pkgs map[string]*pkg, p *pkg, imports map[string]string, expr ast.Expr, seen map[string]bool) (constant.Value, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		return v, v.Kind() != constant.Unknown
+
+	case *ast.ParenExpr:
+		return evalConst(pkgs, p, imports, e.X, seen)
+
+	case *ast.UnaryExpr:
+		x, ok := evalConst(pkgs, p, imports, e.X, seen)
+		if !ok || (e.Op != token.SUB && e.Op != token.ADD) {
+			return nil, false
+		}
+		return constant.UnaryOp(e.Op, x, 0), true
+
+	case *ast.BinaryExpr:
+		x, ok := evalConst(pkgs, p, imports, e.X, seen)
+		if !ok {
+			return nil, false
+		}
+		y, ok := evalConst(pkgs, p, imports, e.Y, seen)
+		if !ok || x.Kind() != y.Kind() {
+			return nil, false
+		}
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL:
+			return constant.BinaryOp(x, e.Op, y), true
+		}
+		return nil, false
+
+	case *ast.Ident:
+		return lookupConst(pkgs, p, e.Name, seen)
+
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return nil, false
+		}
+		other, ok := pkgs[imports[x.Name]]
+		if !ok {
+			return nil, false
+		}
+		return lookupConst(pkgs, other, e.Sel.Name, seen)
+	}
+	return nil, false
+}
+
+// lookupConst evaluates a package-level constant of p
+func lookupConst(pkgs map[string]*pkg, p *pkg, name string, seen map[string]bool) (constant.Value, bool) {
+	if v, ok := p.resolved[name]; ok {
+		return v, true
+	}
+
+	spec, ok := p.consts[name]
+	id := p.path + "." + name
+	if !ok || seen[id] {
+		return nil, false
+	}
+	seen[id] = true
+
+	v, ok := evalConst(pkgs, p, fileImports(spec.file), spec.expr, seen)
+	if ok {
+		p.resolved[name] = v
+	}
+	return v, ok
+}
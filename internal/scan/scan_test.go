@@ -0,0 +1,162 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeModule writes files into a temporary module named example.com/shop
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/shop\n\ngo 1.25\n"
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+const checkoutSource = `package checkout
+
+import (
+	"context"
+	"flag"
+
+	"github.com/OrlandoBitencourt/vexilla"
+	"example.com/shop/flags"
+)
+
+const themeFlag = "ui-" + "theme"
+
+var verbose = flag.Bool("verbose", false, "verbose output")
+
+func Render(ctx context.Context, client *vexilla.Client, key string) {
+	evalCtx := vexilla.NewContext("user-1")
+
+	client.Bool(ctx, "new-checkout", evalCtx)
+	client.Bool(ctx, flags.Pricing, evalCtx)
+	client.String(ctx, themeFlag, evalCtx, "light")
+	client.Int(ctx, "max-items", evalCtx, -10)
+	client.Evaluate(ctx, key, evalCtx)
+	flag.String("name", "default", "usage")
+}
+
+func New() (*vexilla.Client, error) {
+	return vexilla.New(vexilla.WithFallbackStrategy("fail_open"))
+}
+`
+
+func TestDir(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"checkout/checkout.go": checkoutSource,
+		"checkout/checkout_test.go": `package checkout
+
+func TestRender(t *testing.T) { client.Bool(ctx, "test-only", evalCtx) }
+`,
+		"flags/flags.go":      "package flags\n\nconst Pricing = \"pricing-v2\"\n",
+		"other/other.go":      "package other\n\nfunc f() { c.Bool(ctx, \"not-vexilla\", e) }\n",
+		"vendor/dep/dep.go":   "package dep\n\nimport \"github.com/OrlandoBitencourt/vexilla\"\n\nfunc f() { c.Bool(ctx, \"vendored\", e) }\n",
+		"testdata/fixture.go": "package fixture\n\nimport \"github.com/OrlandoBitencourt/vexilla\"\n\nfunc f() { c.Bool(ctx, \"fixture\", e) }\n",
+	})
+
+	result, err := Dir([]string{dir + "/..."}, Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Packages, "only packages importing Vexilla are scanned")
+	require.Len(t, result.References, 5)
+
+	file := filepath.Join(dir, "checkout", "checkout.go")
+	assert.Equal(t, Reference{Method: "Bool", FlagKey: "new-checkout", File: file, Line: 18, Column: 2}, result.References[0])
+	assert.Equal(t, "pricing-v2", result.References[1].FlagKey, "constant of another package")
+	assert.Equal(t, "ui-theme", result.References[2].FlagKey, "constant expression")
+	assert.Equal(t, "light", result.References[2].Default)
+	assert.Equal(t, int64(-10), result.References[3].Default)
+	assert.True(t, result.References[4].Dynamic)
+	assert.Equal(t, "key", result.References[4].Expr)
+	assert.Equal(t, file+":18:2", result.References[0].Position())
+
+	require.Len(t, result.Fallbacks, 1)
+	assert.Equal(t, "fail_open", result.Fallbacks[0].Strategy)
+
+	// Tests are opt-in
+	result, err = Dir([]string{dir + "/..."}, Options{Tests: true})
+	require.NoError(t, err)
+	assert.Len(t, result.References, 6)
+
+	// Without "/..." only the directory is scanned
+	result, err = Dir([]string{filepath.Join(dir, "flags")}, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Packages)
+
+	_, err = Dir([]string{filepath.Join(dir, "missing")}, Options{})
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	result := &Result{References: []Reference{
+		{Method: "Bool", FlagKey: "new-checkout", File: "a.go", Line: 1, Column: 1},
+		{Method: "Bool", FlagKey: "removed", File: "a.go", Line: 2, Column: 1},
+		{Method: "String", FlagKey: "ui-theme", Default: "light", File: "a.go", Line: 3, Column: 1},
+		{Method: "String", FlagKey: "ui-theme", Default: "dark", File: "b.go", Line: 1, Column: 1},
+		{Method: "String", FlagKey: "ui-theme", Default: "disabled", File: "b.go", Line: 2, Column: 1},
+		{Method: "Evaluate", Dynamic: true, Expr: "key", File: "c.go", Line: 1, Column: 1},
+	}}
+	flags := []domain.Flag{{Key: "new-checkout"}, {Key: "ui-theme"}, {Key: "legacy"}}
+
+	findings := Compare(result, flags, "fail_closed")
+	require.Len(t, findings, 5)
+
+	assert.Equal(t, Finding{
+		Kind:       KindMissing,
+		FlagKey:    "removed",
+		Message:    "flag removed is evaluated in code but does not exist",
+		References: []string{"a.go:2:1"},
+	}, findings[0])
+
+	assert.Equal(t, KindFallbackDefault, findings[1].Kind)
+	assert.Equal(t, `default "light" is never returned for a missing flag: with fallback fail_closed, String returns "disabled"`, findings[1].Message)
+	assert.Equal(t, []string{"a.go:3:1"}, findings[1].References)
+	assert.Equal(t, KindFallbackDefault, findings[2].Kind)
+	assert.Equal(t, []string{"b.go:1:1"}, findings[2].References)
+
+	assert.Equal(t, Finding{
+		Kind:       KindInconsistentDefault,
+		FlagKey:    "ui-theme",
+		Message:    `flag ui-theme is evaluated with different defaults: "dark", "disabled", "light"`,
+		References: []string{"a.go:3:1", "b.go:1:1", "b.go:2:1"},
+	}, findings[3])
+
+	assert.Equal(t, Finding{
+		Kind:    KindUnused,
+		FlagKey: "legacy",
+		Message: "flag legacy is not evaluated by any scanned code",
+	}, findings[4])
+
+	// The error strategy returns the default
+	for _, f := range Compare(result, flags, "error") {
+		assert.NotEqual(t, KindFallbackDefault, f.Kind)
+	}
+}
+
+func TestResult_FallbackStrategy(t *testing.T) {
+	strategy, err := (&Result{}).FallbackStrategy()
+	require.NoError(t, err)
+	assert.Equal(t, "fail_closed", strategy, "library default")
+
+	result := &Result{Fallbacks: []Setting{{Strategy: "error", File: "a.go", Line: 1}, {Strategy: "error", File: "b.go", Line: 1}}}
+	strategy, err = result.FallbackStrategy()
+	require.NoError(t, err)
+	assert.Equal(t, "error", strategy)
+
+	result.Fallbacks = append(result.Fallbacks, Setting{Strategy: "fail_open", File: "c.go", Line: 3})
+	_, err = result.FallbackStrategy()
+	assert.EqualError(t, err, "code configures fallback strategies error (a.go:1) and fail_open (c.go:3)")
+}
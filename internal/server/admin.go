@@ -1,16 +1,27 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
 // AdminServer provides admin HTTP endpoints
 type AdminServer struct {
-	cache CacheInterface
-	port  int
+	cache  CacheInterface
+	port   int
+	prefix string
+
+	auth      []Authenticator
+	audit     AuditLogger
+	tlsConfig *tls.Config
+
+	listener *Listener
 }
 
 // CacheInterface defines what the admin server needs from cache
@@ -19,6 +30,90 @@ type CacheInterface interface {
 	InvalidateFlag(flagKey string) error
 	InvalidateAll() error
 	RefreshFlags() error
+
+	// RefreshFlagKeys fetches only the flags with the keys (routed by
+	// prefix) and the IDs of each source ("" is the default source)
+	RefreshFlagKeys(keys []string, ids map[string][]int64) error
+
+	// Health reports the sync state and whether the readiness thresholds
+	// are met; checkFlagr also calls the Flagr health check
+	Health(ctx context.Context, checkFlagr bool) (report interface{}, ready bool)
+
+	// Sources lists the names of the flag sources, the default first;
+	// RefreshSource refreshes a single one
+	Sources() []string
+	RefreshSource(name string) error
+
+	// ExplainFlag evaluates a flag for the request's entity and reports the
+	// trace and strategy analysis
+	ExplainFlag(flagKey string, req EvaluateRequest) (*FlagReport, error)
+
+	// FlagKeys lists the keys of the cached flags of a source, or of every
+	// source when source is empty
+	FlagKeys(source string) []string
+
+	// Lint checks every cached flag for problems
+	Lint() []LintIssue
+
+	// Local overrides
+	ListOverrides() []OverrideSpec
+	SetOverride(spec OverrideSpec) error
+	RemoveOverride(spec OverrideSpec) bool
+	ClearOverrides()
+}
+
+// OverrideSpec is the payload of /admin/overrides. Overrides are identified
+// by flag_key, entity_id and match; attachment and variant_key are the
+// forced result.
+type OverrideSpec struct {
+	FlagKey    string                     `json:"flag_key"`
+	EntityID   string                     `json:"entity_id,omitempty"`
+	Match      map[string]interface{}     `json:"match,omitempty"`
+	VariantKey string                     `json:"variant_key"`
+	Attachment map[string]json.RawMessage `json:"attachment,omitempty"`
+
+	// Source is "runtime" or "file" (ignored on input)
+	Source string `json:"source,omitempty"`
+}
+
+// EvaluateRequest is the payload accepted by POST /admin/evaluate
+type EvaluateRequest struct {
+	FlagKey    string                 `json:"flag_key"`
+	EntityID   string                 `json:"entity_id"`
+	EntityType string                 `json:"entity_type"`
+	Attributes map[string]interface{} `json:"attributes"`
+
+	// Source evaluates in the named flag source instead of the source
+	// picked by the flag key prefix
+	Source string `json:"source,omitempty"`
+
+	// AllFlags evaluates every cached flag for the entity (dry run)
+	AllFlags bool `json:"all_flags"`
+}
+
+// FlagReport is the evaluation of a single flag returned by /admin/evaluate
+type FlagReport struct {
+	FlagKey     string      `json:"flag_key"`
+	Explanation interface{} `json:"explanation,omitempty"`
+	Analysis    interface{} `json:"analysis,omitempty"`
+	Performance interface{} `json:"performance,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// EvaluateAllResponse is returned by /admin/evaluate in all_flags mode
+type EvaluateAllResponse struct {
+	EntityID   string       `json:"entity_id"`
+	EntityType string       `json:"entity_type"`
+	Flags      []FlagReport `json:"flags"`
+}
+
+// LintIssue is a problem reported by /admin/lint
+type LintIssue struct {
+	FlagKey   string `json:"flag_key"`
+	SegmentID int64  `json:"segment_id,omitempty"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
 }
 
 // NewAdminServer creates a new admin server
@@ -29,37 +124,115 @@ func NewAdminServer(cache CacheInterface, port int) *AdminServer {
 	}
 }
 
-// Start starts the admin HTTP server
-func (a *AdminServer) Start() error {
+// WithPathPrefix mounts the endpoints under prefix (e.g. "/ops" serves
+// /ops/health and /ops/admin/stats)
+func (a *AdminServer) WithPathPrefix(prefix string) *AdminServer {
+	a.prefix = strings.TrimSuffix(prefix, "/")
+	return a
+}
+
+// WithAuth requires every endpoint but the health checks to be called by a
+// caller accepted by one of the authenticators, with the role the endpoint
+// needs. Mutating calls are reported to audit, which may be nil.
+func (a *AdminServer) WithAuth(audit AuditLogger, authenticators ...Authenticator) *AdminServer {
+	a.auth = authenticators
+	a.audit = audit
+	return a
+}
+
+// WithTLS serves the standalone server over TLS, e.g. with
+// tls.RequireAndVerifyClientCert for ClientCertificates
+func (a *AdminServer) WithTLS(config *tls.Config) *AdminServer {
+	a.tlsConfig = config
+	return a
+}
+
+// Handler returns the admin HTTP handler, to serve standalone or mount on
+// an existing router
+func (a *AdminServer) Handler() http.Handler {
 	mux := http.NewServeMux()
+	p := a.prefix
 
-	// Health check
-	mux.HandleFunc("/health", a.handleHealth)
+	// Health checks (public, for probes)
+	mux.HandleFunc(p+"/health", a.handleHealth)
+	mux.HandleFunc(p+"/healthz/live", a.handleLive)
+	mux.HandleFunc(p+"/healthz/ready", a.handleReady)
 
 	// Metrics
-	mux.HandleFunc("/admin/stats", a.handleStats)
+	mux.HandleFunc(p+"/admin/stats", a.authorize(RoleRead, a.handleStats))
 
 	// Cache management
-	mux.HandleFunc("/admin/invalidate", a.handleInvalidate)
-	mux.HandleFunc("/admin/invalidate-all", a.handleInvalidateAll)
-	mux.HandleFunc("/admin/refresh", a.handleRefresh)
+	mux.HandleFunc(p+"/admin/invalidate", a.authorize(RoleWrite, a.handleInvalidate))
+	mux.HandleFunc(p+"/admin/invalidate-all", a.authorize(RoleWrite, a.handleInvalidateAll))
+	mux.HandleFunc(p+"/admin/refresh", a.authorize(RoleWrite, a.handleRefresh))
 
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", a.port),
-		Handler: mux,
+	// Debugging (evaluations are dry runs)
+	mux.HandleFunc(p+"/admin/evaluate", a.authorize(RoleRead, a.handleEvaluate))
+	mux.HandleFunc(p+"/admin/lint", a.authorize(RoleRead, a.handleLint))
+
+	// Local overrides (listing only needs RoleRead)
+	mux.HandleFunc(p+"/admin/overrides", a.authorize(RoleWrite, a.handleOverrides))
+
+	return mux
+}
+
+// Start binds the admin port and serves in background
+func (a *AdminServer) Start(ctx context.Context) error {
+	listener, err := Listen(ctx, a.port, a.Handler(), a.tlsConfig)
+	if err != nil {
+		return err
+	}
+	a.listener = listener
+	return nil
+}
+
+// Addr returns the address the server listens on, or nil before Start
+func (a *AdminServer) Addr() net.Addr {
+	if a.listener == nil {
+		return nil
 	}
+	return a.listener.Addr()
+}
 
-	return server.ListenAndServe()
+// Shutdown drains in-flight requests until ctx is done and stops the server
+func (a *AdminServer) Shutdown(ctx context.Context) error {
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Shutdown(ctx)
 }
 
+// handleHealth reports the full health, including the Flagr health check
 func (a *AdminServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	a.writeHealth(w, r, true)
+}
+
+// handleLive answers as long as the process serves requests
+func (a *AdminServer) handleLive(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"status":    "healthy",
+		"status":    "alive",
 		"timestamp": time.Now().Format(time.RFC3339),
 	})
 }
 
+// handleReady reports whether the cached flags meet the readiness
+// thresholds, without calling Flagr
+func (a *AdminServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	a.writeHealth(w, r, false)
+}
+
+// writeHealth writes the health report, with 503 when not ready
+func (a *AdminServer) writeHealth(w http.ResponseWriter, r *http.Request, checkFlagr bool) {
+	report, ready := a.cache.Health(r.Context(), checkFlagr)
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
 func (a *AdminServer) handleStats(w http.ResponseWriter, r *http.Request) {
 	stats := a.cache.GetMetrics()
 	w.Header().Set("Content-Type", "application/json")
@@ -108,17 +281,187 @@ func (a *AdminServer) handleInvalidateAll(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
+// handleRefresh refreshes every source, or the one named by ?source=
 func (a *AdminServer) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if err := a.cache.RefreshFlags(); err != nil {
+	source := r.URL.Query().Get("source")
+	if !a.knownSource(w, source) {
+		return
+	}
+
+	var err error
+	if source == "" {
+		err = a.cache.RefreshFlags()
+	} else {
+		err = a.cache.RefreshSource(source)
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	resp := map[string]string{"status": "ok"}
+	if source != "" {
+		resp["source"] = source
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(resp)
+}
+
+// knownSource reports whether source is empty or names a flag source, and
+// answers 404 when it does not
+func (a *AdminServer) knownSource(w http.ResponseWriter, source string) bool {
+	if source == "" {
+		return true
+	}
+	for _, name := range a.cache.Sources() {
+		if name == source {
+			return true
+		}
+	}
+	http.Error(w, fmt.Sprintf("Unknown source %q", source), http.StatusNotFound)
+	return false
+}
+
+func (a *AdminServer) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req EvaluateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if !req.AllFlags && req.FlagKey == "" {
+		http.Error(w, "flag_key is required unless all_flags is set", http.StatusBadRequest)
+		return
+	}
+	if !a.knownSource(w, req.Source) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if !req.AllFlags {
+		json.NewEncoder(w).Encode(a.explain(req.FlagKey, req))
+		return
+	}
+
+	resp := EvaluateAllResponse{
+		EntityID:   req.EntityID,
+		EntityType: req.EntityType,
+		Flags:      []FlagReport{},
+	}
+	for _, key := range a.cache.FlagKeys(req.Source) {
+		resp.Flags = append(resp.Flags, a.explain(key, req))
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// explain builds the report for one flag; evaluation errors are reported
+// in the body rather than failing the request, since they are part of the
+// answer a support engineer is looking for
+func (a *AdminServer) explain(flagKey string, req EvaluateRequest) FlagReport {
+	report, err := a.cache.ExplainFlag(flagKey, req)
+	if report == nil {
+		report = &FlagReport{FlagKey: flagKey}
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return *report
+}
+
+// handleLint reports the problems found in the cached flags, with the
+// number of issues per severity
+func (a *AdminServer) handleLint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	issues := a.cache.Lint()
+	if issues == nil {
+		issues = []LintIssue{}
+	}
+
+	severities := map[string]int{}
+	for _, issue := range issues {
+		severities[issue.Severity]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":      len(issues),
+		"severities": severities,
+		"issues":     issues,
+	})
+}
+
+// handleOverrides lists (GET), sets (POST) and removes (DELETE) local
+// overrides. DELETE with ?all=true clears every runtime override.
+func (a *AdminServer) handleOverrides(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		overrides := a.cache.ListOverrides()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"count":     len(overrides),
+			"overrides": overrides,
+		})
+
+	case http.MethodPost:
+		var spec OverrideSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if err := a.cache.SetOverride(spec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "ok",
+			"flag":   spec.FlagKey,
+		})
+
+	case http.MethodDelete:
+		if r.URL.Query().Get("all") == "true" {
+			a.cache.ClearOverrides()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+			return
+		}
+
+		var spec OverrideSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if !a.cache.RemoveOverride(spec) {
+			http.Error(w, "Override not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "ok",
+			"flag":   spec.FlagKey,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
 }
@@ -2,7 +2,10 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -18,6 +21,30 @@ type mockCache struct {
 	invalidateErr       error
 	invalidateAllErr    error
 	refreshErr          error
+	Keys                []string
+	ExplainedKeys       []string
+	LastEvaluateRequest EvaluateRequest
+	explainErrs         map[string]error
+	Overrides           []OverrideSpec
+	OverridesCleared    bool
+	Issues              []LintIssue
+	SourceNames         []string
+	RefreshedSource     string
+	KeysSource          string
+	NotReady            bool
+	HealthChecks        []bool
+	InvalidatedKeys     []string
+	RefreshedKeys       [][]string
+	RefreshedIDs        []map[string][]int64
+	refreshHook         func()
+}
+
+func (m *mockCache) Health(ctx context.Context, checkFlagr bool) (interface{}, bool) {
+	m.HealthChecks = append(m.HealthChecks, checkFlagr)
+	if m.NotReady {
+		return map[string]string{"status": "unhealthy"}, false
+	}
+	return map[string]string{"status": "healthy"}, true
 }
 
 func (m *mockCache) GetMetrics() interface{} {
@@ -27,9 +54,19 @@ func (m *mockCache) GetMetrics() interface{} {
 func (m *mockCache) InvalidateFlag(flagKey string) error {
 	m.InvalidateCalled = true
 	m.LastInvalidatedKey = flagKey
+	m.InvalidatedKeys = append(m.InvalidatedKeys, flagKey)
 	return m.invalidateErr
 }
 
+func (m *mockCache) RefreshFlagKeys(keys []string, ids map[string][]int64) error {
+	if m.refreshHook != nil {
+		m.refreshHook()
+	}
+	m.RefreshedKeys = append(m.RefreshedKeys, keys)
+	m.RefreshedIDs = append(m.RefreshedIDs, ids)
+	return m.refreshErr
+}
+
 func (m *mockCache) InvalidateAll() error {
 	m.InvalidateAllCalled = true
 	return m.invalidateAllErr
@@ -40,6 +77,64 @@ func (m *mockCache) RefreshFlags() error {
 	return m.refreshErr
 }
 
+func (m *mockCache) ExplainFlag(flagKey string, req EvaluateRequest) (*FlagReport, error) {
+	m.ExplainedKeys = append(m.ExplainedKeys, flagKey)
+	m.LastEvaluateRequest = req
+	if err, ok := m.explainErrs[flagKey]; ok {
+		return &FlagReport{FlagKey: flagKey}, err
+	}
+	return &FlagReport{
+		FlagKey:     flagKey,
+		Explanation: map[string]string{"entity": req.EntityID},
+		Analysis:    map[string]string{"strategy": "local"},
+	}, nil
+}
+
+func (m *mockCache) Sources() []string {
+	return m.SourceNames
+}
+
+func (m *mockCache) RefreshSource(name string) error {
+	m.RefreshedSource = name
+	return m.refreshErr
+}
+
+func (m *mockCache) FlagKeys(source string) []string {
+	m.KeysSource = source
+	return m.Keys
+}
+
+func (m *mockCache) Lint() []LintIssue {
+	return m.Issues
+}
+
+func (m *mockCache) ListOverrides() []OverrideSpec {
+	return m.Overrides
+}
+
+func (m *mockCache) SetOverride(spec OverrideSpec) error {
+	if spec.FlagKey == "" || spec.VariantKey == "" {
+		return errors.New("flag_key and variant_key are required")
+	}
+	m.Overrides = append(m.Overrides, spec)
+	return nil
+}
+
+func (m *mockCache) RemoveOverride(spec OverrideSpec) bool {
+	for i, o := range m.Overrides {
+		if o.FlagKey == spec.FlagKey && o.EntityID == spec.EntityID {
+			m.Overrides = append(m.Overrides[:i], m.Overrides[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockCache) ClearOverrides() {
+	m.OverridesCleared = true
+	m.Overrides = nil
+}
+
 func TestAdminServer_Health(t *testing.T) {
 	srv := NewAdminServer(&mockCache{}, 0)
 
@@ -55,6 +150,46 @@ func TestAdminServer_Health(t *testing.T) {
 	assert.Equal(t, "healthy", resp["status"])
 }
 
+func TestAdminServer_Handler_PathPrefix(t *testing.T) {
+	mock := &mockCache{Metrics: map[string]int{"a": 1}}
+	handler := NewAdminServer(mock, 0).WithPathPrefix("/ops/").Handler()
+
+	for path, code := range map[string]int{
+		"/ops/health":      200,
+		"/ops/admin/stats": 200,
+		"/admin/stats":     404,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, code, w.Code, path)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/ops/admin/refresh", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.True(t, mock.RefreshCalled)
+}
+
+func TestAdminServer_HealthProbes(t *testing.T) {
+	mock := &mockCache{NotReady: true}
+	handler := NewAdminServer(mock, 0).WithAuth(nil, BearerTokens(nil)).Handler()
+
+	w := serveAdmin(handler, "GET", "/healthz/live", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "liveness does not depend on sync state")
+	assert.Empty(t, mock.HealthChecks)
+
+	w = serveAdmin(handler, "GET", "/healthz/ready", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"unhealthy"}`, w.Body.String())
+
+	w = serveAdmin(handler, "GET", "/health", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, []bool{false, true}, mock.HealthChecks, "only /health calls Flagr")
+
+	mock.NotReady = false
+	assert.Equal(t, http.StatusOK, serveAdmin(handler, "GET", "/healthz/ready", nil).Code)
+}
+
 func TestAdminServer_Stats(t *testing.T) {
 	mock := &mockCache{Metrics: map[string]int{"a": 1}}
 	srv := NewAdminServer(mock, 0)
@@ -108,3 +243,214 @@ func TestAdminServer_Refresh(t *testing.T) {
 
 	assert.True(t, mock.RefreshCalled)
 }
+
+func TestAdminServer_RefreshSource(t *testing.T) {
+	mock := &mockCache{SourceNames: []string{"default", "eu"}}
+	srv := NewAdminServer(mock, 0)
+
+	w := httptest.NewRecorder()
+	srv.handleRefresh(w, httptest.NewRequest("POST", "/admin/refresh?source=eu", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "eu", mock.RefreshedSource)
+	assert.False(t, mock.RefreshCalled, "only the named source is refreshed")
+	assert.JSONEq(t, `{"status":"ok","source":"eu"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	srv.handleRefresh(w, httptest.NewRequest("POST", "/admin/refresh?source=apac", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `Unknown source "apac"`)
+}
+
+func TestAdminServer_Evaluate_Source(t *testing.T) {
+	mock := &mockCache{SourceNames: []string{"default", "eu"}, Keys: []string{"eu/a"}}
+	srv := NewAdminServer(mock, 0)
+
+	body := bytes.NewBufferString(`{"entity_id":"user-1","all_flags":true,"source":"eu"}`)
+	w := httptest.NewRecorder()
+	srv.handleEvaluate(w, httptest.NewRequest("POST", "/admin/evaluate", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "eu", mock.KeysSource)
+	assert.Equal(t, "eu", mock.LastEvaluateRequest.Source)
+
+	body = bytes.NewBufferString(`{"flag_key":"a","source":"apac"}`)
+	w = httptest.NewRecorder()
+	srv.handleEvaluate(w, httptest.NewRequest("POST", "/admin/evaluate", body))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminServer_Evaluate(t *testing.T) {
+	mock := &mockCache{}
+	srv := NewAdminServer(mock, 0)
+
+	body := bytes.NewBufferString(`{"flag_key":"beta","entity_id":"user-1","entity_type":"user","attributes":{"country":"BR"}}`)
+	req := httptest.NewRequest("POST", "/admin/evaluate", body)
+	w := httptest.NewRecorder()
+
+	srv.handleEvaluate(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, []string{"beta"}, mock.ExplainedKeys)
+	assert.Equal(t, "user-1", mock.LastEvaluateRequest.EntityID)
+	assert.Equal(t, "BR", mock.LastEvaluateRequest.Attributes["country"])
+
+	var resp FlagReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "beta", resp.FlagKey)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, map[string]interface{}{"strategy": "local"}, resp.Analysis)
+}
+
+func TestAdminServer_Evaluate_AllFlags(t *testing.T) {
+	mock := &mockCache{
+		Keys:        []string{"a", "b"},
+		explainErrs: map[string]error{"b": errors.New("boom")},
+	}
+	srv := NewAdminServer(mock, 0)
+
+	body := bytes.NewBufferString(`{"entity_id":"user-1","all_flags":true}`)
+	req := httptest.NewRequest("POST", "/admin/evaluate", body)
+	w := httptest.NewRecorder()
+
+	srv.handleEvaluate(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp EvaluateAllResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "user-1", resp.EntityID)
+	assert.Len(t, resp.Flags, 2)
+	assert.Empty(t, resp.Flags[0].Error)
+	assert.Equal(t, "boom", resp.Flags[1].Error)
+}
+
+func TestAdminServer_Evaluate_BadRequests(t *testing.T) {
+	srv := NewAdminServer(&mockCache{}, 0)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		code   int
+	}{
+		{"wrong method", "GET", "", http.StatusMethodNotAllowed},
+		{"invalid json", "POST", "{", http.StatusBadRequest},
+		{"missing flag key", "POST", `{"entity_id":"u"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/evaluate", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			srv.handleEvaluate(w, req)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestAdminServer_Lint(t *testing.T) {
+	mock := &mockCache{Issues: []LintIssue{
+		{FlagKey: "a", Rule: "enabled-without-segments", Severity: "warning", Message: "no segments"},
+		{FlagKey: "b", SegmentID: 3, Rule: "distribution-sum", Severity: "error", Message: "50%"},
+		{FlagKey: "b", SegmentID: 4, Rule: "missing-distribution", Severity: "warning", Message: "none"},
+	}}
+	srv := NewAdminServer(mock, 0)
+
+	req := httptest.NewRequest("GET", "/admin/lint", nil)
+	w := httptest.NewRecorder()
+
+	srv.handleLint(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Count      int            `json:"count"`
+		Severities map[string]int `json:"severities"`
+		Issues     []LintIssue    `json:"issues"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Count)
+	assert.Equal(t, map[string]int{"error": 1, "warning": 2}, resp.Severities)
+	assert.Equal(t, mock.Issues, resp.Issues)
+
+	// No issues is an empty list
+	w = httptest.NewRecorder()
+	NewAdminServer(&mockCache{}, 0).handleLint(w, httptest.NewRequest("GET", "/admin/lint", nil))
+	assert.Contains(t, w.Body.String(), `"issues":[]`)
+
+	w = httptest.NewRecorder()
+	srv.handleLint(w, httptest.NewRequest("POST", "/admin/lint", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestAdminServer_Overrides(t *testing.T) {
+	cache := &mockCache{}
+	srv := NewAdminServer(cache, 0)
+
+	// Set
+	body := bytes.NewBufferString(`{"flag_key":"new-checkout","entity_id":"qa-1","variant_key":"enabled","attachment":{"enabled":true}}`)
+	req := httptest.NewRequest("POST", "/admin/overrides", body)
+	w := httptest.NewRecorder()
+	srv.handleOverrides(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, cache.Overrides, 1)
+	assert.JSONEq(t, "true", string(cache.Overrides[0].Attachment["enabled"]))
+
+	// List
+	req = httptest.NewRequest("GET", "/admin/overrides", nil)
+	w = httptest.NewRecorder()
+	srv.handleOverrides(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Count     int            `json:"count"`
+		Overrides []OverrideSpec `json:"overrides"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Count)
+	assert.Equal(t, "qa-1", list.Overrides[0].EntityID)
+
+	// Delete
+	req = httptest.NewRequest("DELETE", "/admin/overrides", bytes.NewBufferString(`{"flag_key":"new-checkout","entity_id":"qa-1"}`))
+	w = httptest.NewRecorder()
+	srv.handleOverrides(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, cache.Overrides)
+
+	// Delete again
+	req = httptest.NewRequest("DELETE", "/admin/overrides", bytes.NewBufferString(`{"flag_key":"new-checkout","entity_id":"qa-1"}`))
+	w = httptest.NewRecorder()
+	srv.handleOverrides(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Clear
+	req = httptest.NewRequest("DELETE", "/admin/overrides?all=true", nil)
+	w = httptest.NewRecorder()
+	srv.handleOverrides(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, cache.OverridesCleared)
+}
+
+func TestAdminServer_Overrides_BadRequests(t *testing.T) {
+	srv := NewAdminServer(&mockCache{}, 0)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		code   int
+	}{
+		{"wrong method", "PUT", "", http.StatusMethodNotAllowed},
+		{"invalid json", "POST", "{", http.StatusBadRequest},
+		{"missing variant", "POST", `{"flag_key":"f"}`, http.StatusBadRequest},
+		{"invalid delete", "DELETE", "{", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/overrides", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			srv.handleOverrides(w, req)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
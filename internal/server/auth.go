@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Role is the access level of an admin caller
+type Role int
+
+const (
+	// RoleRead allows the endpoints that only report state
+	RoleRead Role = iota
+
+	// RoleWrite also allows the endpoints that change the cache
+	RoleWrite
+)
+
+// Identity is the authenticated caller of an admin endpoint
+type Identity struct {
+	Name string
+	Role Role
+}
+
+// Authenticator identifies the caller of an admin request. It returns
+// false when the request carries no credentials it accepts.
+type Authenticator func(r *http.Request) (Identity, bool)
+
+// AuditEntry records a mutating admin call
+type AuditEntry struct {
+	Time   time.Time
+	Caller Identity
+	Method string
+	Path   string
+	Query  string
+	Status int
+}
+
+// AuditLogger receives an entry for every mutating admin call
+type AuditLogger func(AuditEntry)
+
+// BearerTokens accepts requests with an "Authorization: Bearer <token>"
+// header holding one of the tokens
+func BearerTokens(tokens map[string]Identity) Authenticator {
+	return func(r *http.Request) (Identity, bool) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return Identity{}, false
+		}
+		for known, identity := range tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+				return identity, true
+			}
+		}
+		return Identity{}, false
+	}
+}
+
+// Signature headers of admin calls
+const (
+	// AdminSignatureHeader holds the hex HMAC-SHA256, optionally prefixed
+	// by "sha256="
+	AdminSignatureHeader = "X-Admin-Signature"
+
+	// AdminTimestampHeader holds the Unix time, in seconds, the request was
+	// signed at
+	AdminTimestampHeader = "X-Admin-Timestamp"
+)
+
+// maxSignedBody bounds the body read to check an admin signature
+const maxSignedBody = 1 << 20
+
+// SignedRequests accepts requests whose X-Admin-Signature header is the
+// HMAC-SHA256 of "<timestamp>.<method>.<request URI>.<body>", with the
+// timestamp of X-Admin-Timestamp. Signatures outside
+// DefaultSignatureTolerance or already used are rejected.
+func SignedRequests(secret string, identity Identity) Authenticator {
+	verifier := &signatureVerifier{
+		secrets:   []string{secret},
+		tolerance: DefaultSignatureTolerance,
+	}
+	return func(r *http.Request) (Identity, bool) {
+		signature := r.Header.Get(AdminSignatureHeader)
+		if signature == "" {
+			return Identity{}, false
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxSignedBody))
+		if err != nil {
+			return Identity{}, false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		timestamp := r.Header.Get(AdminTimestampHeader)
+		if verifier.verify(signature, timestamp, signedRequest(r, body)) != nil {
+			return Identity{}, false
+		}
+		return identity, true
+	}
+}
+
+// signedRequest is what an admin signature covers after the timestamp. The
+// request URI is taken as sent, so it still matches when the handler is
+// mounted under a stripped prefix.
+func signedRequest(r *http.Request, body []byte) []byte {
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	payload := make([]byte, 0, len(r.Method)+len(uri)+2+len(body))
+	payload = append(payload, r.Method...)
+	payload = append(payload, '.')
+	payload = append(payload, uri...)
+	payload = append(payload, '.')
+	return append(payload, body...)
+}
+
+// ClientCertificates accepts requests over TLS with a verified client
+// certificate whose subject common name is one of the identities. The
+// server must request and verify client certificates (mTLS).
+func ClientCertificates(identities map[string]Identity) Authenticator {
+	return func(r *http.Request) (Identity, bool) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return Identity{}, false
+		}
+		identity, ok := identities[r.TLS.VerifiedChains[0][0].Subject.CommonName]
+		return identity, ok
+	}
+}
+
+// authorize wraps an admin endpoint requiring role. GET requests only need
+// RoleRead. Without authenticators every request is allowed. Mutating calls
+// are audited with the caller and response status.
+func (a *AdminServer) authorize(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		required := role
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			required = RoleRead
+		}
+
+		caller, ok := a.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if caller.Role < required {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if required < RoleWrite || a.audit == nil {
+			next(w, r)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		a.audit(AuditEntry{
+			Time:   time.Now(),
+			Caller: caller,
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.RawQuery,
+			Status: rec.status,
+		})
+	}
+}
+
+// authenticate returns the identity from the first authenticator accepting
+// the request. Without authenticators the caller is anonymous with write
+// access.
+func (a *AdminServer) authenticate(r *http.Request) (Identity, bool) {
+	if len(a.auth) == 0 {
+		return Identity{Name: "anonymous", Role: RoleWrite}, true
+	}
+	for _, auth := range a.auth {
+		if identity, ok := auth(r); ok {
+			return identity, true
+		}
+	}
+	return Identity{}, false
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
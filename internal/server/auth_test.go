@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveAdmin(handler http.Handler, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestAdminServer_Auth_Roles(t *testing.T) {
+	mock := &mockCache{Metrics: map[string]int{"a": 1}, SourceNames: []string{"default", "eu"}}
+	var audit []AuditEntry
+	handler := NewAdminServer(mock, 0).WithAuth(
+		func(e AuditEntry) { audit = append(audit, e) },
+		BearerTokens(map[string]Identity{
+			"ro-token": {Name: "dashboard", Role: RoleRead},
+			"rw-token": {Name: "oncall", Role: RoleWrite},
+		}),
+	).Handler()
+
+	// Health stays public for probes
+	assert.Equal(t, http.StatusOK, serveAdmin(handler, "GET", "/health", nil).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(handler, "GET", "/admin/stats", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(handler, "GET", "/admin/stats", bearer("wrong")).Code)
+	assert.Equal(t, http.StatusOK, serveAdmin(handler, "GET", "/admin/stats", bearer("ro-token")).Code)
+	assert.Equal(t, http.StatusOK, serveAdmin(handler, "GET", "/admin/overrides", bearer("ro-token")).Code)
+
+	assert.Equal(t, http.StatusForbidden, serveAdmin(handler, "POST", "/admin/invalidate-all", bearer("ro-token")).Code)
+	assert.False(t, mock.InvalidateAllCalled)
+	assert.Empty(t, audit, "only allowed mutating calls are audited")
+
+	assert.Equal(t, http.StatusOK, serveAdmin(handler, "POST", "/admin/refresh?source=eu", bearer("rw-token")).Code)
+	if assert.Len(t, audit, 1) {
+		assert.Equal(t, "oncall", audit[0].Caller.Name)
+		assert.Equal(t, "POST", audit[0].Method)
+		assert.Equal(t, "/admin/refresh", audit[0].Path)
+		assert.Equal(t, "source=eu", audit[0].Query)
+		assert.Equal(t, http.StatusOK, audit[0].Status)
+		assert.False(t, audit[0].Time.IsZero())
+	}
+}
+
+func TestAdminServer_Auth_Disabled(t *testing.T) {
+	mock := &mockCache{}
+	handler := NewAdminServer(mock, 0).Handler()
+
+	assert.Equal(t, http.StatusOK, serveAdmin(handler, "POST", "/admin/invalidate-all", nil).Code)
+	assert.True(t, mock.InvalidateAllCalled)
+}
+
+// signAdmin sets the signature headers of an admin request
+func signAdmin(req *http.Request, secret string, body []byte, at time.Time) {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(AdminTimestampHeader, ts)
+	req.Header.Set(AdminSignatureHeader, sign(secret, []byte(ts+"."+req.Method+"."+req.RequestURI+"."+string(body))))
+}
+
+func TestSignedRequests(t *testing.T) {
+	auth := SignedRequests("s3cret", Identity{Name: "deployer", Role: RoleWrite})
+	body := []byte(`{"flag_key":"a"}`)
+	newReq := func(method, target string, body []byte) *http.Request {
+		return httptest.NewRequest(method, target, bytes.NewReader(body))
+	}
+
+	req := newReq("POST", "/admin/invalidate", body)
+	signAdmin(req, "s3cret", body, time.Now())
+	identity, ok := auth(req)
+	assert.True(t, ok)
+	assert.Equal(t, "deployer", identity.Name)
+
+	// The body is still readable by the endpoint
+	var buf bytes.Buffer
+	buf.ReadFrom(req.Body)
+	assert.Equal(t, body, buf.Bytes())
+
+	// A signature is accepted once
+	replay := newReq("POST", "/admin/invalidate", body)
+	replay.Header = req.Header.Clone()
+	_, ok = auth(replay)
+	assert.False(t, ok, "replayed signature")
+
+	tests := []struct {
+		name string
+		sign func(req *http.Request)
+	}{
+		{"wrong secret", func(req *http.Request) { signAdmin(req, "other", body, time.Now()) }},
+		{"no signature", func(req *http.Request) {}},
+		{"stale timestamp", func(req *http.Request) { signAdmin(req, "s3cret", body, time.Now().Add(-time.Hour)) }},
+		{"body alone", func(req *http.Request) { req.Header.Set(AdminSignatureHeader, sign("s3cret", body)) }},
+		{"signed for another endpoint", func(req *http.Request) {
+			other := newReq("POST", "/admin/invalidate-all", body)
+			signAdmin(other, "s3cret", body, time.Now())
+			req.Header = other.Header
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newReq("POST", "/admin/invalidate", body)
+			tt.sign(req)
+			_, ok := auth(req)
+			assert.False(t, ok)
+		})
+	}
+
+	// Bodies past the limit are not read into memory
+	large := bytes.Repeat([]byte("a"), maxSignedBody+1)
+	req = newReq("POST", "/admin/invalidate", large)
+	signAdmin(req, "s3cret", large, time.Now())
+	_, ok = auth(req)
+	assert.False(t, ok)
+}
+
+func TestClientCertificates(t *testing.T) {
+	auth := ClientCertificates(map[string]Identity{"ops-bot": {Name: "ops-bot", Role: RoleWrite}})
+
+	withCert := func(cn string) *http.Request {
+		req := httptest.NewRequest("POST", "/admin/refresh", nil)
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		return req
+	}
+
+	identity, ok := auth(withCert("ops-bot"))
+	assert.True(t, ok)
+	assert.Equal(t, RoleWrite, identity.Role)
+
+	_, ok = auth(withCert("intruder"))
+	assert.False(t, ok)
+
+	_, ok = auth(httptest.NewRequest("POST", "/admin/refresh", nil))
+	assert.False(t, ok, "plain HTTP carries no certificate")
+}
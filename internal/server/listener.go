@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// readHeaderTimeout bounds how long a client may take to send the request
+// headers, so slow clients can't hold connections open
+const readHeaderTimeout = 10 * time.Second
+
+// Listener is an HTTP server bound synchronously and served in background
+type Listener struct {
+	server   *http.Server
+	listener net.Listener
+	done     chan error
+}
+
+// Listen binds the port (0 picks a free one) and serves handler in
+// background, over TLS when tlsConfig is set. Bind failures, such as a port
+// already in use, are returned.
+func Listen(ctx context.Context, port int, handler http.Handler, tlsConfig *tls.Config) (*Listener, error) {
+	ln, err := new(net.ListenConfig).Listen(ctx, "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	l := &Listener{
+		server:   &http.Server{Handler: handler, ReadHeaderTimeout: readHeaderTimeout},
+		listener: ln,
+		done:     make(chan error, 1),
+	}
+	go func() {
+		l.done <- l.server.Serve(ln)
+	}()
+
+	return l, nil
+}
+
+// Addr returns the address the server listens on
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests
+// until ctx is done, then closes the remaining connections
+func (l *Listener) Shutdown(ctx context.Context) error {
+	err := l.server.Shutdown(ctx)
+	if err != nil {
+		l.server.Close()
+	}
+
+	if serveErr := <-l.done; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
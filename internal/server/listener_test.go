@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_PortConflict(t *testing.T) {
+	l, err := Listen(context.Background(), 0, http.NotFoundHandler(), nil)
+	require.NoError(t, err)
+	defer l.Shutdown(context.Background())
+
+	_, err = Listen(context.Background(), l.Addr().(*net.TCPAddr).Port, http.NotFoundHandler(), nil)
+	assert.Error(t, err)
+}
+
+func TestListen_ReadHeaderTimeout(t *testing.T) {
+	l, err := Listen(context.Background(), 0, http.NotFoundHandler(), nil)
+	require.NoError(t, err)
+	defer l.Shutdown(context.Background())
+
+	assert.Equal(t, readHeaderTimeout, l.server.ReadHeaderTimeout)
+}
+
+func TestListener_ShutdownDrainsRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	l, err := Listen(context.Background(), 0, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	}), nil)
+	require.NoError(t, err)
+
+	url := "http://" + l.Addr().String()
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- l.Shutdown(context.Background()) }()
+
+	// New connections are refused while the request drains
+	require.Eventually(t, func() bool {
+		_, err := net.Dial("tcp", l.Addr().String())
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	assert.Equal(t, "done", <-body)
+	assert.NoError(t, <-shutdown)
+}
+
+func TestListener_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	l, err := Listen(context.Background(), 0, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}), nil)
+	require.NoError(t, err)
+
+	go http.Get("http://" + l.Addr().String())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Shutdown(ctx), context.DeadlineExceeded)
+}
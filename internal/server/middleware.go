@@ -12,14 +12,19 @@ const (
 	contextKeyCache   contextKey = "vexilla_cache"
 )
 
+// ContextBuilder builds the evaluation context of a request
+type ContextBuilder func(r *http.Request) interface{}
+
 // Middleware provides HTTP middleware for flag injection
 type Middleware struct {
 	cache CacheInterface
+	build ContextBuilder
 }
 
-// NewMiddleware creates new middleware
-func NewMiddleware(cache CacheInterface) *Middleware {
-	return &Middleware{cache: cache}
+// NewMiddleware creates new middleware that stores the context built for
+// every request in the request context
+func NewMiddleware(cache CacheInterface, build ContextBuilder) *Middleware {
+	return &Middleware{cache: cache, build: build}
 }
 
 // Handler wraps an HTTP handler with flag evaluation context
@@ -33,40 +38,21 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 func (m *Middleware) buildContext(r *http.Request) context.Context {
 	ctx := r.Context()
 
-	// Extract user ID from header or cookie
-	userID := r.Header.Get("X-User-ID")
-	if userID == "" {
-		if cookie, err := r.Cookie("user_id"); err == nil {
-			userID = cookie.Value
-		}
-	}
-
-	// Build evaluation context
-	evalCtx := map[string]interface{}{
-		"user_id":    userID,
-		"ip":         r.RemoteAddr,
-		"user_agent": r.UserAgent(),
-		"path":       r.URL.Path,
-		"method":     r.Method,
-	}
-
-	// Add headers as attributes
-	for key, values := range r.Header {
-		if len(values) > 0 {
-			evalCtx["header_"+key] = values[0]
-		}
-	}
-
-	ctx = context.WithValue(ctx, contextKeyEvalCtx, evalCtx)
+	ctx = WithEvalContext(ctx, m.build(r))
 	ctx = context.WithValue(ctx, contextKeyCache, m.cache)
 
 	return ctx
 }
 
+// WithEvalContext stores an evaluation context in a context
+func WithEvalContext(ctx context.Context, evalCtx interface{}) context.Context {
+	return context.WithValue(ctx, contextKeyEvalCtx, evalCtx)
+}
+
 // GetEvalContext extracts evaluation context from request context
-func GetEvalContext(ctx context.Context) (map[string]interface{}, bool) {
-	evalCtx, ok := ctx.Value(contextKeyEvalCtx).(map[string]interface{})
-	return evalCtx, ok
+func GetEvalContext(ctx context.Context) (interface{}, bool) {
+	evalCtx := ctx.Value(contextKeyEvalCtx)
+	return evalCtx, evalCtx != nil
 }
 
 // GetCache extracts cache from request context
@@ -10,7 +10,9 @@ import (
 
 func TestMiddleware_Handler(t *testing.T) {
 	mock := &mockCache{}
-	mw := NewMiddleware(mock)
+	mw := NewMiddleware(mock, func(r *http.Request) interface{} {
+		return map[string]interface{}{"method": r.Method, "path": r.URL.Path}
+	})
 
 	called := false
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -18,8 +20,7 @@ func TestMiddleware_Handler(t *testing.T) {
 
 		eval, ok := GetEvalContext(r.Context())
 		assert.True(t, ok)
-		assert.NotEmpty(t, eval["method"])
-		assert.NotEmpty(t, eval["path"])
+		assert.Equal(t, map[string]interface{}{"method": "GET", "path": "/test"}, eval)
 
 		cache, ok := GetCache(r.Context())
 		assert.True(t, ok)
@@ -32,3 +33,10 @@ func TestMiddleware_Handler(t *testing.T) {
 	mw.Handler(next).ServeHTTP(w, req)
 	assert.True(t, called)
 }
+
+func TestGetEvalContext_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+
+	_, ok := GetEvalContext(req.Context())
+	assert.False(t, ok)
+}
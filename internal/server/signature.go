@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signature headers of webhook calls
+const (
+	// WebhookSignatureHeader holds the hex HMAC-SHA256, optionally prefixed
+	// by "sha256="
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	// WebhookTimestampHeader holds the Unix time, in seconds, the request
+	// was signed at. The signature then covers "<timestamp>.<body>".
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+)
+
+// DefaultSignatureTolerance is how far the timestamp of a signed request
+// may be from the server clock
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// DefaultWebhookTolerance is how far the webhook timestamp may be from the
+// server clock
+const DefaultWebhookTolerance = DefaultSignatureTolerance
+
+var (
+	errMissingSignature = errors.New("missing signature")
+	errInvalidSignature = errors.New("invalid signature")
+	errMissingTimestamp = errors.New("missing timestamp")
+	errInvalidTimestamp = errors.New("invalid timestamp")
+	errStaleTimestamp   = errors.New("timestamp outside tolerance")
+	errReplayed         = errors.New("replayed request")
+)
+
+// signatureVerifier checks HMAC-SHA256 signatures made with one of its
+// secrets, rejecting stale and replayed ones
+type signatureVerifier struct {
+	secrets   []string
+	tolerance time.Duration
+	legacy    bool
+	nonces    nonceCache
+}
+
+// verify checks signature against every secret. A timestamped signature
+// covers "<timestamp>.<payload>", must be within the tolerance and is
+// accepted once; a signature of the payload alone is only accepted with
+// legacy signatures.
+func (v *signatureVerifier) verify(signature, timestamp string, payload []byte) error {
+	if signature == "" {
+		return errMissingSignature
+	}
+
+	if timestamp == "" {
+		if !v.legacy {
+			return errMissingTimestamp
+		}
+		if !v.anySecret(signature, payload) {
+			return errInvalidSignature
+		}
+		return nil
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidTimestamp
+	}
+	signedAt := time.Unix(unix, 0)
+	if skew := time.Since(signedAt); skew > v.tolerance || skew < -v.tolerance {
+		return errStaleTimestamp
+	}
+
+	signed := make([]byte, 0, len(timestamp)+1+len(payload))
+	signed = append(signed, timestamp...)
+	signed = append(signed, '.')
+	signed = append(signed, payload...)
+	if !v.anySecret(signature, signed) {
+		return errInvalidSignature
+	}
+
+	// The signature covers the timestamp, so it identifies the request;
+	// it is remembered until the timestamp leaves the tolerance window
+	if !v.nonces.add(normalizeSignature(signature), signedAt.Add(v.tolerance)) {
+		return errReplayed
+	}
+	return nil
+}
+
+// anySecret reports whether signature matches payload with one of the
+// secrets, so senders can move to a new secret while the old one is active
+func (v *signatureVerifier) anySecret(signature string, payload []byte) bool {
+	for _, secret := range v.secrets {
+		if verifyHMAC(secret, signature, payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyHMAC reports whether signature is the hex HMAC-SHA256 of body,
+// with or without a "sha256=" prefix
+func verifyHMAC(secret, signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(normalizeSignature(signature)), []byte(expectedSignature))
+}
+
+// normalizeSignature strips the "sha256=" prefix and lowercases the digest
+func normalizeSignature(signature string) string {
+	signature = strings.TrimSpace(signature)
+	if prefix, digest, ok := strings.Cut(signature, "="); ok && strings.EqualFold(prefix, "sha256") {
+		signature = digest
+	}
+	return strings.ToLower(signature)
+}
+
+// nonceCache remembers the accepted signatures until they expire
+type nonceCache struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	nextPrune time.Time
+}
+
+// add records nonce until expires, returning false if it was already seen
+func (c *nonceCache) add(nonce string, expires time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.seen == nil {
+		c.seen = make(map[string]time.Time)
+	}
+	if now.After(c.nextPrune) {
+		for n, exp := range c.seen {
+			if now.After(exp) {
+				delete(c.seen, n)
+			}
+		}
+		c.nextPrune = now.Add(time.Minute)
+	}
+
+	if exp, ok := c.seen[nonce]; ok && !now.After(exp) {
+		return false
+	}
+	c.seen[nonce] = expires
+	return true
+}
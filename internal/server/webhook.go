@@ -1,49 +1,167 @@
 package server
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
-// WebhookServer handles webhooks from Flagr
+// Defaults of the webhook event queue
+const (
+	DefaultWebhookQueueSize = 100
+	DefaultWebhookDebounce  = 200 * time.Millisecond
+)
+
+// WebhookServer handles webhooks from Flagr. Events are acknowledged with
+// 202 and applied in background: the events of a burst are coalesced and
+// only the affected flags are fetched.
 type WebhookServer struct {
 	cache  CacheInterface
 	port   int
-	secret string
+	prefix string
+
+	// Signature verification; no secrets disables it
+	verifier signatureVerifier
+
+	queueSize int
+	debounce  time.Duration
+
+	listener *Listener
+
+	// Event queue, started by the first event
+	startOnce sync.Once
+	mu        sync.RWMutex // guards closed and sends on events
+	closed    bool
+	events    chan WebhookPayload
+	done      chan struct{}
 }
 
 // WebhookPayload represents the webhook payload from Flagr
 type WebhookPayload struct {
 	Event     string   `json:"event"`
 	FlagKeys  []string `json:"flag_keys"`
+	FlagIDs   []int64  `json:"flag_ids,omitempty"`
 	Timestamp string   `json:"timestamp"`
+
+	// Source names the flag source of FlagIDs (the default source when
+	// empty); keys are routed by their prefix
+	Source string `json:"source,omitempty"`
 }
 
-// NewWebhookServer creates a new webhook server
+// NewWebhookServer creates a new webhook server. With a secret, requests
+// must be signed (see signatureVerifier.verify).
 func NewWebhookServer(cache CacheInterface, port int, secret string) *WebhookServer {
-	return &WebhookServer{
-		cache:  cache,
-		port:   port,
-		secret: secret,
+	w := &WebhookServer{
+		cache:     cache,
+		port:      port,
+		verifier:  signatureVerifier{tolerance: DefaultWebhookTolerance},
+		queueSize: DefaultWebhookQueueSize,
+		debounce:  DefaultWebhookDebounce,
+	}
+	return w.WithSecrets(secret)
+}
+
+// WithSecrets adds secrets accepted besides the current one, so senders
+// can be moved to a new secret one at a time
+func (w *WebhookServer) WithSecrets(secrets ...string) *WebhookServer {
+	for _, secret := range secrets {
+		if secret != "" {
+			w.verifier.secrets = append(w.verifier.secrets, secret)
+		}
 	}
+	return w
 }
 
-// Start starts the webhook HTTP server
-func (w *WebhookServer) Start() error {
+// WithReplayProtection sets how far X-Webhook-Timestamp may be from the
+// server clock (zero keeps the default) and whether signatures of the body
+// alone, without timestamp nor replay protection, are still accepted
+func (w *WebhookServer) WithReplayProtection(tolerance time.Duration, legacy bool) *WebhookServer {
+	if tolerance > 0 {
+		w.verifier.tolerance = tolerance
+	}
+	w.verifier.legacy = legacy
+	return w
+}
+
+// WithQueue bounds the events waiting while a burst is applied (further
+// events are rejected with 503) and sets how long the first event of a
+// burst waits for others to coalesce with. Zero keeps a default.
+func (w *WebhookServer) WithQueue(size int, debounce time.Duration) *WebhookServer {
+	if size > 0 {
+		w.queueSize = size
+	}
+	if debounce > 0 {
+		w.debounce = debounce
+	}
+	return w
+}
+
+// WithPathPrefix mounts the endpoint under prefix (e.g. "/flagr" serves
+// /flagr/webhook)
+func (w *WebhookServer) WithPathPrefix(prefix string) *WebhookServer {
+	w.prefix = strings.TrimSuffix(prefix, "/")
+	return w
+}
+
+// Handler returns the webhook HTTP handler, to serve standalone or mount
+// on an existing router
+func (w *WebhookServer) Handler() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/webhook", w.handleWebhook)
+	mux.HandleFunc(w.prefix+"/webhook", w.handleWebhook)
+	return mux
+}
 
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", w.port),
-		Handler: mux,
+// Start binds the webhook port and serves in background
+func (w *WebhookServer) Start(ctx context.Context) error {
+	listener, err := Listen(ctx, w.port, w.Handler(), nil)
+	if err != nil {
+		return err
 	}
+	w.listener = listener
+	return nil
+}
 
-	return server.ListenAndServe()
+// Addr returns the address the server listens on, or nil before Start
+func (w *WebhookServer) Addr() net.Addr {
+	if w.listener == nil {
+		return nil
+	}
+	return w.listener.Addr()
+}
+
+// Shutdown drains in-flight requests until ctx is done, stops the server
+// and applies the queued events
+func (w *WebhookServer) Shutdown(ctx context.Context) error {
+	var err error
+	if w.listener != nil {
+		err = w.listener.Shutdown(ctx)
+	}
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		if w.events != nil {
+			close(w.events)
+		}
+	}
+	done := w.done
+	w.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+	}
+	return err
 }
 
 func (w *WebhookServer) handleWebhook(rw http.ResponseWriter, r *http.Request) {
@@ -59,10 +177,12 @@ func (w *WebhookServer) handleWebhook(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify signature if secret is configured
-	if w.secret != "" {
-		if !w.verifySignature(r, body) {
-			http.Error(rw, "Invalid signature", http.StatusUnauthorized)
+	// Verify signature if secrets are configured
+	if len(w.verifier.secrets) > 0 {
+		signature := r.Header.Get(WebhookSignatureHeader)
+		timestamp := r.Header.Get(WebhookTimestampHeader)
+		if err := w.verifier.verify(signature, timestamp, body); err != nil {
+			http.Error(rw, "Invalid signature: "+err.Error(), http.StatusUnauthorized)
 			return
 		}
 	}
@@ -73,36 +193,17 @@ func (w *WebhookServer) handleWebhook(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.handleEvent(payload)
-
-	rw.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(rw).Encode(map[string]string{"status": "ok"})
-}
-
-func (w *WebhookServer) verifySignature(r *http.Request, body []byte) bool {
-	signature := r.Header.Get("X-Webhook-Signature")
-	if signature == "" {
-		return false
+	status := "accepted"
+	switch {
+	case !knownEvent(payload.Event):
+		status = "ignored"
+	case !w.enqueue(payload):
+		rw.Header().Set("Retry-After", "1")
+		http.Error(rw, "Event queue full", http.StatusServiceUnavailable)
+		return
 	}
 
-	mac := hmac.New(sha256.New, []byte(w.secret))
-	mac.Write(body)
-	expectedSignature := hex.EncodeToString(mac.Sum(nil))
-
-	return hmac.Equal([]byte(signature), []byte(expectedSignature))
-}
-
-func (w *WebhookServer) handleEvent(payload WebhookPayload) {
-	switch payload.Event {
-	case "flag.updated":
-		for _, key := range payload.FlagKeys {
-			w.cache.InvalidateFlag(key)
-		}
-		w.cache.RefreshFlags()
-
-	case "flag.deleted":
-		for _, key := range payload.FlagKeys {
-			w.cache.InvalidateFlag(key)
-		}
-	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(rw).Encode(map[string]string{"status": status})
 }
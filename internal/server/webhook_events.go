@@ -0,0 +1,157 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// Webhook event types; the last event of a flag in a burst wins
+const (
+	EventFlagCreated  = "flag.created"
+	EventFlagUpdated  = "flag.updated"
+	EventFlagEnabled  = "flag.enabled"
+	EventFlagDisabled = "flag.disabled"
+	EventFlagDeleted  = "flag.deleted"
+)
+
+// knownEvent reports whether the webhook handles the event type
+func knownEvent(event string) bool {
+	switch event {
+	case EventFlagCreated, EventFlagUpdated, EventFlagEnabled, EventFlagDisabled, EventFlagDeleted:
+		return true
+	}
+	return false
+}
+
+// enqueue queues an event, starting the worker on the first one. It
+// returns false when the queue is full or the server is shut down.
+func (w *WebhookServer) enqueue(payload WebhookPayload) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return false
+	}
+	w.startOnce.Do(func() {
+		w.events = make(chan WebhookPayload, w.queueSize)
+		w.done = make(chan struct{})
+		go w.run()
+	})
+
+	select {
+	case w.events <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// run applies the queued events until the queue is closed. The first
+// event of a burst waits for the debounce window, and every event received
+// meanwhile is applied with it.
+func (w *WebhookServer) run() {
+	defer close(w.done)
+
+	for payload := range w.events {
+		batch := newChanges()
+		batch.add(payload)
+
+		timer := time.NewTimer(w.debounce)
+	collect:
+		for {
+			select {
+			case payload, ok := <-w.events:
+				if !ok {
+					break collect
+				}
+				batch.add(payload)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		w.apply(batch)
+	}
+}
+
+// apply invalidates the deleted keys and fetches the other affected
+// flags. Errors are dropped: the periodic refresh catches up.
+func (w *WebhookServer) apply(batch *changes) {
+	var refresh []string
+	for _, key := range batch.sortedKeys() {
+		if batch.keys[key] {
+			refresh = append(refresh, key)
+		} else {
+			w.cache.InvalidateFlag(key)
+		}
+	}
+
+	if len(refresh) > 0 || len(batch.ids) > 0 {
+		w.cache.RefreshFlagKeys(refresh, batch.sortedIDs())
+	}
+}
+
+// changes coalesces the events of a burst
+type changes struct {
+	// keys maps a flag key to true when it must be fetched, false when it
+	// was deleted
+	keys map[string]bool
+
+	// ids are the flag IDs to fetch, by source. Deleted IDs are fetched
+	// too: flags Flagr no longer has are removed.
+	ids map[string]map[int64]struct{}
+}
+
+func newChanges() *changes {
+	return &changes{
+		keys: make(map[string]bool),
+		ids:  make(map[string]map[int64]struct{}),
+	}
+}
+
+// add merges an event
+func (c *changes) add(payload WebhookPayload) {
+	for _, key := range payload.FlagKeys {
+		c.keys[key] = payload.Event != EventFlagDeleted
+	}
+
+	if len(payload.FlagIDs) == 0 {
+		return
+	}
+	ids, ok := c.ids[payload.Source]
+	if !ok {
+		ids = make(map[int64]struct{})
+		c.ids[payload.Source] = ids
+	}
+	for _, id := range payload.FlagIDs {
+		ids[id] = struct{}{}
+	}
+}
+
+func (c *changes) sortedKeys() []string {
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedIDs returns the IDs by source, sorted
+func (c *changes) sortedIDs() map[string][]int64 {
+	if len(c.ids) == 0 {
+		return nil
+	}
+
+	out := make(map[string][]int64, len(c.ids))
+	for source, set := range c.ids {
+		ids := make([]int64, 0, len(set))
+		for id := range set {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		out[source] = ids
+	}
+	return out
+}
@@ -2,14 +2,18 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func sign(secret string, body []byte) string {
@@ -18,22 +22,101 @@ func sign(secret string, body []byte) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// signAt sets the timestamped signature headers of a webhook request
+func signAt(req *http.Request, secret string, body []byte, at time.Time) {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(WebhookTimestampHeader, ts)
+	req.Header.Set(WebhookSignatureHeader, sign(secret, append([]byte(ts+"."), body...)))
+}
+
+// post sends a webhook event and returns the response code
+func post(w *WebhookServer, body string) int {
+	req := httptest.NewRequest("POST", "/webhook", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	w.handleWebhook(rec, req)
+	return rec.Code
+}
+
+// drain applies the queued events
+func drain(t *testing.T, w *WebhookServer) {
+	t.Helper()
+	assert.NoError(t, w.Shutdown(context.Background()))
+}
+
 func TestWebhook_HandleWebhook_Update(t *testing.T) {
 	mock := &mockCache{}
 	secret := "abc123"
 	webhook := NewWebhookServer(mock, 0, secret)
 
 	body := []byte(`{"event":"flag.updated","flag_keys":["a","b"]}`)
-	sig := sign(secret, body)
-
 	req := httptest.NewRequest("POST", "/webhook", bytes.NewBuffer(body))
-	req.Header.Set("X-Webhook-Signature", sig)
+	signAt(req, secret, body, time.Now())
 
 	w := httptest.NewRecorder()
 	webhook.handleWebhook(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
 
-	assert.True(t, mock.InvalidateCalled)
-	assert.True(t, mock.RefreshCalled)
+	drain(t, webhook)
+	assert.Equal(t, [][]string{{"a", "b"}}, mock.RefreshedKeys, "only the changed flags are fetched")
+	assert.False(t, mock.RefreshCalled)
+	assert.False(t, mock.InvalidateCalled)
+}
+
+func TestWebhook_Coalesce(t *testing.T) {
+	mock := &mockCache{}
+	webhook := NewWebhookServer(mock, 0, "").WithQueue(0, time.Second)
+
+	assert.Equal(t, http.StatusAccepted, post(webhook, `{"event":"flag.created","flag_keys":["a"]}`))
+	assert.Equal(t, http.StatusAccepted, post(webhook, `{"event":"flag.updated","flag_keys":["a","b"]}`))
+	assert.Equal(t, http.StatusAccepted, post(webhook, `{"event":"flag.enabled","flag_ids":[7]}`))
+	assert.Equal(t, http.StatusAccepted, post(webhook, `{"event":"flag.disabled","flag_ids":[3],"source":"eu"}`))
+	assert.Equal(t, http.StatusAccepted, post(webhook, `{"event":"flag.deleted","flag_keys":["b","c"],"flag_ids":[8]}`))
+	assert.Equal(t, http.StatusAccepted, post(webhook, `{"event":"flag.audited","flag_keys":["z"]}`))
+
+	drain(t, webhook)
+	require.Len(t, mock.RefreshedKeys, 1, "a burst is applied at once")
+	assert.Equal(t, []string{"a"}, mock.RefreshedKeys[0])
+	assert.Equal(t, map[string][]int64{"": {7, 8}, "eu": {3}}, mock.RefreshedIDs[0])
+	assert.Equal(t, []string{"b", "c"}, mock.InvalidatedKeys, "the last event of a flag wins")
+}
+
+func TestWebhook_QueueFull(t *testing.T) {
+	applying := make(chan struct{})
+	release := make(chan struct{})
+	mock := &mockCache{refreshHook: func() {
+		applying <- struct{}{}
+		<-release
+	}}
+	webhook := NewWebhookServer(mock, 0, "").WithQueue(1, time.Millisecond)
+
+	// The worker is busy applying the first event, the queue holds one more
+	event := `{"event":"flag.updated","flag_keys":["a"]}`
+	assert.Equal(t, http.StatusAccepted, post(webhook, event))
+	<-applying
+	assert.Equal(t, http.StatusAccepted, post(webhook, event))
+	assert.Equal(t, http.StatusServiceUnavailable, post(webhook, event))
+
+	close(release)
+	<-applying
+	drain(t, webhook)
+	assert.Len(t, mock.RefreshedKeys, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, post(webhook, event), "rejected after shutdown")
+}
+
+func TestWebhook_Handler_PathPrefix(t *testing.T) {
+	mock := &mockCache{}
+	handler := NewWebhookServer(mock, 0, "abc123").WithPathPrefix("/flagr").Handler()
+
+	body := []byte(`{"event":"flag.deleted","flag_keys":["a"]}`)
+	req := httptest.NewRequest("POST", "/flagr/webhook", bytes.NewBuffer(body))
+	signAt(req, "abc123", body, time.Now())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/webhook", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestWebhook_InvalidSignature(t *testing.T) {
@@ -51,6 +134,114 @@ func TestWebhook_InvalidSignature(t *testing.T) {
 	assert.False(t, mock.InvalidateCalled)
 }
 
+func TestWebhook_Signatures(t *testing.T) {
+	body := []byte(`{"event":"flag.updated","flag_keys":["x"]}`)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		sign   func(req *http.Request)
+		legacy bool
+		want   int
+	}{
+		{
+			name: "timestamped",
+			sign: func(req *http.Request) { signAt(req, "current", body, now) },
+			want: http.StatusAccepted,
+		},
+		{
+			name: "previous secret during rotation",
+			sign: func(req *http.Request) { signAt(req, "previous", body, now) },
+			want: http.StatusAccepted,
+		},
+		{
+			name: "sha256 prefix",
+			sign: func(req *http.Request) {
+				signAt(req, "current", body, now)
+				req.Header.Set(WebhookSignatureHeader, "sha256="+req.Header.Get(WebhookSignatureHeader))
+			},
+			want: http.StatusAccepted,
+		},
+		{
+			name: "unknown secret",
+			sign: func(req *http.Request) { signAt(req, "other", body, now) },
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "timestamp outside tolerance",
+			sign: func(req *http.Request) { signAt(req, "current", body, now.Add(-2*time.Minute)) },
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "timestamp in the future",
+			sign: func(req *http.Request) { signAt(req, "current", body, now.Add(2*time.Minute)) },
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered timestamp",
+			sign: func(req *http.Request) {
+				signAt(req, "current", body, now)
+				req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(now.Unix()+1, 10))
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "body signature rejected",
+			sign: func(req *http.Request) { req.Header.Set(WebhookSignatureHeader, sign("current", body)) },
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:   "body signature with legacy signatures",
+			sign:   func(req *http.Request) { req.Header.Set(WebhookSignatureHeader, sign("previous", body)) },
+			legacy: true,
+			want:   http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := NewWebhookServer(&mockCache{}, 0, "current").
+				WithSecrets("previous").
+				WithReplayProtection(time.Minute, tt.legacy)
+			defer drain(t, webhook)
+
+			req := httptest.NewRequest("POST", "/webhook", bytes.NewBuffer(body))
+			tt.sign(req)
+			w := httptest.NewRecorder()
+			webhook.handleWebhook(w, req)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestWebhook_Replay(t *testing.T) {
+	webhook := NewWebhookServer(&mockCache{}, 0, "secret")
+	defer drain(t, webhook)
+
+	body := []byte(`{"event":"flag.updated","flag_keys":["x"]}`)
+	send := func(at time.Time, prefix string) int {
+		req := httptest.NewRequest("POST", "/webhook", bytes.NewBuffer(body))
+		signAt(req, "secret", body, at)
+		req.Header.Set(WebhookSignatureHeader, prefix+req.Header.Get(WebhookSignatureHeader))
+		w := httptest.NewRecorder()
+		webhook.handleWebhook(w, req)
+		return w.Code
+	}
+
+	now := time.Now()
+	assert.Equal(t, http.StatusAccepted, send(now, ""))
+	assert.Equal(t, http.StatusUnauthorized, send(now, ""), "a captured request is rejected")
+	assert.Equal(t, http.StatusUnauthorized, send(now, "sha256="), "whatever the signature format")
+	assert.Equal(t, http.StatusAccepted, send(now.Add(-time.Second), ""), "the same event sent again is signed anew")
+}
+
+func TestNonceCache_Expiry(t *testing.T) {
+	var c nonceCache
+	assert.True(t, c.add("a", time.Now().Add(-time.Second)))
+	assert.True(t, c.add("a", time.Now().Add(time.Minute)), "expired nonces are forgotten")
+	assert.False(t, c.add("a", time.Now().Add(time.Minute)))
+}
+
 func TestWebhook_Delete(t *testing.T) {
 	mock := &mockCache{}
 	webhook := NewWebhookServer(mock, 0, "")
@@ -61,8 +252,11 @@ func TestWebhook_Delete(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	webhook.handleWebhook(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
 
-	assert.True(t, mock.InvalidateCalled)
+	drain(t, webhook)
+	assert.Equal(t, []string{"x"}, mock.InvalidatedKeys)
+	assert.Empty(t, mock.RefreshedKeys)
 	assert.False(t, mock.RefreshCalled)
 }
 
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
@@ -15,7 +16,8 @@ import (
 
 var ErrNotFound = errors.New("flag not found")
 
-const snapshotFile = "snapshot.json"
+// SnapshotFile is the name of the snapshot file in a disk storage directory
+const SnapshotFile = "snapshot.json"
 
 type DiskStorage struct {
 	dir     string
@@ -58,7 +60,7 @@ func (d *DiskStorage) Get(ctx context.Context, key string) (*domain.Flag, error)
 		return nil, err
 	}
 
-	d.metrics.GetsKept++
+	atomic.AddUint64(&d.metrics.GetsKept, 1)
 	return &flag, nil
 }
 
@@ -80,14 +82,14 @@ func (d *DiskStorage) Set(ctx context.Context, key string, flag domain.Flag, ttl
 	file := d.filePath(key)
 	writeErr := os.WriteFile(file, data, 0644)
 	if writeErr != nil {
-		d.metrics.SetsDropped++
+		atomic.AddUint64(&d.metrics.SetsDropped, 1)
 		return writeErr
 	}
 
 	if _, err := os.Stat(file); os.IsNotExist(err) {
-		d.metrics.KeysAdded++
+		atomic.AddUint64(&d.metrics.KeysAdded, 1)
 	} else {
-		d.metrics.KeysUpdated++
+		atomic.AddUint64(&d.metrics.KeysUpdated, 1)
 	}
 
 	return nil
@@ -102,7 +104,7 @@ func (d *DiskStorage) Delete(ctx context.Context, key string) error {
 		return err
 	}
 
-	d.metrics.KeysDeleted++
+	atomic.AddUint64(&d.metrics.KeysDeleted, 1)
 	return nil
 }
 
@@ -150,18 +152,7 @@ func (d *DiskStorage) SaveSnapshot(ctx context.Context, snapshot map[string]doma
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	data, err := json.MarshalIndent(snapshot, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal snapshot: %w", err)
-	}
-
-	file := filepath.Join(d.dir, snapshotFile)
-	err = os.WriteFile(file, data, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write snapshot: %w", err)
-	}
-
-	return nil
+	return WriteSnapshotFile(filepath.Join(d.dir, SnapshotFile), snapshot)
 }
 
 func (d *DiskStorage) LoadSnapshot(ctx context.Context) (map[string]domain.Flag, error) {
@@ -174,9 +165,25 @@ func (d *DiskStorage) LoadSnapshot(ctx context.Context) (map[string]domain.Flag,
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	file := filepath.Join(d.dir, snapshotFile)
+	return ReadSnapshotFile(filepath.Join(d.dir, SnapshotFile))
+}
 
-	data, err := os.ReadFile(file)
+// SnapshotTime returns when the snapshot was last saved
+func (d *DiskStorage) SnapshotTime() (time.Time, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	info, err := os.Stat(filepath.Join(d.dir, SnapshotFile))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// ReadSnapshotFile reads a snapshot written by SaveSnapshot or
+// WriteSnapshotFile
+func ReadSnapshotFile(path string) (map[string]domain.Flag, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("snapshot not found: %w", err)
@@ -192,6 +199,20 @@ func (d *DiskStorage) LoadSnapshot(ctx context.Context) (map[string]domain.Flag,
 	return snapshot, nil
 }
 
-func (d *DiskStorage) Metrics() Metrics { return d.metrics }
+// WriteSnapshotFile writes a snapshot of flags by key as indented JSON
+func WriteSnapshotFile(path string, snapshot map[string]domain.Flag) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DiskStorage) Metrics() Metrics { return d.metrics.load() }
 
 func (d *DiskStorage) Close() error { return nil }
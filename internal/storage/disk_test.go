@@ -134,7 +134,7 @@ func TestDiskStorage_LoadSnapshot_InvalidJSON(t *testing.T) {
 	ds, err := NewDiskStorage(dir)
 	require.NoError(t, err)
 
-	file := filepath.Join(dir, snapshotFile)
+	file := filepath.Join(dir, SnapshotFile)
 	os.WriteFile(file, []byte("invalid-json"), 0644)
 
 	_, err = ds.LoadSnapshot(ctx)
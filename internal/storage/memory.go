@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
@@ -40,12 +41,12 @@ func (m *MemoryStorage) Get(ctx context.Context, key string) (*domain.Flag, erro
 
 	val, ok := m.cache.Get(key)
 	if !ok {
-		m.metrics.GetsDropped++
+		atomic.AddUint64(&m.metrics.GetsDropped, 1)
 		return nil, ErrNotFound
 	}
 
 	flag := val.(domain.Flag)
-	m.metrics.GetsKept++
+	atomic.AddUint64(&m.metrics.GetsKept, 1)
 	return &flag, nil
 }
 
@@ -56,17 +57,20 @@ func (m *MemoryStorage) Set(ctx context.Context, key string, flag domain.Flag, t
 	default:
 	}
 
-	if ttl == 0 {
+	switch {
+	case ttl == NoExpiration:
+		ttl = 0 // Ristretto never expires entries without TTL
+	case ttl == 0:
 		ttl = m.config.DefaultTTL
 	}
 
 	ok := m.cache.SetWithTTL(key, flag, 1, ttl)
 	if !ok {
-		m.metrics.SetsRejected++
+		atomic.AddUint64(&m.metrics.SetsRejected, 1)
 		return errors.New("cache rejected set")
 	}
 
-	m.metrics.KeysAdded++
+	atomic.AddUint64(&m.metrics.KeysAdded, 1)
 
 	// CRITICAL: Wait for Ristretto to process the write
 	// Ristretto is async by design, this ensures the key is actually stored
@@ -77,7 +81,7 @@ func (m *MemoryStorage) Set(ctx context.Context, key string, flag domain.Flag, t
 
 func (m *MemoryStorage) Delete(ctx context.Context, key string) error {
 	m.cache.Del(key)
-	m.metrics.KeysDeleted++
+	atomic.AddUint64(&m.metrics.KeysDeleted, 1)
 	return nil
 }
 
@@ -91,6 +95,6 @@ func (m *MemoryStorage) List(ctx context.Context) ([]string, error) {
 	return nil, errors.New("memory storage cannot list keys (not supported by ristretto)")
 }
 
-func (m *MemoryStorage) Metrics() Metrics { return m.metrics }
+func (m *MemoryStorage) Metrics() Metrics { return m.metrics.load() }
 
 func (m *MemoryStorage) Close() error { m.cache.Close(); return nil }
@@ -148,6 +148,23 @@ func TestMemoryStorage_DefaultTTL(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNotFound)
 }
 
+func TestMemoryStorage_NoExpiration(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DefaultTTL = 50 * time.Millisecond
+	s, err := NewMemoryStorage(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = s.Set(ctx, "kept", domain.Flag{Key: "kept"}, NoExpiration)
+	require.NoError(t, err)
+
+	// Outlives the default TTL
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = s.Get(ctx, "kept")
+	assert.NoError(t, err)
+}
+
 func TestMemoryStorage_List(t *testing.T) {
 	cfg := newTestConfig()
 	s, err := NewMemoryStorage(cfg)
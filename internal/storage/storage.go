@@ -3,17 +3,22 @@ package storage
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 )
 
+// NoExpiration keeps a flag until it is replaced or deleted
+const NoExpiration time.Duration = -1
+
 // Storage defines the interface for flag storage
 type Storage interface {
 	// Get retrieves a flag by key
 	Get(ctx context.Context, key string) (*domain.Flag, error)
 
-	// Set stores a flag with optional TTL
+	// Set stores a flag with optional TTL; zero uses the storage default,
+	// NoExpiration keeps it until it is replaced or deleted
 	Set(ctx context.Context, key string, flag domain.Flag, ttl time.Duration) error
 
 	// Delete removes a flag
@@ -57,6 +62,25 @@ type Metrics struct {
 	Size int64
 }
 
+// load returns a copy of the metrics, read atomically as storages update
+// the counters concurrently
+func (m *Metrics) load() Metrics {
+	return Metrics{
+		KeysAdded:    atomic.LoadUint64(&m.KeysAdded),
+		KeysUpdated:  atomic.LoadUint64(&m.KeysUpdated),
+		KeysEvicted:  atomic.LoadUint64(&m.KeysEvicted),
+		KeysDeleted:  atomic.LoadUint64(&m.KeysDeleted),
+		CostAdded:    atomic.LoadUint64(&m.CostAdded),
+		CostEvicted:  atomic.LoadUint64(&m.CostEvicted),
+		SetsDropped:  atomic.LoadUint64(&m.SetsDropped),
+		SetsRejected: atomic.LoadUint64(&m.SetsRejected),
+		GetsKept:     atomic.LoadUint64(&m.GetsKept),
+		GetsDropped:  atomic.LoadUint64(&m.GetsDropped),
+		HitRatio:     m.HitRatio,
+		Size:         m.Size,
+	}
+}
+
 // Config holds storage configuration
 type Config struct {
 	// Memory limits
@@ -0,0 +1,68 @@
+package vexilla
+
+import (
+	"context"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+)
+
+// LintIssue is a problem found in a cached flag by Client.Lint.
+type LintIssue struct {
+	// FlagKey is the key of the flag with the problem
+	FlagKey string `json:"flag_key"`
+
+	// SegmentID is the segment with the problem (0 for flag-level issues)
+	SegmentID int64 `json:"segment_id,omitempty"`
+
+	// Rule identifies the check, such as "shadowed-segment"
+	Rule string `json:"rule"`
+
+	// Severity is "error" (the flag does not behave as configured),
+	// "warning" (it probably does not behave as intended) or "info" (it
+	// works but could be simpler or cheaper to evaluate)
+	Severity string `json:"severity"`
+
+	Message string `json:"message"`
+}
+
+// Lint checks every cached flag and reports problems, sorted by flag key:
+//
+//   - distribution-sum: distributions of a segment do not add up to 100%
+//   - unknown-variant: a distribution references a variant that does not exist
+//   - missing-distribution: a segment serves no variant
+//   - shadowed-segment: an earlier segment catches every entity of a segment
+//   - unknown-property: a constraint uses a property services never send
+//     (see WithKnownProperties)
+//   - enabled-without-segments: an enabled flag serves no variant
+//   - unreachable-variant: no segment serves a variant
+//   - near-deterministic-split: a flag is evaluated by Flagr only because
+//     of a 99/1 split or a 99% rollout
+//
+// Example:
+//
+//	for _, issue := range client.Lint(ctx) {
+//	    log.Printf("%s %s: %s", issue.Severity, issue.FlagKey, issue.Message)
+//	}
+func (c *Client) Lint(ctx context.Context) []LintIssue {
+	return lintSources(ctx, c.sources, c.knownProperties)
+}
+
+// lintSources lints the cached flags of every source; flag keys carry the
+// prefix of their source
+func lintSources(ctx context.Context, sources []*source, knownProperties []string) []LintIssue {
+	linter := evaluator.NewLinter(knownProperties)
+
+	result := []LintIssue{}
+	for _, s := range sources {
+		for _, issue := range linter.Lint(s.cache.Flags(ctx)) {
+			result = append(result, LintIssue{
+				FlagKey:   s.prefix + issue.FlagKey,
+				SegmentID: issue.SegmentID,
+				Rule:      issue.Rule,
+				Severity:  string(issue.Severity),
+				Message:   issue.Message,
+			})
+		}
+	}
+	return result
+}
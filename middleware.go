@@ -0,0 +1,195 @@
+package vexilla
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/server"
+)
+
+// Extractor fills the evaluation context with data from an HTTP request.
+// HTTPMiddleware runs its extractors in order, each one receiving the
+// context returned by the previous one. Any function with this signature
+// can be used as a custom extractor.
+type Extractor func(r *http.Request, evalCtx Context) Context
+
+// DefaultExtractors are used when HTTPMiddleware is given no extractor: the
+// entity comes from the X-User-ID header or the user_id cookie, and the
+// request path, method, client IP and user agent become attributes.
+// Headers and cookies are never copied wholesale.
+func DefaultExtractors() []Extractor {
+	return []Extractor{
+		EntityFromHeader("X-User-ID"),
+		EntityFromCookie("user_id"),
+		RequestAttributes(),
+	}
+}
+
+// EntityFromHeader sets the entity ID from a request header, unless a
+// previous extractor already set it.
+func EntityFromHeader(header string) Extractor {
+	return func(r *http.Request, evalCtx Context) Context {
+		if evalCtx.EntityID == "" {
+			evalCtx.EntityID = r.Header.Get(header)
+		}
+		return evalCtx
+	}
+}
+
+// EntityFromCookie sets the entity ID from a cookie, unless a previous
+// extractor already set it.
+func EntityFromCookie(name string) Extractor {
+	return func(r *http.Request, evalCtx Context) Context {
+		if evalCtx.EntityID != "" {
+			return evalCtx
+		}
+		if cookie, err := r.Cookie(name); err == nil {
+			evalCtx.EntityID = cookie.Value
+		}
+		return evalCtx
+	}
+}
+
+// RequestAttributes adds the request path, method, client IP and user
+// agent as the "path", "method", "ip" and "user_agent" attributes.
+func RequestAttributes() Extractor {
+	return func(r *http.Request, evalCtx Context) Context {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+		return evalCtx.
+			WithAttribute("path", r.URL.Path).
+			WithAttribute("method", r.Method).
+			WithAttribute("ip", ip).
+			WithAttribute("user_agent", r.UserAgent())
+	}
+}
+
+// FromHeaders copies an allowlist of request headers into attributes,
+// mapping each header name to an attribute name. Missing headers are skipped.
+//
+// Example: vexilla.FromHeaders(map[string]string{"X-Country": "country"})
+func FromHeaders(headers map[string]string) Extractor {
+	return func(r *http.Request, evalCtx Context) Context {
+		for header, attribute := range headers {
+			if value := r.Header.Get(header); value != "" {
+				evalCtx = evalCtx.WithAttribute(attribute, value)
+			}
+		}
+		return evalCtx
+	}
+}
+
+// FromCookies copies cookies into attributes, mapping each cookie name to
+// an attribute name. Missing cookies are skipped.
+//
+// Example: vexilla.FromCookies(map[string]string{"plan": "tier"})
+func FromCookies(cookies map[string]string) Extractor {
+	return func(r *http.Request, evalCtx Context) Context {
+		for name, attribute := range cookies {
+			if cookie, err := r.Cookie(name); err == nil {
+				evalCtx = evalCtx.WithAttribute(attribute, cookie.Value)
+			}
+		}
+		return evalCtx
+	}
+}
+
+// FromJWTClaims reads the claims of the bearer token in the Authorization
+// header. The entityClaim (such as "sub") sets the entity ID unless a
+// previous extractor already set it, and claims maps claim names to
+// attribute names. Requests without a readable token are left unchanged.
+//
+// The token signature is NOT verified: use this extractor behind the
+// middleware that authenticates the request.
+//
+// Example: vexilla.FromJWTClaims("sub", map[string]string{"plan": "tier"})
+func FromJWTClaims(entityClaim string, claims map[string]string) Extractor {
+	return func(r *http.Request, evalCtx Context) Context {
+		payload, ok := jwtClaims(r)
+		if !ok {
+			return evalCtx
+		}
+
+		if id, ok := payload[entityClaim].(string); ok && evalCtx.EntityID == "" {
+			evalCtx.EntityID = id
+		}
+		for claim, attribute := range claims {
+			if value, ok := payload[claim]; ok {
+				evalCtx = evalCtx.WithAttribute(attribute, value)
+			}
+		}
+		return evalCtx
+	}
+}
+
+// jwtClaims decodes the payload of the request's bearer token
+func jwtClaims(r *http.Request) (map[string]any, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+
+	var claims map[string]any
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// extractContext builds the evaluation context of a request
+func extractContext(r *http.Request, extractors []Extractor) Context {
+	evalCtx := NewContext("")
+	for _, extract := range extractors {
+		evalCtx = extract(r, evalCtx)
+	}
+	return evalCtx
+}
+
+// ContextFromRequest returns the evaluation context that HTTPMiddleware
+// built for the request. Outside the middleware the context is built with
+// DefaultExtractors.
+func ContextFromRequest(r *http.Request) Context {
+	if evalCtx, ok := FromContext(r.Context()); ok {
+		return evalCtx
+	}
+	return extractContext(r, DefaultExtractors())
+}
+
+// NewContextWith returns a copy of ctx carrying the evaluation context, for
+// integrations that propagate it across calls (such as vexillagrpc).
+func NewContextWith(ctx context.Context, evalCtx Context) context.Context {
+	return server.WithEvalContext(ctx, evalCtx)
+}
+
+// FromContext returns the evaluation context carried by ctx, as stored by
+// HTTPMiddleware, NewContextWith or the vexillagrpc interceptors.
+func FromContext(ctx context.Context) (Context, bool) {
+	value, ok := server.GetEvalContext(ctx)
+	if !ok {
+		return Context{}, false
+	}
+	evalCtx, ok := value.(Context)
+	return evalCtx, ok
+}
+
+// BoolFromRequest evaluates a boolean flag for the evaluation context of
+// the request (see ContextFromRequest).
+func (c *Client) BoolFromRequest(r *http.Request, flagKey string) bool {
+	return c.Bool(r.Context(), flagKey, ContextFromRequest(r))
+}
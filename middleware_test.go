@@ -0,0 +1,125 @@
+package vexilla
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwt returns an unsigned token with the given payload
+func jwt(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestExtractors(t *testing.T) {
+	req := httptest.NewRequest("GET", "/checkout?beta=1", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Authorization", "Bearer "+jwt(`{"sub":"user-42","plan":"pro","age":30}`))
+	req.Header.Set("X-Country", "BR")
+	req.Header.Set("X-Secret", "hunter2")
+	req.AddCookie(&http.Cookie{Name: "user_id", Value: "cookie-user"})
+	req.AddCookie(&http.Cookie{Name: "ab", Value: "b"})
+
+	t.Run("defaults", func(t *testing.T) {
+		evalCtx := extractContext(req, DefaultExtractors())
+		assert.Equal(t, "cookie-user", evalCtx.EntityID)
+		assert.Equal(t, "user", evalCtx.EntityType)
+		assert.Equal(t, map[string]any{
+			"path":       "/checkout",
+			"method":     "GET",
+			"ip":         "10.0.0.1",
+			"user_agent": "test-agent",
+		}, evalCtx.Attributes, "headers and cookies are not copied")
+	})
+
+	t.Run("allowlists", func(t *testing.T) {
+		evalCtx := extractContext(req, []Extractor{
+			FromJWTClaims("sub", map[string]string{"plan": "tier", "age": "age", "missing": "missing"}),
+			EntityFromCookie("user_id"),
+			FromHeaders(map[string]string{"X-Country": "country", "X-Missing": "missing"}),
+			FromCookies(map[string]string{"ab": "ab_group"}),
+			func(r *http.Request, evalCtx Context) Context {
+				return evalCtx.WithAttribute("beta", r.URL.Query().Has("beta"))
+			},
+		})
+		assert.Equal(t, "user-42", evalCtx.EntityID, "the first extractor setting the entity wins")
+		assert.Equal(t, map[string]any{
+			"tier":     "pro",
+			"age":      float64(30),
+			"country":  "BR",
+			"ab_group": "b",
+			"beta":     true,
+		}, evalCtx.Attributes)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		for _, auth := range []string{"", "Basic abc", "Bearer not-a-jwt", "Bearer a.%%%.c"} {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Authorization", auth)
+			evalCtx := extractContext(r, []Extractor{FromJWTClaims("sub", nil)})
+			assert.Empty(t, evalCtx.EntityID, auth)
+		}
+	})
+}
+
+func TestClient_BoolFromRequest(t *testing.T) {
+	srv := flagrtest.NewServer(domain.Flag{
+		ID:      1,
+		Key:     "beta",
+		Enabled: true,
+		Segments: []domain.Segment{{
+			ID:             1,
+			Rank:           1,
+			RolloutPercent: 100,
+			Constraints:    []domain.Constraint{{ID: 1, Property: "country", Operator: "EQ", Value: "BR"}},
+			Distributions:  []domain.Distribution{{ID: 1, VariantID: 1, Percent: 100}},
+		}, {
+			ID:             2,
+			Rank:           2,
+			RolloutPercent: 100,
+			Distributions:  []domain.Distribution{{ID: 2, VariantID: 2, Percent: 100}},
+		}},
+		Variants: []domain.Variant{{ID: 1, Key: "enabled"}, {ID: 2, Key: "off"}},
+	})
+	defer srv.Close()
+
+	client, err := New(WithFlagrEndpoint(srv.URL), WithRefreshInterval(0))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	var enabled bool
+	var evalCtx Context
+	handler := client.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enabled = client.BoolFromRequest(r, "beta")
+		evalCtx = ContextFromRequest(r)
+	}), EntityFromHeader("X-User-ID"), FromHeaders(map[string]string{"X-Country": "country"}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	req.Header.Set("X-Country", "BR")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, enabled)
+	assert.Equal(t, "user-1", evalCtx.EntityID)
+	assert.Equal(t, map[string]any{"country": "BR"}, evalCtx.Attributes)
+
+	req.Header.Set("X-Country", "US")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, enabled)
+
+	// Outside the middleware the default extractors are used
+	req = httptest.NewRequest("GET", "/plain", nil)
+	req.Header.Set("X-User-ID", "user-2")
+	evalCtx = ContextFromRequest(req)
+	assert.Equal(t, "user-2", evalCtx.EntityID)
+	assert.Equal(t, "/plain", evalCtx.Attributes["path"])
+}
@@ -2,13 +2,19 @@ package vexilla
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/cache"
+	"github.com/OrlandoBitencourt/vexilla/internal/clienthooks"
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
 	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/override"
 	"github.com/OrlandoBitencourt/vexilla/internal/server"
 	"github.com/OrlandoBitencourt/vexilla/internal/storage"
 )
@@ -27,6 +33,9 @@ type clientConfig struct {
 	initialTimeout   time.Duration
 	fallbackStrategy string
 
+	maxStaleness time.Duration
+	stalePolicy  string
+
 	circuitThreshold int
 	circuitTimeout   time.Duration
 
@@ -35,6 +44,8 @@ type clientConfig struct {
 	requireServiceTag bool
 	additionalTags    []string
 	tagMatchMode      string
+	namespaces        []string
+	lazyNamespaces    []string
 
 	// Server options
 	webhookEnabled bool
@@ -42,6 +53,49 @@ type clientConfig struct {
 	webhookSecret  string
 	adminEnabled   bool
 	adminPort      int
+
+	webhookShutdownTimeout time.Duration
+	adminShutdownTimeout   time.Duration
+
+	webhookPathPrefix string
+	adminPathPrefix   string
+
+	webhookQueueSize int
+	webhookDebounce  time.Duration
+
+	webhookPreviousSecrets  []string
+	webhookTolerance        time.Duration
+	webhookLegacySignatures bool
+
+	adminAuth  []AdminAuthenticator
+	adminAudit func(AdminAuditEntry)
+	adminTLS   *tls.Config
+
+	// Readiness thresholds of Health
+	readiness readiness
+
+	// Local overrides
+	overrideFile         string
+	overridePollInterval time.Duration
+
+	// Flag file used instead of Flagr
+	flagFile   string
+	fileSource *flagr.FileSource
+
+	// Injected dependencies (see WithFlagrClient and WithStorage)
+	flagrClient flagr.Client
+	storage     storage.Storage
+
+	evaluationHook func(flagKey string, evalCtx Context, result *Result, err error)
+
+	// Receives errors of background work (see WithErrorHandler)
+	errorHandler func(error)
+
+	// Context attributes services send (see WithKnownProperties)
+	knownProperties []string
+
+	// Additional flag sources (see WithSource)
+	sources []SourceConfig
 }
 
 // WebhookConfig configura o servidor de webhook para invalidação externa
@@ -52,20 +106,87 @@ type WebhookConfig struct {
 	// Secret é o segredo compartilhado para validação HMAC-SHA256
 	// Se vazio, a validação de assinatura é desabilitada
 	Secret string
+
+	// PreviousSecrets são segredos ainda aceitos além de Secret, para
+	// rotacionar o segredo sem atualizar todos os remetentes ao mesmo tempo
+	PreviousSecrets []string
+
+	// Tolerance é a diferença máxima entre X-Webhook-Timestamp e o relógio
+	// do servidor (padrão: 5m). Requisições repetidas dentro da janela são
+	// rejeitadas.
+	Tolerance time.Duration
+
+	// LegacySignatures aceita também assinaturas só do corpo, sem
+	// timestamp e sem proteção contra replay, enquanto os remetentes são
+	// migrados
+	LegacySignatures bool
+
+	// PathPrefix monta o endpoint sob um prefixo (ex: "/flagr" atende
+	// POST /flagr/webhook), tanto no servidor próprio quanto em
+	// Client.WebhookHandler
+	PathPrefix string
+
+	// DisableServer não abre a porta: o endpoint fica disponível apenas
+	// via Client.WebhookHandler, para ser montado no router da aplicação
+	DisableServer bool
+
+	// QueueSize limita os eventos aguardando enquanto uma rajada é
+	// aplicada; além dele o webhook responde 503 (padrão: 100)
+	QueueSize int
+
+	// Debounce é quanto o primeiro evento de uma rajada aguarda para ser
+	// aplicado junto com os seguintes (padrão: 200ms)
+	Debounce time.Duration
+
+	// ShutdownTimeout é o tempo que o Stop aguarda as requisições em
+	// andamento terminarem (padrão: 5s)
+	ShutdownTimeout time.Duration
 }
 
 // AdminConfig configura o servidor de administração
 type AdminConfig struct {
 	// Port é a porta onde o admin server irá escutar
 	Port int
+
+	// PathPrefix monta os endpoints sob um prefixo (ex: "/ops" atende
+	// /ops/health e /ops/admin/stats), tanto no servidor próprio quanto em
+	// Client.AdminHandler
+	PathPrefix string
+
+	// DisableServer não abre a porta: os endpoints ficam disponíveis apenas
+	// via Client.AdminHandler, para serem montados no router da aplicação
+	DisableServer bool
+
+	// Auth exige autenticação em todos os endpoints exceto /health. Os
+	// autenticadores são testados em ordem (BearerTokens, SignedRequests,
+	// ClientCertificates ou próprios); sem nenhum, o acesso é livre.
+	// Chamadas GET exigem AdminRead, as que alteram o cache AdminWrite.
+	Auth []AdminAuthenticator
+
+	// Audit recebe uma entrada para cada chamada que altera o cache, com a
+	// identidade de quem chamou (padrão: log via slog)
+	Audit func(AdminAuditEntry)
+
+	// TLSConfig serve o admin server via TLS; com
+	// ClientAuth: tls.RequireAndVerifyClientCert habilita ClientCertificates
+	TLSConfig *tls.Config
+
+	// ShutdownTimeout é o tempo que o Stop aguarda as requisições em
+	// andamento terminarem (padrão: 5s)
+	ShutdownTimeout time.Duration
 }
 
 // toCacheOptions converts clientConfig to cache options.
 func (c *clientConfig) toCacheOptions() []cache.Option {
 	opts := []cache.Option{}
 
-	// Create Flagr client
-	if c.flagrEndpoint != "" {
+	// Create Flagr client (a flag file replaces the Flagr server)
+	if c.flagrClient != nil {
+		opts = append(opts, cache.WithFlagrClient(c.flagrClient))
+	} else if c.flagFile != "" {
+		c.fileSource = flagr.NewFileSource(c.flagFile)
+		opts = append(opts, cache.WithFlagrClient(c.fileSource))
+	} else if c.flagrEndpoint != "" {
 		flagrClient := flagr.NewHTTPClient(flagr.Config{
 			Endpoint:   c.flagrEndpoint,
 			APIKey:     c.flagrAPIKey,
@@ -76,8 +197,12 @@ func (c *clientConfig) toCacheOptions() []cache.Option {
 	}
 
 	// Create storage
-	memStorage, _ := storage.NewMemoryStorage(storage.DefaultConfig())
-	opts = append(opts, cache.WithStorage(memStorage))
+	if c.storage != nil {
+		opts = append(opts, cache.WithStorage(c.storage))
+	} else {
+		memStorage, _ := storage.NewMemoryStorage(storage.DefaultConfig())
+		opts = append(opts, cache.WithStorage(memStorage))
+	}
 
 	// Create evaluator
 	eval := evaluator.New()
@@ -96,10 +221,25 @@ func (c *clientConfig) toCacheOptions() []cache.Option {
 		opts = append(opts, cache.WithFallbackStrategy(c.fallbackStrategy))
 	}
 
+	if c.maxStaleness > 0 {
+		opts = append(opts, cache.WithMaxStaleness(c.maxStaleness, c.stalePolicy))
+	}
+
 	if c.circuitThreshold > 0 {
 		opts = append(opts, cache.WithCircuitBreaker(c.circuitThreshold, c.circuitTimeout))
 	}
 
+	if c.evaluationHook != nil {
+		hook := c.evaluationHook
+		opts = append(opts, cache.WithEvaluationHook(func(flagKey string, evalCtx domain.EvaluationContext, result *domain.EvaluationResult, err error) {
+			var r *Result
+			if result != nil {
+				r = toResult(result)
+			}
+			hook(flagKey, fromDomainContext(evalCtx), r, err)
+		}))
+	}
+
 	// Filtering options
 	if c.onlyEnabled {
 		opts = append(opts, cache.WithOnlyEnabled(true))
@@ -117,6 +257,10 @@ func (c *clientConfig) toCacheOptions() []cache.Option {
 		opts = append(opts, cache.WithAdditionalTags(c.additionalTags, matchMode))
 	}
 
+	if len(c.namespaces) > 0 || len(c.lazyNamespaces) > 0 {
+		opts = append(opts, cache.WithNamespaces(c.namespaces, c.lazyNamespaces))
+	}
+
 	return opts
 }
 
@@ -196,6 +340,33 @@ func WithFallbackStrategy(strategy string) Option {
 	}
 }
 
+// WithMaxStaleness sets what happens to flags Flagr has not returned for
+// longer than maxStaleness, e.g. during an outage. Flags never expire on
+// their own: the last known good definition is kept until Flagr replaces
+// or deletes it. Past maxStaleness the policy applies:
+//   - StaleServe: evaluate the flag as usual
+//   - StaleMark: evaluate it with EvaluationReason ReasonStale
+//   - StaleFallback: answer with the fallback strategy (see WithFallbackStrategy)
+//
+// Stale evaluations are counted in Metrics. Default: stale flags are served.
+//
+// Example: vexilla.WithMaxStaleness(time.Hour, vexilla.StaleMark)
+func WithMaxStaleness(maxStaleness time.Duration, policy string) Option {
+	return func(c *clientConfig) error {
+		if maxStaleness <= 0 {
+			return fmt.Errorf("max staleness must be positive")
+		}
+		switch policy {
+		case StaleServe, StaleMark, StaleFallback:
+		default:
+			return fmt.Errorf("invalid stale policy: %s", policy)
+		}
+		c.maxStaleness = maxStaleness
+		c.stalePolicy = policy
+		return nil
+	}
+}
+
 // WithCircuitBreaker configures the circuit breaker.
 //
 // Example: vexilla.WithCircuitBreaker(3, 30*time.Second)
@@ -207,6 +378,26 @@ func WithCircuitBreaker(threshold int, timeout time.Duration) Option {
 	}
 }
 
+// WithReadiness configures when Health (and the /healthz/ready admin
+// endpoint) reports the client as ready. A source is not ready before its
+// first load, while its circuit breaker is open, when its last successful
+// sync is older than maxStaleness, or when it caches fewer than minFlags
+// flags. Zero disables a threshold.
+//
+// Example: vexilla.WithReadiness(10*time.Minute, 1)
+func WithReadiness(maxStaleness time.Duration, minFlags int) Option {
+	return func(c *clientConfig) error {
+		if maxStaleness < 0 {
+			return fmt.Errorf("max staleness cannot be negative")
+		}
+		if minFlags < 0 {
+			return fmt.Errorf("min flags cannot be negative")
+		}
+		c.readiness = readiness{maxStaleness: maxStaleness, minFlags: minFlags}
+		return nil
+	}
+}
+
 // WithOnlyEnabled filters out disabled flags.
 // When true, only enabled flags are cached, reducing memory usage.
 //
@@ -250,6 +441,32 @@ func WithAdditionalTags(tags []string, matchMode string) Option {
 	}
 }
 
+// WithNamespaces loads only the flags tagged with one of the namespaces.
+// Unlike the tag filters, the filtering happens in Flagr's list query, so
+// flags of other namespaces are never downloaded. The namespaces are loaded
+// at start and refreshed periodically.
+//
+// Example: vexilla.WithNamespaces("checkout", "payments")
+func WithNamespaces(namespaces ...string) Option {
+	return func(c *clientConfig) error {
+		c.namespaces = append(c.namespaces, namespaces...)
+		return nil
+	}
+}
+
+// WithLazyNamespaces adds namespaces loaded the first time one of their
+// flags is requested. That request looks the flag up in Flagr by key and
+// loads its whole namespace, which is then refreshed with the others.
+// Flags outside every namespace get the fallback strategy.
+//
+// Example: vexilla.WithLazyNamespaces("search", "recommendations")
+func WithLazyNamespaces(namespaces ...string) Option {
+	return func(c *clientConfig) error {
+		c.lazyNamespaces = append(c.lazyNamespaces, namespaces...)
+		return nil
+	}
+}
+
 // WithConfig applies a full Config struct.
 // This is an alternative to using individual options.
 func WithConfig(cfg Config) Option {
@@ -267,6 +484,8 @@ func WithConfig(cfg Config) Option {
 		c.requireServiceTag = cfg.Cache.Filter.RequireServiceTag
 		c.additionalTags = cfg.Cache.Filter.AdditionalTags
 		c.tagMatchMode = cfg.Cache.Filter.TagMatchMode
+		c.namespaces = cfg.Cache.Filter.Namespaces
+		c.lazyNamespaces = cfg.Cache.Filter.LazyNamespaces
 
 		c.circuitThreshold = cfg.CircuitBreaker.Threshold
 		c.circuitTimeout = cfg.CircuitBreaker.Timeout
@@ -277,6 +496,210 @@ func WithConfig(cfg Config) Option {
 	}
 }
 
+// WithFlagFile serves flags from a local JSON or YAML file instead of a
+// Flagr server, which is handy for local development and tests. Every flag
+// is evaluated locally, including partial rollouts and A/B distributions.
+// The file is reloaded whenever it changes.
+//
+// The file may be a Flagr export (the output of GET /api/v1/flags?preload=true)
+// or use the simple format:
+//
+//	flags:
+//	  new-checkout:
+//	    variants:
+//	      enabled: {enabled: true}
+//	      disabled: {enabled: false}
+//	    segments:
+//	      - constraints:
+//	          - {property: country, operator: EQ, value: BR}
+//	        variant: enabled
+//	      - rollout: 20
+//	        variant: enabled
+//	    default: disabled
+//
+// WithFlagFile cannot be combined with WithFlagrEndpoint.
+//
+// Example: vexilla.WithFlagFile("flags.yaml")
+func WithFlagFile(path string) Option {
+	return func(c *clientConfig) error {
+		if path == "" {
+			return fmt.Errorf("flag file path cannot be empty")
+		}
+		c.flagFile = path
+		return nil
+	}
+}
+
+// WithEvaluationHook registers a function called after every evaluation
+// (Bool, String, Int and Evaluate) with the flag key, the context, and the
+// result or error. The hook runs on the evaluating goroutine, so it must be
+// fast and safe for concurrent use.
+//
+// Example:
+//
+//	vexilla.WithEvaluationHook(func(flagKey string, evalCtx vexilla.Context, result *vexilla.Result, err error) {
+//	    log.Printf("flag %s for %s: %v", flagKey, evalCtx.EntityID, result)
+//	})
+func WithEvaluationHook(hook func(flagKey string, evalCtx Context, result *Result, err error)) Option {
+	return func(c *clientConfig) error {
+		c.evaluationHook = hook
+		return nil
+	}
+}
+
+// WithErrorHandler sets the function receiving errors of background work,
+// such as a failed reload of the override file or the flag file. The previous state is kept
+// when such an error happens. Default: log via slog.
+//
+// Example:
+//
+//	vexilla.WithErrorHandler(func(err error) {
+//	    logger.Warn("vexilla", "error", err)
+//	})
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *clientConfig) error {
+		if handler == nil {
+			return fmt.Errorf("error handler cannot be nil")
+		}
+		c.errorHandler = handler
+		return nil
+	}
+}
+
+// reportError passes an error of background work to the handler set with
+// WithErrorHandler, logging it via slog by default
+func (c *clientConfig) reportError(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(err)
+		return
+	}
+	slog.Warn("vexilla background error", "error", err)
+}
+
+// WithKnownProperties lists the context attributes your services send.
+// Lint then reports constraints on any other property.
+// Nested attributes of a known property are known too: "user" covers
+// "user.country".
+//
+// Example: vexilla.WithKnownProperties("country", "tier", "account")
+func WithKnownProperties(properties ...string) Option {
+	return func(c *clientConfig) error {
+		c.knownProperties = append([]string{}, properties...)
+		return nil
+	}
+}
+
+// WithSource adds a flag source: another Flagr instance, such as the Flagr
+// of another region, with its own endpoint, filters and refresh interval.
+// Evaluations are routed to a source by the prefix of the flag key, or by
+// name with Context.WithSource; other keys go to the default source, which
+// is the Flagr configured with WithFlagrEndpoint (or WithFlagFile), or the
+// first source added when there is none.
+//
+// Each source has its own cache and circuit breaker; the fallback
+// strategy, evaluation hook and local overrides are shared. Overrides match
+// the flag key without the prefix.
+//
+// Example:
+//
+//	client, err := vexilla.New(
+//	    vexilla.WithFlagrEndpoint("http://flagr.us.internal:18000"),
+//	    vexilla.WithSource(vexilla.SourceConfig{
+//	        Name:   "eu",
+//	        Prefix: "eu/",
+//	        Flagr:  vexilla.FlagrConfig{Endpoint: "http://flagr.eu.internal:18000"},
+//	    }),
+//	)
+//
+//	client.Bool(ctx, "eu/new-checkout", evalCtx)              // new-checkout in the EU Flagr
+//	client.Bool(ctx, "new-checkout", evalCtx.WithSource("eu")) // the same flag
+func WithSource(config SourceConfig) Option {
+	return func(c *clientConfig) error {
+		if config.Name == "" {
+			return fmt.Errorf("source name cannot be empty")
+		}
+		if config.Name == defaultSource {
+			return fmt.Errorf("source name %q is reserved", defaultSource)
+		}
+		if config.Flagr.Endpoint == "" {
+			return fmt.Errorf("source %s: flagr endpoint cannot be empty", config.Name)
+		}
+		if config.Filter.TagMatchMode != "" && config.Filter.TagMatchMode != "any" && config.Filter.TagMatchMode != "all" {
+			return fmt.Errorf("source %s: tag match mode must be 'any' or 'all'", config.Name)
+		}
+		for _, other := range c.sources {
+			if other.Name == config.Name {
+				return fmt.Errorf("source %s is configured twice", config.Name)
+			}
+			if config.Prefix != "" && other.Prefix == config.Prefix {
+				return fmt.Errorf("sources %s and %s have the same prefix %q", other.Name, config.Name, config.Prefix)
+			}
+		}
+
+		c.sources = append(c.sources, config)
+		return nil
+	}
+}
+
+// The options taking internal types are reachable from in-module tooling
+// only, through the clienthooks package
+func init() {
+	clienthooks.WithFlagrClient = func(client flagr.Client) any { return withFlagrClient(client) }
+	clienthooks.WithStorage = func(s storage.Storage) any { return withStorage(s) }
+}
+
+// withFlagrClient replaces the Flagr HTTP client with another flag source
+func withFlagrClient(client flagr.Client) Option {
+	return func(c *clientConfig) error {
+		if client == nil {
+			return fmt.Errorf("flagr client cannot be nil")
+		}
+		c.flagrClient = client
+		return nil
+	}
+}
+
+// withStorage replaces the in-memory flag storage
+func withStorage(s storage.Storage) Option {
+	return func(c *clientConfig) error {
+		if s == nil {
+			return fmt.Errorf("storage cannot be nil")
+		}
+		c.storage = s
+		return nil
+	}
+}
+
+// WithOverrideFile loads local overrides from a YAML or JSON file and
+// reloads it whenever it changes. The file is checked every pollInterval
+// (default 2 seconds when zero). Start fails if the file cannot be loaded;
+// later invalid versions are ignored and the previous overrides are kept.
+//
+// File format (".json" files use the same structure in JSON):
+//
+//	overrides:
+//	  - flag_key: new-checkout
+//	    entity_id: qa-account-1
+//	    variant_key: enabled
+//	    attachment:
+//	      enabled: true
+//	  - flag_key: pricing-v2
+//	    match:
+//	      tier: [qa, internal]
+//	    variant_key: control
+//
+// Example: vexilla.WithOverrideFile("overrides.yaml", 5*time.Second)
+func WithOverrideFile(path string, pollInterval time.Duration) Option {
+	return func(c *clientConfig) error {
+		if path == "" {
+			return fmt.Errorf("override file path cannot be empty")
+		}
+		c.overrideFile = path
+		c.overridePollInterval = pollInterval
+		return nil
+	}
+}
+
 // WithWebhookInvalidation habilita o servidor de webhook para invalidação externa.
 // O webhook permite que sistemas externos (como Flagr) notifiquem o Vexilla
 // sobre mudanças nas flags, permitindo invalidação em tempo real.
@@ -291,25 +714,62 @@ func WithConfig(cfg Config) Option {
 //	    }),
 //	)
 //
+// A porta 0 escolhe uma porta livre; o endereço real é retornado por
+// Client.WebhookAddr após o Start.
+//
+// Para montar o endpoint no router da aplicação em vez de abrir uma porta,
+// use DisableServer e Client.WebhookHandler.
+//
+// Com Secret, cada requisição deve trazer os headers:
+//
+//	X-Webhook-Timestamp: <unix em segundos>
+//	X-Webhook-Signature: sha256=<hex HMAC-SHA256 de "<timestamp>.<corpo>">
+//
+// O prefixo "sha256=" é opcional. Timestamps fora de Tolerance e
+// assinaturas já recebidas são rejeitados com 401.
+//
 // O webhook irá responder em POST /webhook com payload:
 //
 //	{
 //	  "event": "flag.updated",
 //	  "flag_keys": ["flag1", "flag2"],
+//	  "flag_ids": [42],
 //	  "timestamp": "2025-01-15T10:30:00Z"
 //	}
+//
+// Eventos: flag.created, flag.updated, flag.enabled, flag.disabled e
+// flag.deleted. O webhook responde 202 imediatamente e aplica os eventos em
+// background: os eventos de uma rajada são agrupados (Debounce) e apenas as
+// flags afetadas são buscadas no Flagr, por chave ou ID. Flags excluídas
+// são removidas do cache. Com a fila cheia (QueueSize) responde 503.
 func WithWebhookInvalidation(config WebhookConfig) Option {
 	return func(c *clientConfig) error {
-		if config.Port <= 0 {
-			return fmt.Errorf("webhook port must be positive")
+		if config.Port < 0 {
+			return fmt.Errorf("webhook port cannot be negative")
 		}
 		if config.Port > 65535 {
 			return fmt.Errorf("webhook port must be <= 65535")
 		}
+		if err := validatePathPrefix(config.PathPrefix); err != nil {
+			return fmt.Errorf("webhook %w", err)
+		}
+		if config.Tolerance < 0 {
+			return fmt.Errorf("webhook tolerance cannot be negative")
+		}
+		if config.Secret == "" && len(config.PreviousSecrets) > 0 {
+			return fmt.Errorf("webhook previous secrets require a secret")
+		}
 
-		c.webhookEnabled = true
+		c.webhookEnabled = !config.DisableServer
+		c.webhookPathPrefix = config.PathPrefix
+		c.webhookQueueSize = config.QueueSize
+		c.webhookDebounce = config.Debounce
 		c.webhookPort = config.Port
 		c.webhookSecret = config.Secret
+		c.webhookPreviousSecrets = config.PreviousSecrets
+		c.webhookTolerance = config.Tolerance
+		c.webhookLegacySignatures = config.LegacySignatures
+		c.webhookShutdownTimeout = config.ShutdownTimeout
 		return nil
 	}
 }
@@ -318,11 +778,16 @@ func WithWebhookInvalidation(config WebhookConfig) Option {
 // O admin server fornece endpoints para gerenciamento e observabilidade.
 //
 // Endpoints disponíveis:
-//   - GET /health - Health check
+//   - GET /health - Saúde completa (sync, circuit breaker, flags e Flagr); 503 quando não pronto
+//   - GET /healthz/live - Liveness probe
+//   - GET /healthz/ready - Readiness probe, segundo WithReadiness (não chama o Flagr)
 //   - GET /admin/stats - Métricas do cache
 //   - POST /admin/invalidate - Invalida uma flag específica
 //   - POST /admin/invalidate-all - Limpa todo o cache
-//   - POST /admin/refresh - Força refresh das flags
+//   - POST /admin/refresh - Força refresh das flags (?source= para uma única fonte)
+//   - POST /admin/evaluate - Explica a avaliação de uma flag (ou de todas) para uma entidade
+//   - GET /admin/lint - Lista problemas encontrados nas flags em cache
+//   - GET/POST/DELETE /admin/overrides - Lista, cria e remove overrides locais
 //
 // Exemplo:
 //
@@ -332,26 +797,59 @@ func WithWebhookInvalidation(config WebhookConfig) Option {
 //	        Port: 19000,
 //	    }),
 //	)
+//
+// A porta 0 escolhe uma porta livre; o endereço real é retornado por
+// Client.AdminAddr após o Start.
+//
+// Para montar os endpoints no router da aplicação em vez de abrir uma porta,
+// use DisableServer e Client.AdminHandler.
+//
+// Sem Auth os endpoints ficam abertos a quem alcança a porta. Com
+// autenticadores, /health e /healthz/* continuam públicos e o restante exige AdminRead
+// (GET) ou AdminWrite (invalidate, refresh e overrides), com auditoria de
+// cada chamada que altera o cache.
 func WithAdminServer(config AdminConfig) Option {
 	return func(c *clientConfig) error {
-		if config.Port <= 0 {
-			return fmt.Errorf("admin port must be positive")
+		if config.Port < 0 {
+			return fmt.Errorf("admin port cannot be negative")
 		}
 		if config.Port > 65535 {
 			return fmt.Errorf("admin port must be <= 65535")
 		}
+		if err := validatePathPrefix(config.PathPrefix); err != nil {
+			return fmt.Errorf("admin %w", err)
+		}
 
-		c.adminEnabled = true
+		c.adminEnabled = !config.DisableServer
+		c.adminPathPrefix = config.PathPrefix
+		c.adminAuth = config.Auth
+		c.adminAudit = config.Audit
+		c.adminTLS = config.TLSConfig
 		c.adminPort = config.Port
+		c.adminShutdownTimeout = config.ShutdownTimeout
 		return nil
 	}
 }
 
+// validatePathPrefix valida o prefixo dos endpoints dos servidores
+func validatePathPrefix(prefix string) error {
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("path prefix must start with /")
+	}
+	return nil
+}
+
 // HTTPMiddleware retorna um middleware HTTP que injeta automaticamente
 // o contexto de avaliação nas requisições.
 //
-// O middleware extrai informações da requisição HTTP (headers, path, user_id, etc)
-// e as disponibiliza no context.Context para uso na avaliação de flags.
+// Cada requisição recebe também um escopo de avaliações (WithEvaluations):
+// o primeiro resultado de cada flag é fixado até o fim da requisição, e
+// EvaluatedFlags lista as flags avaliadas.
+//
+// O contexto é montado pelos extractors, executados em ordem. Sem
+// extractors são usados os DefaultExtractors (user_id via header X-User-ID
+// ou cookie, path, method, ip e user_agent). Headers e cookies só entram no
+// contexto quando listados explicitamente (FromHeaders, FromCookies).
 //
 // Exemplo com net/http:
 //
@@ -359,9 +857,7 @@ func WithAdminServer(config AdminConfig) Option {
 //
 //	handler := client.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 //	    // O contexto já contém informações da requisição
-//	    enabled := client.Bool(r.Context(), "new-feature", vexilla.NewContext(""))
-//
-//	    if enabled {
+//	    if client.BoolFromRequest(r, "new-feature") {
 //	        w.Write([]byte("New feature is enabled!"))
 //	    } else {
 //	        w.Write([]byte("Old behavior"))
@@ -370,66 +866,301 @@ func WithAdminServer(config AdminConfig) Option {
 //
 //	http.ListenAndServe(":8080", handler)
 //
-// Exemplo com http.Handler wrapping:
+// Exemplo com extractors:
 //
-//	mux := http.NewServeMux()
-//	mux.HandleFunc("/", myHandler)
-//
-//	wrappedHandler := client.HTTPMiddleware(mux)
+//	wrappedHandler := client.HTTPMiddleware(mux,
+//	    vexilla.FromJWTClaims("sub", map[string]string{"plan": "tier"}),
+//	    vexilla.FromHeaders(map[string]string{"X-Country": "country"}),
+//	    func(r *http.Request, evalCtx vexilla.Context) vexilla.Context {
+//	        return evalCtx.WithAttribute("beta", r.URL.Query().Has("beta"))
+//	    },
+//	)
 //	http.ListenAndServe(":8080", wrappedHandler)
-func (c *Client) HTTPMiddleware(next http.Handler) http.Handler {
-	adapter := &cacheAdapter{cache: c.cache}
-	middleware := server.NewMiddleware(adapter)
-	return middleware.Handler(next)
+func (c *Client) HTTPMiddleware(next http.Handler, extractors ...Extractor) http.Handler {
+	if len(extractors) == 0 {
+		extractors = DefaultExtractors()
+	}
+
+	adapter := &cacheAdapter{cache: c.cache, sources: c.sources}
+	middleware := server.NewMiddleware(adapter, func(r *http.Request) interface{} {
+		return extractContext(r, extractors)
+	})
+	return middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(c.WithEvaluations(r.Context())))
+	}))
 }
 
-// startWebhookServer inicia o servidor de webhook em background
-func (c *Client) startWebhookServer(ctx context.Context, port int, secret string) error {
-	adapter := &cacheAdapter{cache: c.cache}
-	webhookServer := server.NewWebhookServer(adapter, port, secret)
+// startWebhookServer inicia o servidor de webhook. A porta é aberta antes
+// do retorno, então conflitos de porta são reportados ao Start.
+func (c *Client) startWebhookServer(ctx context.Context) error {
+	return c.webhook().Start(ctx)
+}
 
-	go func() {
-		if err := webhookServer.Start(); err != nil && err != http.ErrServerClosed {
-			// Log error but don't crash the application
-			fmt.Printf("webhook server error: %v\n", err)
-		}
-	}()
+// startAdminServer inicia o servidor de administração. A porta é aberta
+// antes do retorno, então conflitos de porta são reportados ao Start.
+func (c *Client) startAdminServer(ctx context.Context, port int) error {
+	adminServer := c.newAdminServer(port)
+	if err := adminServer.Start(ctx); err != nil {
+		return err
+	}
 
+	c.adminServer = adminServer
 	return nil
 }
 
-// startAdminServer inicia o servidor de administração em background
-func (c *Client) startAdminServer(ctx context.Context, port int) error {
-	adapter := &cacheAdapter{cache: c.cache}
-	adminServer := server.NewAdminServer(adapter, port)
-
-	go func() {
-		if err := adminServer.Start(); err != nil && err != http.ErrServerClosed {
-			// Log error but don't crash the application
-			fmt.Printf("admin server error: %v\n", err)
-		}
-	}()
+// newWebhookServer cria o servidor de webhook com o prefixo, a fila e as
+// assinaturas configurados
+func (c *Client) newWebhookServer(port int, secret string) *server.WebhookServer {
+	adapter := &cacheAdapter{cache: c.cache, sources: c.sources}
+	return server.NewWebhookServer(adapter, port, secret).
+		WithSecrets(c.webhookPreviousSecrets...).
+		WithReplayProtection(c.webhookTolerance, c.webhookLegacySignatures).
+		WithPathPrefix(c.webhookPathPrefix).
+		WithQueue(c.webhookQueueSize, c.webhookDebounce)
+}
 
-	return nil
+// newAdminServer cria o servidor de administração com o prefixo configurado
+func (c *Client) newAdminServer(port int) *server.AdminServer {
+	adapter := &cacheAdapter{cache: c.cache, sources: c.sources, knownProperties: c.knownProperties, readiness: c.readiness}
+	audit := c.adminAudit
+	if audit == nil {
+		audit = logAdminAudit
+	}
+	return server.NewAdminServer(adapter, port).
+		WithPathPrefix(c.adminPathPrefix).
+		WithAuth(adminAuditLogger(audit), adminAuthenticators(c.adminAuth)...).
+		WithTLS(c.adminTLS)
 }
 
 // cacheAdapter adapts cache.Cache to server.CacheInterface
 type cacheAdapter struct {
+	// cache is the cache of the default source
 	cache *cache.Cache
+
+	// Every flag source, the default first (nil when cache is the only one)
+	sources []*source
+
+	// Context attributes services send, checked by Lint
+	knownProperties []string
+
+	// Readiness thresholds reported by the health endpoints
+	readiness readiness
+}
+
+// adminMetrics are the /admin/stats metrics of a client with several
+// sources: the default source's, and every source's
+type adminMetrics struct {
+	cache.Metrics
+	Sources []SourceMetrics
+}
+
+// allSources returns every flag source, the default first
+func (a *cacheAdapter) allSources() []*source {
+	if len(a.sources) == 0 {
+		return []*source{{name: defaultSource, cache: a.cache}}
+	}
+	return a.sources
+}
+
+func (a *cacheAdapter) Health(ctx context.Context, checkFlagr bool) (interface{}, bool) {
+	health := a.readiness.check(ctx, a.allSources(), checkFlagr)
+	return health, health.Ready
 }
 
 func (a *cacheAdapter) GetMetrics() interface{} {
-	return a.cache.GetMetrics()
+	if len(a.sources) <= 1 {
+		return a.cache.GetMetrics()
+	}
+
+	metrics := adminMetrics{Metrics: a.cache.GetMetrics()}
+	for _, s := range a.sources {
+		metrics.Sources = append(metrics.Sources, s.metrics())
+	}
+	return metrics
 }
 
 func (a *cacheAdapter) InvalidateFlag(flagKey string) error {
-	return a.cache.InvalidateFlag(context.Background(), flagKey)
+	s, key, err := route(a.allSources(), flagKey, "")
+	if err != nil {
+		return err
+	}
+	return s.cache.InvalidateFlag(context.Background(), key)
 }
 
 func (a *cacheAdapter) InvalidateAll() error {
-	return a.cache.InvalidateAll(context.Background())
+	var errs []error
+	for _, s := range a.allSources() {
+		if err := s.cache.InvalidateAll(context.Background()); err != nil {
+			errs = append(errs, sourceError(s, err))
+		}
+	}
+	return joinErrors(errs)
 }
 
 func (a *cacheAdapter) RefreshFlags() error {
-	return a.cache.Sync(context.Background())
+	var errs []error
+	for _, s := range a.allSources() {
+		if err := s.cache.Sync(context.Background()); err != nil {
+			errs = append(errs, sourceError(s, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+func (a *cacheAdapter) RefreshFlagKeys(keys []string, ids map[string][]int64) error {
+	all := a.allSources()
+	type target struct {
+		keys []string
+		ids  []int64
+	}
+	targets := make(map[*source]*target)
+	var order []*source
+	add := func(s *source) *target {
+		if _, ok := targets[s]; !ok {
+			targets[s] = &target{}
+			order = append(order, s)
+		}
+		return targets[s]
+	}
+
+	var errs []error
+	for _, flagKey := range keys {
+		s, key, err := route(all, flagKey, "")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t := add(s)
+		t.keys = append(t.keys, key)
+	}
+	for name, sourceIDs := range ids {
+		s := all[0]
+		if name != "" {
+			var err error
+			if s, _, err = route(all, "", name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		t := add(s)
+		t.ids = append(t.ids, sourceIDs...)
+	}
+
+	for _, s := range order {
+		t := targets[s]
+		if err := s.cache.RefreshFlagKeys(context.Background(), t.keys, t.ids); err != nil {
+			errs = append(errs, sourceError(s, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+func (a *cacheAdapter) Sources() []string {
+	var names []string
+	for _, s := range a.allSources() {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+func (a *cacheAdapter) RefreshSource(name string) error {
+	s, _, err := route(a.allSources(), "", name)
+	if err != nil {
+		return err
+	}
+	return sourceError(s, s.cache.Sync(context.Background()))
+}
+
+func (a *cacheAdapter) ExplainFlag(flagKey string, req server.EvaluateRequest) (*server.FlagReport, error) {
+	ctx := context.Background()
+	evalCtx := Context{
+		EntityID:   req.EntityID,
+		EntityType: req.EntityType,
+		Attributes: req.Attributes,
+	}
+	if evalCtx.EntityType == "" {
+		evalCtx.EntityType = "user"
+	}
+
+	report := &server.FlagReport{FlagKey: flagKey}
+
+	s, key, err := route(a.allSources(), flagKey, req.Source)
+	if err != nil {
+		return report, err
+	}
+
+	if flag, err := s.cache.GetFlag(ctx, key); err == nil {
+		strategy := evaluator.NewStrategyDeterminer()
+		report.Analysis = strategy.AnalyzeFlag(*flag)
+		report.Performance = strategy.EstimatePerformance(*flag)
+	}
+
+	trace, err := s.cache.Explain(ctx, key, toDomainContext(evalCtx))
+	if trace != nil {
+		report.Explanation = toExplanation(trace)
+	}
+
+	return report, err
+}
+
+// FlagKeys lists the keys of the cached flags of a source, or of every
+// source, with the prefix of their source
+func (a *cacheAdapter) FlagKeys(source string) []string {
+	var keys []string
+	for _, s := range a.allSources() {
+		if source != "" && s.name != source {
+			continue
+		}
+		for _, key := range s.cache.FlagKeys() {
+			keys = append(keys, s.prefix+key)
+		}
+	}
+	return keys
+}
+
+func (a *cacheAdapter) Lint() []server.LintIssue {
+	issues := lintSources(context.Background(), a.allSources(), a.knownProperties)
+	out := make([]server.LintIssue, len(issues))
+	for i, issue := range issues {
+		out[i] = server.LintIssue(issue)
+	}
+	return out
+}
+
+func (a *cacheAdapter) ListOverrides() []server.OverrideSpec {
+	list := a.cache.Overrides().List()
+	specs := make([]server.OverrideSpec, 0, len(list))
+	for _, o := range list {
+		specs = append(specs, server.OverrideSpec{
+			FlagKey:    o.FlagKey,
+			EntityID:   o.EntityID,
+			Match:      o.Match,
+			VariantKey: o.VariantKey,
+			Attachment: o.VariantAttachment,
+			Source:     string(o.Source),
+		})
+	}
+	return specs
+}
+
+func (a *cacheAdapter) SetOverride(spec server.OverrideSpec) error {
+	return a.cache.Overrides().Set(overrideFromSpec(spec))
+}
+
+func (a *cacheAdapter) RemoveOverride(spec server.OverrideSpec) bool {
+	return a.cache.Overrides().Remove(overrideFromSpec(spec))
+}
+
+func (a *cacheAdapter) ClearOverrides() {
+	a.cache.Overrides().Clear()
+}
+
+func overrideFromSpec(spec server.OverrideSpec) override.Override {
+	return override.Override{
+		FlagKey:           spec.FlagKey,
+		EntityID:          spec.EntityID,
+		Match:             spec.Match,
+		VariantKey:        spec.VariantKey,
+		VariantAttachment: spec.Attachment,
+	}
 }
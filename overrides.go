@@ -0,0 +1,129 @@
+package vexilla
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/override"
+)
+
+// ReasonOverride is the EvaluationReason of results forced by a local override.
+const ReasonOverride = override.Reason
+
+// Override forces the result of a flag locally, without touching Flagr.
+//
+// Overrides are consulted before the cache. An override with an EntityID
+// applies to that entity only; one with Match applies to contexts whose
+// attributes equal every entry (dotted paths and "entityID"/"entityType"
+// are supported, and a slice value matches any of its elements); one with
+// neither applies to every evaluation of the flag. When several overrides
+// match, entity overrides win over attribute matchers, which win over
+// flag-wide overrides.
+type Override struct {
+	// FlagKey is the key of the overridden flag (required)
+	FlagKey string
+
+	// EntityID restricts the override to a single entity
+	EntityID string
+
+	// Match restricts the override to contexts with these attributes
+	Match map[string]any
+
+	// VariantKey is the variant to return (required)
+	VariantKey string
+
+	// Attachment is the variant attachment to return
+	Attachment map[string]any
+
+	// Source is "runtime" or "file" (set by Overrides, ignored otherwise)
+	Source string
+}
+
+// SetOverride adds a runtime override, replacing any existing runtime
+// override for the same flag, entity and matcher.
+//
+// Example:
+//
+//	err := client.SetOverride(vexilla.Override{
+//	    FlagKey:    "new-checkout",
+//	    EntityID:   "qa-account-1",
+//	    VariantKey: "enabled",
+//	    Attachment: map[string]any{"enabled": true},
+//	})
+func (c *Client) SetOverride(o Override) error {
+	internal, err := toInternalOverride(o)
+	if err != nil {
+		return err
+	}
+	return c.cache.Overrides().Set(internal)
+}
+
+// RemoveOverride removes the runtime override for the same flag, entity
+// and matcher as o. It returns false if no such override exists.
+func (c *Client) RemoveOverride(o Override) bool {
+	internal, err := toInternalOverride(o)
+	if err != nil {
+		return false
+	}
+	return c.cache.Overrides().Remove(internal)
+}
+
+// ClearOverrides removes every runtime override.
+// Overrides loaded from an override file are kept.
+func (c *Client) ClearOverrides() {
+	c.cache.Overrides().Clear()
+}
+
+// Overrides lists the active overrides, runtime overrides first.
+func (c *Client) Overrides() []Override {
+	list := c.cache.Overrides().List()
+	out := make([]Override, 0, len(list))
+	for _, o := range list {
+		out = append(out, fromInternalOverride(o))
+	}
+	return out
+}
+
+func toInternalOverride(o Override) (override.Override, error) {
+	internal := override.Override{
+		FlagKey:    o.FlagKey,
+		EntityID:   o.EntityID,
+		Match:      o.Match,
+		VariantKey: o.VariantKey,
+	}
+
+	if len(o.Attachment) > 0 {
+		internal.VariantAttachment = make(map[string]json.RawMessage, len(o.Attachment))
+		for k, v := range o.Attachment {
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return override.Override{}, fmt.Errorf("invalid override attachment %q: %w", k, err)
+			}
+			internal.VariantAttachment[k] = raw
+		}
+	}
+
+	return internal, nil
+}
+
+func fromInternalOverride(o override.Override) Override {
+	out := Override{
+		FlagKey:    o.FlagKey,
+		EntityID:   o.EntityID,
+		Match:      o.Match,
+		VariantKey: o.VariantKey,
+		Source:     string(o.Source),
+	}
+
+	if len(o.VariantAttachment) > 0 {
+		out.Attachment = make(map[string]any, len(o.VariantAttachment))
+		for k, raw := range o.VariantAttachment {
+			var v any
+			if err := json.Unmarshal(raw, &v); err == nil {
+				out.Attachment[k] = v
+			}
+		}
+	}
+
+	return out
+}
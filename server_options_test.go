@@ -2,13 +2,22 @@ package vexilla
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -36,11 +45,34 @@ func TestWithWebhookInvalidation(t *testing.T) {
 			expectErr: false,
 		},
 		{
-			name: "invalid port - zero",
+			name: "invalid path prefix",
+			config: WebhookConfig{
+				Port:       18001,
+				PathPrefix: "flagr",
+			},
+			expectErr: true,
+		},
+		{
+			name: "port zero picks a free port",
 			config: WebhookConfig{
 				Port:   0,
 				Secret: "secret",
 			},
+			expectErr: false,
+		},
+		{
+			name: "negative tolerance",
+			config: WebhookConfig{
+				Secret:    "secret",
+				Tolerance: -time.Second,
+			},
+			expectErr: true,
+		},
+		{
+			name: "previous secrets without secret",
+			config: WebhookConfig{
+				PreviousSecrets: []string{"old"},
+			},
 			expectErr: true,
 		},
 		{
@@ -94,12 +126,20 @@ func TestWithAdminServer(t *testing.T) {
 			expectErr: false,
 		},
 		{
-			name: "invalid port - zero",
+			name: "invalid path prefix",
 			config: AdminConfig{
-				Port: 0,
+				Port:       19000,
+				PathPrefix: "ops",
 			},
 			expectErr: true,
 		},
+		{
+			name: "port zero picks a free port",
+			config: AdminConfig{
+				Port: 0,
+			},
+			expectErr: false,
+		},
 		{
 			name: "invalid port - negative",
 			config: AdminConfig{
@@ -331,6 +371,141 @@ func TestClient_CombinedServers(t *testing.T) {
 	assert.Equal(t, 19503, client.adminPort)
 }
 
+// TestClient_ServerLifecycle tests that Start binds the servers and Stop shuts them down
+func TestClient_ServerLifecycle(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithWebhookInvalidation(WebhookConfig{Port: 0, Secret: "test-secret"}),
+		WithAdminServer(AdminConfig{Port: 0}),
+	)
+	require.NoError(t, err)
+	assert.Nil(t, client.AdminAddr(), "not bound before Start")
+
+	require.NoError(t, client.Start(context.Background()))
+
+	adminAddr := client.AdminAddr()
+	require.NotNil(t, adminAddr)
+	assert.NotZero(t, adminAddr.(*net.TCPAddr).Port)
+	require.NotNil(t, client.WebhookAddr())
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/health", adminAddr.(*net.TCPAddr).Port))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, client.Stop())
+	assert.Nil(t, client.AdminAddr())
+	assert.Nil(t, client.WebhookAddr())
+
+	_, err = net.Dial("tcp", adminAddr.String())
+	assert.Error(t, err, "admin server is shut down")
+}
+
+// TestClient_MountedHandlers tests the handlers mounted on an application router
+func TestClient_MountedHandlers(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithWebhookInvalidation(WebhookConfig{Secret: "test-secret", PathPrefix: "/flagr", DisableServer: true}),
+		WithAdminServer(AdminConfig{PathPrefix: "/ops", DisableServer: true}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	assert.Nil(t, client.AdminAddr(), "no port is opened")
+	assert.Nil(t, client.WebhookAddr(), "no port is opened")
+
+	mux := http.NewServeMux()
+	mux.Handle("/ops/", client.AdminHandler())
+	mux.Handle("/flagr/", client.WebhookHandler())
+	app := httptest.NewServer(mux)
+	defer app.Close()
+
+	resp, err := http.Get(app.URL + "/ops/admin/stats")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The webhook still checks signatures
+	resp, err = http.Post(app.URL+"/flagr/webhook", "application/json", strings.NewReader(`{"event":"flag.deleted"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+// TestClient_WebhookSecretRotation tests that senders signing with the
+// previous secret are accepted while the secret is rotated
+func TestClient_WebhookSecretRotation(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithWebhookInvalidation(WebhookConfig{
+			Secret:          "new-secret",
+			PreviousSecrets: []string{"old-secret"},
+			DisableServer:   true,
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	app := httptest.NewServer(client.WebhookHandler())
+	defer app.Close()
+
+	body := `{"event":"flag.deleted","flag_keys":["x"]}`
+	send := func(secret string, at time.Time) int {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "." + body))
+
+		req, err := http.NewRequest(http.MethodPost, app.URL+"/webhook", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("X-Webhook-Timestamp", ts)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	now := time.Now()
+	assert.Equal(t, http.StatusAccepted, send("new-secret", now))
+	assert.Equal(t, http.StatusAccepted, send("old-secret", now))
+	assert.Equal(t, http.StatusUnauthorized, send("old-secret", now), "replays are rejected")
+	assert.Equal(t, http.StatusUnauthorized, send("new-secret", now.Add(-time.Hour)), "outside the tolerance")
+	assert.Equal(t, http.StatusUnauthorized, send("retired-secret", now))
+}
+
+// TestClient_ServerPortConflict tests that Start reports ports already in use
+func TestClient_ServerPortConflict(t *testing.T) {
+	server := NewMockFlagrServer(t)
+	defer server.Close()
+
+	busy, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(server.URL),
+		WithWebhookInvalidation(WebhookConfig{Port: 0, Secret: "test-secret"}),
+		WithAdminServer(AdminConfig{Port: busy.Addr().(*net.TCPAddr).Port}),
+	)
+	require.NoError(t, err)
+
+	err = client.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start admin server")
+	assert.Nil(t, client.WebhookAddr(), "servers already started are shut down")
+}
+
 // BenchmarkClient_HTTPMiddleware benchmarks the middleware
 func BenchmarkClient_HTTPMiddleware(b *testing.B) {
 	server := NewMockFlagrServer(&testing.T{})
@@ -391,3 +566,47 @@ func (w *testResponseWriter) Write(data []byte) (int, error) {
 func (w *testResponseWriter) WriteHeader(statusCode int) {
 	w.status = statusCode
 }
+
+// TestClient_WebhookTargetedRefresh tests that webhook events fetch only the changed flags
+func TestClient_WebhookTargetedRefresh(t *testing.T) {
+	banner := themeFlag(2, "on")
+	banner.Key = "banner"
+	srv := flagrtest.NewServer(themeFlag(1, "light"), banner)
+	defer srv.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithRefreshInterval(0),
+		WithWebhookInvalidation(WebhookConfig{DisableServer: true, Debounce: 10 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	app := httptest.NewServer(client.WebhookHandler())
+	defer app.Close()
+	send := func(body string) {
+		resp, err := http.Post(app.URL+"/webhook", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	listed := srv.Requests("/api/v1/flags")
+	srv.SetFlags([]domain.Flag{themeFlag(1, "dark"), banner})
+	send(`{"event":"flag.updated","flag_keys":["theme"]}`)
+
+	evalCtx := NewContext("user-1")
+	require.Eventually(t, func() bool {
+		return client.String(context.Background(), "theme", evalCtx, "") == "dark"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, listed+1, srv.Requests("/api/v1/flags"), "a single key lookup, no full sync")
+
+	fetched := srv.Requests("/api/v1/flags/{flagID}")
+	srv.SetFlags([]domain.Flag{themeFlag(1, "dark")})
+	send(`{"event":"flag.deleted","flag_ids":[2]}`)
+	require.Eventually(t, func() bool {
+		return client.Metrics().Sources[0].Flags == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, fetched+1, srv.Requests("/api/v1/flags/{flagID}"))
+}
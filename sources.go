@@ -0,0 +1,132 @@
+package vexilla
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/cache"
+)
+
+// defaultSource is the name of the source configured with the client
+// options (WithFlagrEndpoint, WithFlagFile or WithFlagrClient)
+const defaultSource = "default"
+
+// source is a named flag source with its own cache
+type source struct {
+	name   string
+	prefix string
+	cache  *cache.Cache
+}
+
+// SourceMetrics are the metrics of one flag source.
+type SourceMetrics struct {
+	// Name and Prefix identify the source; the source configured with the
+	// client options is named "default"
+	Name   string
+	Prefix string
+
+	// Flags is the number of cached flags
+	Flags int
+
+	Storage          StorageMetrics
+	LastRefresh      time.Time
+	ConsecutiveFails int
+	CircuitOpen      bool
+	Namespaces       []string
+	Stale            StaleMetrics
+}
+
+// route picks the source of a flag: the named source when name is set,
+// else the source with the longest prefix of the key, else the first
+// (default) source. It returns the key within the source.
+func route(sources []*source, flagKey, name string) (*source, string, error) {
+	if name != "" {
+		for _, s := range sources {
+			if s.name == name {
+				return s, strings.TrimPrefix(flagKey, s.prefix), nil
+			}
+		}
+		return nil, "", &EvaluationError{FlagKey: flagKey, Reason: fmt.Sprintf("unknown source %q", name)}
+	}
+
+	var match *source
+	for _, s := range sources {
+		if s.prefix != "" && strings.HasPrefix(flagKey, s.prefix) && (match == nil || len(s.prefix) > len(match.prefix)) {
+			match = s
+		}
+	}
+	if match == nil {
+		return sources[0], flagKey, nil
+	}
+	return match, strings.TrimPrefix(flagKey, match.prefix), nil
+}
+
+// sourceError names the source in errors of additional sources
+func sourceError(s *source, err error) error {
+	if err == nil || s.name == defaultSource {
+		return err
+	}
+	return fmt.Errorf("source %s: %w", s.name, err)
+}
+
+// metrics returns the metrics of the source
+func (s *source) metrics() SourceMetrics {
+	m := s.cache.GetMetrics()
+	return SourceMetrics{
+		Name:   s.name,
+		Prefix: s.prefix,
+		Flags:  len(s.cache.FlagKeys()),
+		Storage: StorageMetrics{
+			KeysAdded:   m.Storage.KeysAdded,
+			KeysEvicted: m.Storage.KeysEvicted,
+			HitRatio:    m.Storage.HitRatio,
+		},
+		LastRefresh:      m.LastRefresh,
+		ConsecutiveFails: m.ConsecutiveFails,
+		CircuitOpen:      m.CircuitOpen,
+		Namespaces:       m.Namespaces,
+		Stale:            toStaleMetrics(m),
+	}
+}
+
+// forSource returns the configuration of an additional source: the
+// client's, with the source's Flagr connection, refresh interval and filter
+func (c *clientConfig) forSource(src SourceConfig) *clientConfig {
+	cfg := *c
+	cfg.flagrClient = nil
+	cfg.flagFile = ""
+	cfg.storage = nil
+
+	cfg.flagrEndpoint = src.Flagr.Endpoint
+	cfg.flagrAPIKey = src.Flagr.APIKey
+	if src.Flagr.Timeout > 0 {
+		cfg.flagrTimeout = src.Flagr.Timeout
+	}
+	if src.Flagr.MaxRetries > 0 {
+		cfg.flagrMaxRetries = src.Flagr.MaxRetries
+	}
+	if src.RefreshInterval > 0 {
+		cfg.refreshInterval = src.RefreshInterval
+	}
+
+	cfg.onlyEnabled = src.Filter.OnlyEnabled
+	cfg.serviceName = src.Filter.ServiceName
+	cfg.requireServiceTag = src.Filter.RequireServiceTag
+	cfg.additionalTags = src.Filter.AdditionalTags
+	cfg.tagMatchMode = src.Filter.TagMatchMode
+	cfg.namespaces = src.Filter.Namespaces
+	cfg.lazyNamespaces = src.Filter.LazyNamespaces
+
+	return &cfg
+}
+
+// joinErrors joins the errors of several sources; a single error is
+// returned as is, so callers can check its type
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,213 @@
+package vexilla
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/server"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// themeFlag serves a single theme to everyone
+func themeFlag(id int64, theme string) domain.Flag {
+	return domain.Flag{
+		ID:      id,
+		Key:     "theme",
+		Enabled: true,
+		Variants: []domain.Variant{
+			{ID: 1, Key: theme, Attachment: map[string]json.RawMessage{"value": json.RawMessage(`"` + theme + `"`)}},
+		},
+		Segments: []domain.Segment{
+			{ID: id, Rank: 1, RolloutPercent: 100, Distributions: []domain.Distribution{{ID: id, VariantID: 1, Percent: 100}}},
+		},
+	}
+}
+
+func TestClient_Sources(t *testing.T) {
+	us := flagrtest.NewServer(themeFlag(1, "us"))
+	defer us.Close()
+	eu := flagrtest.NewServer(themeFlag(1, "eu"), domain.Flag{ID: 2, Key: "legacy"})
+	defer eu.Close()
+	beta := flagrtest.NewServer(themeFlag(1, "beta"))
+	defer beta.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(us.URL),
+		WithRefreshInterval(0),
+		WithSource(SourceConfig{
+			Name:   "eu",
+			Prefix: "eu/",
+			Flagr:  FlagrConfig{Endpoint: eu.URL},
+			Filter: FilterConfig{OnlyEnabled: true},
+		}),
+		WithSource(SourceConfig{Name: "beta", Flagr: FlagrConfig{Endpoint: beta.URL}}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	evalCtx := NewContext("user-1")
+	assert.Equal(t, "us", client.String(ctx, "theme", evalCtx, ""), "unprefixed keys go to the default source")
+	assert.Equal(t, "eu", client.String(ctx, "eu/theme", evalCtx, ""), "routed by prefix")
+	assert.Equal(t, "eu", client.String(ctx, "theme", evalCtx.WithSource("eu"), ""), "routed by name")
+	assert.Equal(t, "eu", client.String(ctx, "eu/theme", evalCtx.WithSource("eu"), ""))
+	assert.Equal(t, "beta", client.String(ctx, "theme", evalCtx.WithSource("beta"), ""))
+
+	assert.Equal(t, "none", client.String(ctx, "theme", evalCtx.WithSource("apac"), "none"))
+	_, err = client.Evaluate(ctx, "theme", evalCtx.WithSource("apac"))
+	assert.EqualError(t, err, `evaluation error for flag theme: unknown source "apac"`)
+
+	exp, err := client.Explain(ctx, "eu/theme", evalCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "eu", exp.Result.VariantKey)
+
+	// Metrics are broken down per source, with each source's own filter
+	metrics := client.Metrics()
+	require.Len(t, metrics.Sources, 3)
+	assert.Equal(t, "default", metrics.Sources[0].Name)
+	assert.Equal(t, 1, metrics.Sources[0].Flags)
+	assert.Equal(t, "eu", metrics.Sources[1].Name)
+	assert.Equal(t, "eu/", metrics.Sources[1].Prefix)
+	assert.Equal(t, 1, metrics.Sources[1].Flags, "disabled flags of the EU source are filtered out")
+	assert.Equal(t, "beta", metrics.Sources[2].Name)
+	assert.False(t, metrics.Sources[1].LastRefresh.IsZero())
+
+	// Invalidation is routed as well
+	require.NoError(t, client.InvalidateFlag(ctx, "eu/theme"))
+	assert.Equal(t, 0, client.Metrics().Sources[1].Flags)
+	assert.Equal(t, 1, client.Metrics().Sources[0].Flags)
+
+	require.NoError(t, client.Sync(ctx))
+	assert.Equal(t, 1, client.Metrics().Sources[1].Flags)
+}
+
+func TestClient_SourcesWithoutDefault(t *testing.T) {
+	eu := flagrtest.NewServer(themeFlag(1, "eu"))
+	defer eu.Close()
+	apac := flagrtest.NewServer(themeFlag(1, "apac"))
+	defer apac.Close()
+
+	client, err := New(
+		WithRefreshInterval(0),
+		WithSource(SourceConfig{Name: "eu", Prefix: "eu/", Flagr: FlagrConfig{Endpoint: eu.URL}}),
+		WithSource(SourceConfig{Name: "apac", Prefix: "apac/", Flagr: FlagrConfig{Endpoint: apac.URL}}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	evalCtx := NewContext("user-1")
+	assert.Equal(t, "eu", client.String(ctx, "theme", evalCtx, ""), "the first source is the default")
+	assert.Equal(t, "apac", client.String(ctx, "apac/theme", evalCtx, ""))
+
+	// Errors name the source
+	apac.FailWith(503)
+	err = client.Sync(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "source apac: failed to fetch flags")
+}
+
+func TestClient_SourceStartError(t *testing.T) {
+	us := flagrtest.NewServer(themeFlag(1, "us"))
+	defer us.Close()
+	eu := flagrtest.NewServer()
+	defer eu.Close()
+	eu.FailWith(503)
+
+	client, err := New(
+		WithFlagrEndpoint(us.URL),
+		WithFlagrMaxRetries(0),
+		WithSource(SourceConfig{Name: "eu", Prefix: "eu/", Flagr: FlagrConfig{Endpoint: eu.URL}}),
+	)
+	require.NoError(t, err)
+
+	err = client.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "source eu: initial flag load failed")
+}
+
+func TestWithSource(t *testing.T) {
+	endpoint := FlagrConfig{Endpoint: "http://localhost:18000"}
+	tests := []struct {
+		name    string
+		sources []SourceConfig
+		err     string
+	}{
+		{"missing name", []SourceConfig{{Flagr: endpoint}}, "source name cannot be empty"},
+		{"reserved name", []SourceConfig{{Name: "default", Flagr: endpoint}}, `source name "default" is reserved`},
+		{"missing endpoint", []SourceConfig{{Name: "eu"}}, "source eu: flagr endpoint cannot be empty"},
+		{"invalid match mode", []SourceConfig{{Name: "eu", Flagr: endpoint, Filter: FilterConfig{TagMatchMode: "some"}}}, "source eu: tag match mode must be 'any' or 'all'"},
+		{"duplicate name", []SourceConfig{{Name: "eu", Flagr: endpoint}, {Name: "eu", Flagr: endpoint}}, "source eu is configured twice"},
+		{"duplicate prefix", []SourceConfig{{Name: "eu", Prefix: "eu/", Flagr: endpoint}, {Name: "eu2", Prefix: "eu/", Flagr: endpoint}}, `sources eu and eu2 have the same prefix "eu/"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			for _, src := range tt.sources {
+				opts = append(opts, WithSource(src))
+			}
+			_, err := New(opts...)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	sources := []*source{{name: "default"}, {name: "eu", prefix: "eu/"}, {name: "eu-west", prefix: "eu/west/"}}
+
+	s, key, err := route(sources, "eu/west/theme", "")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west", s.name, "longest prefix wins")
+	assert.Equal(t, "theme", key)
+
+	s, key, _ = route(sources, "europe-theme", "")
+	assert.Equal(t, "default", s.name)
+	assert.Equal(t, "europe-theme", key)
+
+	s, key, _ = route(sources, "theme", "eu")
+	assert.Equal(t, "eu", s.name)
+	assert.Equal(t, "theme", key)
+}
+
+func TestCacheAdapter_Sources(t *testing.T) {
+	us := flagrtest.NewServer(themeFlag(1, "us"))
+	defer us.Close()
+	eu := flagrtest.NewServer(themeFlag(1, "eu"))
+	defer eu.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(us.URL),
+		WithRefreshInterval(0),
+		WithSource(SourceConfig{Name: "eu", Prefix: "eu/", Flagr: FlagrConfig{Endpoint: eu.URL}}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop()
+
+	adapter := &cacheAdapter{cache: client.cache, sources: client.sources}
+
+	assert.Equal(t, []string{"default", "eu"}, adapter.Sources())
+	assert.Equal(t, []string{"theme", "eu/theme"}, adapter.FlagKeys(""))
+	assert.Equal(t, []string{"eu/theme"}, adapter.FlagKeys("eu"))
+
+	metrics, ok := adapter.GetMetrics().(adminMetrics)
+	require.True(t, ok, "several sources are broken down")
+	require.Len(t, metrics.Sources, 2)
+	assert.Equal(t, "eu", metrics.Sources[1].Name)
+
+	report, err := adapter.ExplainFlag("theme", server.EvaluateRequest{EntityID: "user-1", Source: "eu"})
+	require.NoError(t, err)
+	assert.Equal(t, "eu", report.Explanation.(*Explanation).Result.VariantKey)
+
+	assert.NoError(t, adapter.RefreshSource("eu"))
+	assert.Error(t, adapter.RefreshSource("apac"))
+}
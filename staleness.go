@@ -0,0 +1,84 @@
+package vexilla
+
+import (
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/cache"
+)
+
+// Stale policies of WithMaxStaleness
+const (
+	// StaleServe evaluates stale flags as usual
+	StaleServe = cache.StaleServe
+
+	// StaleMark evaluates stale flags with EvaluationReason ReasonStale
+	StaleMark = cache.StaleMark
+
+	// StaleFallback answers stale flags with the fallback strategy
+	StaleFallback = cache.StaleFallback
+)
+
+// ReasonStale is the EvaluationReason of stale flags under StaleMark.
+const ReasonStale = cache.ReasonStale
+
+// FlagStaleness is how fresh the cached definition of a flag is.
+type FlagStaleness struct {
+	// SyncedAt is when Flagr last returned the flag, zero when it was
+	// restored from a disk snapshot of unknown age
+	SyncedAt time.Time
+
+	// Age is how long ago that was
+	Age time.Duration
+
+	// Stale reports whether Age exceeds the max staleness (see
+	// WithMaxStaleness)
+	Stale bool
+}
+
+// StaleMetrics reports flags past the max staleness and how their
+// evaluations were answered (see WithMaxStaleness).
+type StaleMetrics struct {
+	// Flags is the number of cached flags past the max staleness
+	Flags int
+
+	// OldestSync is when the least recently synced flag was last returned
+	// by Flagr
+	OldestSync time.Time
+
+	// Served counts the evaluations of stale flags that were served
+	Served uint64
+
+	// FellBack counts the evaluations of stale flags answered by the
+	// fallback strategy
+	FellBack uint64
+}
+
+// Staleness returns how fresh the cached definition of a flag is, or false
+// when the flag is not cached. The source of the flag is picked by the key
+// prefix.
+func (c *Client) Staleness(flagKey string) (FlagStaleness, bool) {
+	s, key, err := c.route(flagKey, Context{})
+	if err != nil {
+		return FlagStaleness{}, false
+	}
+
+	syncedAt, ok := s.cache.FlagSyncedAt(key)
+	if !ok {
+		return FlagStaleness{}, false
+	}
+
+	staleness := FlagStaleness{SyncedAt: syncedAt, Stale: s.cache.IsStale(key)}
+	if !syncedAt.IsZero() {
+		staleness.Age = time.Since(syncedAt)
+	}
+	return staleness, true
+}
+
+func toStaleMetrics(m cache.Metrics) StaleMetrics {
+	return StaleMetrics{
+		Flags:      m.StaleFlags,
+		OldestSync: m.OldestSync,
+		Served:     m.StaleServed,
+		FellBack:   m.StaleFellBack,
+	}
+}
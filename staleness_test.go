@@ -0,0 +1,68 @@
+package vexilla
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxStaleness(t *testing.T) {
+	cfg := &clientConfig{}
+	require.NoError(t, WithMaxStaleness(time.Hour, StaleMark)(cfg))
+	assert.Equal(t, time.Hour, cfg.maxStaleness)
+	assert.Equal(t, StaleMark, cfg.stalePolicy)
+
+	assert.Error(t, WithMaxStaleness(0, StaleServe)(&clientConfig{}))
+	assert.Error(t, WithMaxStaleness(time.Hour, "expire")(&clientConfig{}))
+}
+
+func TestClient_StaleFlags(t *testing.T) {
+	srv := flagrtest.NewServer(themeFlag(1, "dark"))
+	defer srv.Close()
+
+	client, err := New(
+		WithFlagrEndpoint(srv.URL),
+		WithMaxStaleness(500*time.Millisecond, StaleMark),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	result, err := client.Evaluate(ctx, "theme", Context{EntityID: "u1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, ReasonStale, result.EvaluationReason)
+
+	staleness, ok := client.Staleness("theme")
+	require.True(t, ok)
+	assert.False(t, staleness.Stale)
+	assert.False(t, staleness.SyncedAt.IsZero())
+
+	// Flagr goes down and no sync succeeds past the max staleness
+	srv.FailWith(http.StatusServiceUnavailable)
+	assert.Error(t, client.Sync(ctx))
+	time.Sleep(600 * time.Millisecond)
+
+	result, err = client.Evaluate(ctx, "theme", Context{EntityID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, "dark", result.VariantKey, "the last known good flag is served")
+	assert.Equal(t, ReasonStale, result.EvaluationReason)
+
+	staleness, ok = client.Staleness("theme")
+	require.True(t, ok)
+	assert.True(t, staleness.Stale)
+	assert.Greater(t, staleness.Age, 500*time.Millisecond)
+
+	_, ok = client.Staleness("missing")
+	assert.False(t, ok)
+
+	metrics := client.Metrics()
+	assert.Equal(t, 1, metrics.Stale.Flags)
+	assert.Equal(t, uint64(1), metrics.Stale.Served)
+	assert.Equal(t, metrics.Stale, metrics.Sources[0].Stale)
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"strings"
 	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
 )
 
 // Context holds user/request context for flag evaluation.
@@ -18,6 +20,10 @@ type Context struct {
 	// Attributes contains additional context for constraint evaluation
 	// (e.g., country, tier, age, etc.)
 	Attributes map[string]any
+
+	// Source names the flag source to evaluate in (see WithSource); when
+	// empty the source is picked by the flag key prefix
+	Source string
 }
 
 // NewContext creates a new evaluation context with the given entity ID.
@@ -38,6 +44,40 @@ func (c Context) WithAttribute(key string, value any) Context {
 	return c
 }
 
+// FromStruct copies the exported fields of a struct into the attributes
+// (fluent interface).
+//
+// Attribute names come from the `vexilla` struct tag, falling back to the
+// `json` tag and then the field name. Use "-" to skip a field and
+// "omitempty" to skip zero values. Nested structs become nested attributes
+// that constraints can address with dotted properties such as "account.region".
+//
+// Example:
+//
+//	type User struct {
+//	    Country string  `vexilla:"country"`
+//	    Account Account `vexilla:"account"`
+//	    Email   string  `vexilla:"-"`
+//	}
+//
+//	evalCtx := vexilla.NewContext("user-123").FromStruct(user)
+func (c Context) FromStruct(v any) Context {
+	if c.Attributes == nil {
+		c.Attributes = make(map[string]any)
+	}
+	for key, val := range domain.StructToAttributes(v) {
+		c.Attributes[key] = val
+	}
+	return c
+}
+
+// WithSource evaluates flags in the named source, whatever the flag key
+// prefix (fluent interface).
+func (c Context) WithSource(name string) Context {
+	c.Source = name
+	return c
+}
+
 // WithEntityType sets the entity type (fluent interface).
 func (c Context) WithEntityType(entityType string) Context {
 	c.EntityType = entityType
@@ -158,6 +198,16 @@ type Metrics struct {
 
 	// CircuitOpen indicates if the circuit breaker is open
 	CircuitOpen bool
+
+	// Namespaces are the loaded namespaces (see WithNamespaces and
+	// WithLazyNamespaces)
+	Namespaces []string
+
+	// Stale reports flags past the max staleness (see WithMaxStaleness)
+	Stale StaleMetrics
+
+	// Sources are the metrics of every flag source, the default first
+	Sources []SourceMetrics
 }
 
 // StorageMetrics represents storage layer metrics.
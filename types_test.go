@@ -34,6 +34,40 @@ func TestContext_WithEntityType(t *testing.T) {
 	assert.Equal(t, "device", ctx.EntityType)
 }
 
+// TestContext_FromStruct tests building attributes from struct tags
+func TestContext_FromStruct(t *testing.T) {
+	type account struct {
+		Region string `vexilla:"region"`
+	}
+	type user struct {
+		Country string  `vexilla:"country"`
+		Tier    string  `json:"tier"`
+		Account account `vexilla:"account"`
+		Email   string  `vexilla:"-"`
+		Beta    bool    `vexilla:"beta,omitempty"`
+	}
+
+	ctx := NewContext("user-123").
+		WithAttribute("existing", "kept").
+		FromStruct(user{Country: "BR", Tier: "premium", Account: account{Region: "sa-east-1"}, Email: "x@y.z"})
+
+	assert.Equal(t, "kept", ctx.Attributes["existing"])
+	assert.Equal(t, "BR", ctx.Attributes["country"])
+	assert.Equal(t, "premium", ctx.Attributes["tier"])
+	assert.Equal(t, map[string]any{"region": "sa-east-1"}, ctx.Attributes["account"])
+	assert.NotContains(t, ctx.Attributes, "Email")
+	assert.NotContains(t, ctx.Attributes, "beta")
+
+	// Nested attributes are addressable with dotted properties
+	region, ok := toDomainContext(ctx).Lookup("account.region")
+	assert.True(t, ok)
+	assert.Equal(t, "sa-east-1", region)
+
+	// Nil attributes map is initialized
+	empty := Context{EntityID: "user-456"}.FromStruct(&user{Country: "US"})
+	assert.Equal(t, "US", empty.Attributes["country"])
+}
+
 // TestResult_IsEnabled tests various enabled/disabled scenarios
 func TestResult_IsEnabled(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,206 @@
+// Package vexillagrpc propagates the vexilla evaluation context through
+// gRPC calls, so every service in a call chain evaluates flags for the
+// same entity.
+//
+// Server interceptors build a vexilla.Context from the incoming metadata
+// and attach it to the request context, where vexilla.FromContext finds it.
+// Client interceptors forward the entity ID, entity type and selected
+// attributes of the context carried by the outgoing call as metadata.
+//
+// Example:
+//
+//	opts := []vexillagrpc.Option{vexillagrpc.WithAttribute("x-country", "country")}
+//
+//	srv := grpc.NewServer(
+//	    grpc.UnaryInterceptor(vexillagrpc.UnaryServerInterceptor(opts...)),
+//	    grpc.StreamInterceptor(vexillagrpc.StreamServerInterceptor(opts...)),
+//	)
+//
+//	conn, err := grpc.NewClient(target,
+//	    grpc.WithUnaryInterceptor(vexillagrpc.UnaryClientInterceptor(opts...)),
+//	    grpc.WithStreamInterceptor(vexillagrpc.StreamClientInterceptor(opts...)),
+//	)
+//
+//	func (s *service) Checkout(ctx context.Context, req *pb.CheckoutRequest) (*pb.CheckoutReply, error) {
+//	    evalCtx, _ := vexilla.FromContext(ctx)
+//	    if s.flags.Bool(ctx, "new-checkout", evalCtx) {
+//	        ...
+//	    }
+//	}
+package vexillagrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OrlandoBitencourt/vexilla"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultEntityKey is the metadata key carrying the entity ID
+const DefaultEntityKey = "x-vexilla-entity-id"
+
+// DefaultEntityTypeKey is the metadata key carrying the entity type
+const DefaultEntityTypeKey = "x-vexilla-entity-type"
+
+// Extractor fills the evaluation context with data from incoming metadata
+type Extractor func(md metadata.MD, evalCtx vexilla.Context) vexilla.Context
+
+// Option configures the interceptors
+type Option func(*config)
+
+type config struct {
+	entityKey     string
+	entityTypeKey string
+	attributes    map[string]string
+	extractors    []Extractor
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		entityKey:     DefaultEntityKey,
+		entityTypeKey: DefaultEntityTypeKey,
+		attributes:    make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithEntityKey sets the metadata key carrying the entity ID
+// (default "x-vexilla-entity-id").
+func WithEntityKey(key string) Option {
+	return func(c *config) {
+		c.entityKey = key
+	}
+}
+
+// WithEntityTypeKey sets the metadata key carrying the entity type
+// (default "x-vexilla-entity-type").
+func WithEntityTypeKey(key string) Option {
+	return func(c *config) {
+		c.entityTypeKey = key
+	}
+}
+
+// WithAttribute maps a metadata key to an attribute. Server interceptors
+// read the key into the attribute, client interceptors forward the
+// attribute under the key. Metadata values are strings, so forwarded
+// attributes arrive as strings.
+//
+// Example: vexillagrpc.WithAttribute("x-country", "country")
+func WithAttribute(metadataKey, attribute string) Option {
+	return func(c *config) {
+		c.attributes[metadataKey] = attribute
+	}
+}
+
+// WithExtractor adds a custom extractor run by the server interceptors
+// after the entity and attributes are read.
+func WithExtractor(extract Extractor) Option {
+	return func(c *config) {
+		c.extractors = append(c.extractors, extract)
+	}
+}
+
+// UnaryServerInterceptor attaches the evaluation context built from the
+// incoming metadata to the request context.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(c.incoming(ctx), req)
+	}
+}
+
+// StreamServerInterceptor attaches the evaluation context built from the
+// incoming metadata to the stream context.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	c := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: c.incoming(ss.Context())})
+	}
+}
+
+// UnaryClientInterceptor forwards the evaluation context carried by the
+// call context as outgoing metadata.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		return invoker(c.outgoing(ctx), method, req, reply, cc, callOpts...)
+	}
+}
+
+// StreamClientInterceptor forwards the evaluation context carried by the
+// stream context as outgoing metadata.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(c.outgoing(ctx), desc, cc, method, callOpts...)
+	}
+}
+
+// incoming attaches the evaluation context of the incoming metadata
+func (c *config) incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	evalCtx := vexilla.NewContext(first(md, c.entityKey))
+	if entityType := first(md, c.entityTypeKey); entityType != "" {
+		evalCtx = evalCtx.WithEntityType(entityType)
+	}
+	for key, attribute := range c.attributes {
+		if value := first(md, key); value != "" {
+			evalCtx = evalCtx.WithAttribute(attribute, value)
+		}
+	}
+	for _, extract := range c.extractors {
+		evalCtx = extract(md, evalCtx)
+	}
+
+	return vexilla.NewContextWith(ctx, evalCtx)
+}
+
+// outgoing appends the evaluation context carried by ctx to the outgoing
+// metadata; calls without one are left unchanged
+func (c *config) outgoing(ctx context.Context) context.Context {
+	evalCtx, ok := vexilla.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	var pairs []string
+	if evalCtx.EntityID != "" {
+		pairs = append(pairs, c.entityKey, evalCtx.EntityID)
+	}
+	if evalCtx.EntityType != "" {
+		pairs = append(pairs, c.entityTypeKey, evalCtx.EntityType)
+	}
+	for key, attribute := range c.attributes {
+		if value, ok := evalCtx.Attributes[attribute]; ok {
+			pairs = append(pairs, key, fmt.Sprint(value))
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// first returns the first value of a metadata key
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// serverStream overrides the context of a server stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,199 @@
+package vexillagrpc_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla"
+	"github.com/OrlandoBitencourt/vexilla/vexillagrpc"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// service records the evaluation context of every call. It forwards calls
+// to next, or answers SERVING when the new-checkout flag is on.
+type service struct {
+	healthpb.UnimplementedHealthServer
+
+	flags *vexillatest.Client
+	next  healthpb.HealthClient
+
+	mu   sync.Mutex
+	seen []vexilla.Context
+}
+
+func (s *service) record(ctx context.Context) vexilla.Context {
+	evalCtx, _ := vexilla.FromContext(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen = append(s.seen, evalCtx)
+	return evalCtx
+}
+
+func (s *service) status(ctx context.Context, evalCtx vexilla.Context) *healthpb.HealthCheckResponse {
+	if s.flags.Bool(ctx, "new-checkout", evalCtx) {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}
+}
+
+func (s *service) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	evalCtx := s.record(ctx)
+	if s.next != nil {
+		return s.next.Check(ctx, req)
+	}
+	return s.status(ctx, evalCtx), nil
+}
+
+func (s *service) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	evalCtx := s.record(ctx)
+	if s.next == nil {
+		return stream.Send(s.status(ctx, evalCtx))
+	}
+
+	watch, err := s.next.Watch(ctx, req)
+	if err != nil {
+		return err
+	}
+	resp, err := watch.Recv()
+	if err != nil {
+		return err
+	}
+	return stream.Send(resp)
+}
+
+// serve starts the service on an in-process listener and returns a client
+// connection to it, both using the interceptors
+func serve(t *testing.T, svc *service, opts ...vexillagrpc.Option) healthpb.HealthClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(vexillagrpc.UnaryServerInterceptor(opts...)),
+		grpc.StreamInterceptor(vexillagrpc.StreamServerInterceptor(opts...)),
+	)
+	healthpb.RegisterHealthServer(srv, svc)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(vexillagrpc.UnaryClientInterceptor(opts...)),
+		grpc.WithStreamInterceptor(vexillagrpc.StreamClientInterceptor(opts...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+// chain starts a frontend service calling a backend service that
+// evaluates the new-checkout flag
+func chain(t *testing.T, opts ...vexillagrpc.Option) (healthpb.HealthClient, *service, *service) {
+	flags := vexillatest.NewClient(
+		vexillatest.BoolFlag("new-checkout", false).
+			Segment(vexillatest.Segment().Where("country", "EQ", "BR").Serve("enabled")),
+	)
+	backend := &service{flags: flags}
+	frontend := &service{flags: flags, next: serve(t, backend, opts...)}
+	return serve(t, frontend, opts...), frontend, backend
+}
+
+func TestInterceptors_Unary(t *testing.T) {
+	client, frontend, backend := chain(t, vexillagrpc.WithAttribute("x-country", "country"))
+
+	ctx := vexilla.NewContextWith(context.Background(), vexilla.NewContext("user-1").WithAttribute("country", "BR"))
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	ctx = vexilla.NewContextWith(context.Background(), vexilla.NewContext("user-2").WithAttribute("country", "US"))
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	// Every service of the chain saw the same entity
+	for _, svc := range []*service{frontend, backend} {
+		require.Len(t, svc.seen, 2)
+		assert.Equal(t, "user-1", svc.seen[0].EntityID)
+		assert.Equal(t, map[string]any{"country": "BR"}, svc.seen[0].Attributes)
+		assert.Equal(t, "user-2", svc.seen[1].EntityID)
+	}
+}
+
+func TestInterceptors_Stream(t *testing.T) {
+	client, frontend, backend := chain(t, vexillagrpc.WithAttribute("x-country", "country"))
+
+	ctx := vexilla.NewContextWith(context.Background(), vexilla.NewContext("user-1").WithAttribute("country", "BR"))
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	resp, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	for _, svc := range []*service{frontend, backend} {
+		require.Len(t, svc.seen, 1)
+		assert.Equal(t, "user-1", svc.seen[0].EntityID)
+		assert.Equal(t, "BR", svc.seen[0].Attributes["country"])
+	}
+}
+
+func TestInterceptors_Options(t *testing.T) {
+	client, _, backend := chain(t,
+		vexillagrpc.WithEntityKey("x-account-id"),
+		vexillagrpc.WithAttribute("x-tier", "tier"),
+		vexillagrpc.WithExtractor(func(md metadata.MD, evalCtx vexilla.Context) vexilla.Context {
+			return evalCtx.WithEntityType("account")
+		}),
+	)
+
+	// Only mapped attributes are forwarded, as strings
+	evalCtx := vexilla.NewContext("acct-9").WithAttribute("tier", 3).WithAttribute("email", "a@b.c")
+	_, err := client.Check(vexilla.NewContextWith(context.Background(), evalCtx), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	require.Len(t, backend.seen, 1)
+	assert.Equal(t, "acct-9", backend.seen[0].EntityID)
+	assert.Equal(t, "account", backend.seen[0].EntityType)
+	assert.Equal(t, map[string]any{"tier": "3"}, backend.seen[0].Attributes)
+
+	// Calls without an evaluation context reach the services with an empty entity
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Len(t, backend.seen, 2)
+	assert.Empty(t, backend.seen[1].EntityID)
+}
+
+func TestInterceptors_EntityType(t *testing.T) {
+	client, frontend, backend := chain(t)
+
+	ctx := vexilla.NewContextWith(context.Background(), vexilla.NewContext("acct-9").WithEntityType("account"))
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	for _, svc := range []*service{frontend, backend} {
+		require.Len(t, svc.seen, 1)
+		assert.Equal(t, "acct-9", svc.seen[0].EntityID)
+		assert.Equal(t, "account", svc.seen[0].EntityType)
+	}
+
+	// A custom key must match on both ends
+	client, _, backend = chain(t, vexillagrpc.WithEntityTypeKey("x-entity-kind"))
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Len(t, backend.seen, 1)
+	assert.Equal(t, "account", backend.seen[0].EntityType)
+}
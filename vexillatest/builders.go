@@ -0,0 +1,260 @@
+package vexillatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+)
+
+// FlagBuilder describes a flag served by a fake client.
+type FlagBuilder struct {
+	key            string
+	description    string
+	disabled       bool
+	tags           []string
+	variants       []*VariantBuilder
+	segments       []*SegmentBuilder
+	defaultVariant string
+}
+
+// Flag starts an enabled flag with no variants or segments.
+//
+// Example:
+//
+//	vexillatest.Flag("new-checkout").
+//	    Variant(vexillatest.Variant("on").With("enabled", true)).
+//	    Variant(vexillatest.Variant("off").With("enabled", false)).
+//	    Segment(vexillatest.Segment().Where("country", "EQ", "BR").Serve("on")).
+//	    Default("off")
+func Flag(key string) *FlagBuilder {
+	return &FlagBuilder{key: key}
+}
+
+// BoolFlag is a flag that serves the "enabled" or "disabled" variant to everyone.
+func BoolFlag(key string, enabled bool) *FlagBuilder {
+	serve := "disabled"
+	if enabled {
+		serve = "enabled"
+	}
+	return Flag(key).
+		Variant(Variant("enabled").With("enabled", true)).
+		Variant(Variant("disabled").With("enabled", false)).
+		Default(serve)
+}
+
+// StringFlag is a flag that serves value to everyone.
+func StringFlag(key, value string) *FlagBuilder {
+	return Flag(key).
+		Variant(Variant(value).With("value", value)).
+		Default(value)
+}
+
+// IntFlag is a flag that serves value to everyone.
+func IntFlag(key string, value int) *FlagBuilder {
+	variant := strconv.Itoa(value)
+	return Flag(key).
+		Variant(Variant(variant).With("value", value)).
+		Default(variant)
+}
+
+// Description sets the flag description.
+func (b *FlagBuilder) Description(description string) *FlagBuilder {
+	b.description = description
+	return b
+}
+
+// Disabled marks the flag as disabled.
+func (b *FlagBuilder) Disabled() *FlagBuilder {
+	b.disabled = true
+	return b
+}
+
+// Tags adds tags to the flag.
+func (b *FlagBuilder) Tags(tags ...string) *FlagBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// Variant declares a variant. Variants referenced by segments are declared
+// automatically (without attachment) when missing.
+func (b *FlagBuilder) Variant(v *VariantBuilder) *FlagBuilder {
+	b.variants = append(b.variants, v)
+	return b
+}
+
+// Segment appends a segment; segments are evaluated in the order added.
+func (b *FlagBuilder) Segment(s *SegmentBuilder) *FlagBuilder {
+	b.segments = append(b.segments, s)
+	return b
+}
+
+// Default serves variantKey to entities that match no segment.
+func (b *FlagBuilder) Default(variantKey string) *FlagBuilder {
+	b.defaultVariant = variantKey
+	return b
+}
+
+// build converts the builder into a flag with the given ID
+func (b *FlagBuilder) build(id int64) (domain.Flag, error) {
+	flag := domain.Flag{
+		ID:          id,
+		Key:         b.key,
+		Description: b.description,
+		Enabled:     !b.disabled,
+	}
+	if b.key == "" {
+		return flag, fmt.Errorf("flag key is required")
+	}
+
+	for _, tag := range b.tags {
+		flag.Tags = append(flag.Tags, domain.Tag{Value: tag})
+	}
+
+	variantIDs := make(map[string]int64)
+	variantID := func(key string) int64 {
+		if id, ok := variantIDs[key]; ok {
+			return id
+		}
+		id := int64(len(flag.Variants) + 1)
+		variantIDs[key] = id
+		flag.Variants = append(flag.Variants, domain.Variant{ID: id, Key: key})
+		return id
+	}
+
+	for _, v := range b.variants {
+		attachment, err := v.attachment()
+		if err != nil {
+			return flag, fmt.Errorf("flag %s: %w", b.key, err)
+		}
+		id := variantID(v.key)
+		flag.Variants[id-1].Attachment = attachment
+	}
+
+	segments := b.segments
+	if b.defaultVariant != "" {
+		segments = append(segments, Segment().Description("default").Serve(b.defaultVariant))
+	}
+
+	var distributionID int64
+	for i, s := range segments {
+		if len(s.distributions) == 0 {
+			return flag, fmt.Errorf("flag %s segment %d: no variant (use Serve or Split)", b.key, i+1)
+		}
+
+		segment := domain.Segment{
+			ID:             int64(i + 1),
+			Rank:           i + 1,
+			Description:    s.description,
+			RolloutPercent: s.rollout,
+		}
+		for j, c := range s.constraints {
+			c.ID = int64(j + 1)
+			segment.Constraints = append(segment.Constraints, c)
+		}
+		for _, d := range s.distributions {
+			distributionID++
+			segment.Distributions = append(segment.Distributions, domain.Distribution{
+				ID:        distributionID,
+				VariantID: variantID(d.variantKey),
+				Percent:   d.percent,
+			})
+		}
+
+		flag.Segments = append(flag.Segments, segment)
+	}
+
+	return flag, nil
+}
+
+// VariantBuilder describes a flag variant.
+type VariantBuilder struct {
+	key    string
+	values map[string]any
+}
+
+// Variant starts a variant with no attachment.
+func Variant(key string) *VariantBuilder {
+	return &VariantBuilder{key: key}
+}
+
+// With adds a value to the variant attachment.
+func (v *VariantBuilder) With(key string, value any) *VariantBuilder {
+	if v.values == nil {
+		v.values = make(map[string]any)
+	}
+	v.values[key] = value
+	return v
+}
+
+func (v *VariantBuilder) attachment() (map[string]json.RawMessage, error) {
+	if len(v.values) == 0 {
+		return nil, nil
+	}
+
+	attachment := make(map[string]json.RawMessage, len(v.values))
+	for k, val := range v.values {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("variant %s: invalid attachment %q: %w", v.key, k, err)
+		}
+		attachment[k] = raw
+	}
+	return attachment, nil
+}
+
+// SegmentBuilder describes a targeting segment.
+type SegmentBuilder struct {
+	description   string
+	rollout       int
+	constraints   []domain.Constraint
+	distributions []distribution
+}
+
+type distribution struct {
+	variantKey string
+	percent    int
+}
+
+// Segment starts a segment that matches everyone with a 100% rollout.
+func Segment() *SegmentBuilder {
+	return &SegmentBuilder{rollout: 100}
+}
+
+// Description sets the segment description.
+func (s *SegmentBuilder) Description(description string) *SegmentBuilder {
+	s.description = description
+	return s
+}
+
+// Where adds a constraint; all constraints must match.
+// operator is a Flagr operator such as "EQ", "NEQ", "IN", "GT" or "MATCHES".
+func (s *SegmentBuilder) Where(property, operator string, value any) *SegmentBuilder {
+	s.constraints = append(s.constraints, domain.Constraint{
+		Property: property,
+		Operator: domain.Operator(operator),
+		Value:    value,
+	})
+	return s
+}
+
+// Rollout limits the segment to a percentage of the matching entities,
+// bucketed deterministically by entity ID.
+func (s *SegmentBuilder) Rollout(percent int) *SegmentBuilder {
+	s.rollout = percent
+	return s
+}
+
+// Serve sends every entity in the segment to variantKey.
+func (s *SegmentBuilder) Serve(variantKey string) *SegmentBuilder {
+	s.distributions = []distribution{{variantKey: variantKey, percent: 100}}
+	return s
+}
+
+// Split sends percent of the segment's entities to variantKey.
+// Call it once per variant of an A/B test.
+func (s *SegmentBuilder) Split(variantKey string, percent int) *SegmentBuilder {
+	s.distributions = append(s.distributions, distribution{variantKey: variantKey, percent: percent})
+	return s
+}
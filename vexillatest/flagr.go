@@ -0,0 +1,25 @@
+package vexillatest
+
+import (
+	"fmt"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest/flagrtest"
+)
+
+// NewFlagrServer starts a Flagr-compatible HTTP server serving the given
+// flags, for integration tests that exercise a client configured with
+// vexilla.WithFlagrEndpoint(srv.URL). Call Close when done.
+// It panics if a flag is invalid.
+func NewFlagrServer(flags ...*FlagBuilder) *flagrtest.Server {
+	built := make([]domain.Flag, 0, len(flags))
+	for i, b := range flags {
+		flag, err := b.build(int64(i + 1))
+		if err != nil {
+			panic(fmt.Sprintf("vexillatest: %v", err))
+		}
+		built = append(built, flag)
+	}
+
+	return flagrtest.NewServer(built...)
+}
@@ -0,0 +1,629 @@
+package flagrtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+)
+
+// registerAdminRoutes registers Flagr's write endpoints
+func (s *Server) registerAdminRoutes(mux *http.ServeMux) {
+	s.handle(mux, "POST /api/v1/flags", s.handleCreateFlag)
+	s.handle(mux, "PUT /api/v1/flags/{flagID}", s.handleUpdateFlag)
+	s.handle(mux, "DELETE /api/v1/flags/{flagID}", s.handleDeleteFlag)
+	s.handle(mux, "PUT /api/v1/flags/{flagID}/enabled", s.handleSetEnabled)
+
+	s.handle(mux, "GET /api/v1/flags/{flagID}/tags", s.handleListTags)
+	s.handle(mux, "POST /api/v1/flags/{flagID}/tags", s.handleAddTag)
+	s.handle(mux, "DELETE /api/v1/flags/{flagID}/tags/{tagID}", s.handleDeleteTag)
+
+	s.handle(mux, "POST /api/v1/flags/{flagID}/variants", s.handleCreateVariant)
+	s.handle(mux, "PUT /api/v1/flags/{flagID}/variants/{variantID}", s.handleUpdateVariant)
+	s.handle(mux, "DELETE /api/v1/flags/{flagID}/variants/{variantID}", s.handleDeleteVariant)
+
+	s.handle(mux, "POST /api/v1/flags/{flagID}/segments", s.handleCreateSegment)
+	s.handle(mux, "PUT /api/v1/flags/{flagID}/segments/reorder", s.handleReorderSegments)
+	s.handle(mux, "PUT /api/v1/flags/{flagID}/segments/{segmentID}", s.handleUpdateSegment)
+	s.handle(mux, "DELETE /api/v1/flags/{flagID}/segments/{segmentID}", s.handleDeleteSegment)
+
+	s.handle(mux, "POST /api/v1/flags/{flagID}/segments/{segmentID}/constraints", s.handleCreateConstraint)
+	s.handle(mux, "PUT /api/v1/flags/{flagID}/segments/{segmentID}/constraints/{constraintID}", s.handleUpdateConstraint)
+	s.handle(mux, "DELETE /api/v1/flags/{flagID}/segments/{segmentID}/constraints/{constraintID}", s.handleDeleteConstraint)
+
+	s.handle(mux, "PUT /api/v1/flags/{flagID}/segments/{segmentID}/distributions", s.handleSetDistributions)
+}
+
+// operators are the constraint operators the server accepts
+var operators = map[domain.Operator]bool{
+	domain.OperatorEQ:       true,
+	domain.OperatorNEQ:      true,
+	domain.OperatorLT:       true,
+	domain.OperatorLTE:      true,
+	domain.OperatorGT:       true,
+	domain.OperatorGTE:      true,
+	domain.OperatorIN:       true,
+	domain.OperatorNOTIN:    true,
+	domain.OperatorMATCHES:  true,
+	domain.OperatorCONTAINS: true,
+}
+
+// apiError is a failed write with its HTTP status
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func badRequest(format string, args ...interface{}) error {
+	return &apiError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &apiError{status: http.StatusNotFound, message: fmt.Sprintf(format, args...)}
+}
+
+// handleCreateFlag creates a disabled flag (POST /api/v1/flags)
+func (s *Server) handleCreateFlag(w http.ResponseWriter, r *http.Request) {
+	var req flagr.CreateFlagRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Key != "" && s.keyInUse(req.Key, 0) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("flag key %s already exists", req.Key))
+		return
+	}
+
+	s.lastID++
+	flag := domain.Flag{
+		ID:          s.lastID,
+		Key:         req.Key,
+		Description: req.Description,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if flag.Key == "" {
+		flag.Key = fmt.Sprintf("k%d", flag.ID)
+	}
+	s.store(flag)
+
+	writeJSON(w, http.StatusOK, s.flagrFlag(flag))
+}
+
+// handleUpdateFlag updates key and description (PUT /api/v1/flags/{flagID})
+func (s *Server) handleUpdateFlag(w http.ResponseWriter, r *http.Request) {
+	var req flagr.UpdateFlagRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		if req.Key != "" && req.Key != flag.Key {
+			if s.keyInUse(req.Key, flag.ID) {
+				return nil, badRequest("flag key %s already exists", req.Key)
+			}
+			flag.Key = req.Key
+		}
+		flag.Description = req.Description
+		if req.DataRecordsEnabled != nil {
+			flag.DataRecordsEnabled = *req.DataRecordsEnabled
+		}
+		return flag, nil
+	})
+}
+
+// handleDeleteFlag deletes a flag (DELETE /api/v1/flags/{flagID})
+func (s *Server) handleDeleteFlag(w http.ResponseWriter, r *http.Request) {
+	flagID, err := pathID(r, "flagID")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	_, ok := s.flags[flagID]
+	delete(s.flags, flagID)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("flag %d not found", flagID))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// handleSetEnabled enables or disables a flag
+// (PUT /api/v1/flags/{flagID}/enabled)
+func (s *Server) handleSetEnabled(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		flag.Enabled = *req.Enabled
+		return flag, nil
+	})
+}
+
+// handleListTags lists the tags of a flag (GET /api/v1/flags/{flagID}/tags)
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		return s.flagrFlag(*flag).Tags, nil
+	})
+}
+
+// handleAddTag tags a flag (POST /api/v1/flags/{flagID}/tags)
+func (s *Server) handleAddTag(w http.ResponseWriter, r *http.Request) {
+	var req flagr.Tag
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Value == "" {
+		writeError(w, http.StatusBadRequest, "tag value is required")
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		tag := flagr.Tag{ID: s.tagID(req.Value), Value: req.Value}
+		for _, t := range flag.Tags {
+			if t.Value == req.Value {
+				return tag, nil
+			}
+		}
+		flag.Tags = append(flag.Tags, domain.Tag{Value: req.Value})
+		return tag, nil
+	})
+}
+
+// handleDeleteTag removes a tag from a flag
+// (DELETE /api/v1/flags/{flagID}/tags/{tagID})
+func (s *Server) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		tagID, err := pathID(r, "tagID")
+		if err != nil {
+			return nil, err
+		}
+		for i, t := range flag.Tags {
+			if s.tags[t.Value] == tagID {
+				flag.Tags = append(flag.Tags[:i], flag.Tags[i+1:]...)
+				return struct{}{}, nil
+			}
+		}
+		return nil, notFound("tag %d not found", tagID)
+	})
+}
+
+// handleCreateVariant adds a variant (POST /api/v1/flags/{flagID}/variants)
+func (s *Server) handleCreateVariant(w http.ResponseWriter, r *http.Request) {
+	var req flagr.VariantRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		if req.Key == "" {
+			return nil, badRequest("variant key is required")
+		}
+		for _, v := range flag.Variants {
+			if v.Key == req.Key {
+				return nil, badRequest("variant key %s already exists", req.Key)
+			}
+		}
+
+		s.lastID++
+		variant := domain.Variant{ID: s.lastID, Key: req.Key, Attachment: req.Attachment}
+		flag.Variants = append(flag.Variants, variant)
+		return flagr.FlagrVariant{ID: variant.ID, Key: variant.Key, Attachment: variant.Attachment}, nil
+	})
+}
+
+// handleUpdateVariant updates a variant
+// (PUT /api/v1/flags/{flagID}/variants/{variantID})
+func (s *Server) handleUpdateVariant(w http.ResponseWriter, r *http.Request) {
+	var req flagr.VariantRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, err := variantIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+		if req.Key != "" {
+			flag.Variants[i].Key = req.Key
+		}
+		flag.Variants[i].Attachment = req.Attachment
+
+		v := flag.Variants[i]
+		return flagr.FlagrVariant{ID: v.ID, Key: v.Key, Attachment: v.Attachment}, nil
+	})
+}
+
+// handleDeleteVariant deletes a variant that no distribution uses
+// (DELETE /api/v1/flags/{flagID}/variants/{variantID})
+func (s *Server) handleDeleteVariant(w http.ResponseWriter, r *http.Request) {
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, err := variantIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+
+		variantID := flag.Variants[i].ID
+		for _, seg := range flag.Segments {
+			for _, d := range seg.Distributions {
+				if d.VariantID == variantID {
+					return nil, badRequest("variant %d is used by segment %d", variantID, seg.ID)
+				}
+			}
+		}
+
+		flag.Variants = append(flag.Variants[:i], flag.Variants[i+1:]...)
+		return struct{}{}, nil
+	})
+}
+
+// handleCreateSegment appends a segment (POST /api/v1/flags/{flagID}/segments)
+func (s *Server) handleCreateSegment(w http.ResponseWriter, r *http.Request) {
+	var req flagr.SegmentRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.RolloutPercent < 0 || req.RolloutPercent > 100 {
+		writeError(w, http.StatusBadRequest, "rolloutPercent must be between 0 and 100")
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		rank := 0
+		for _, seg := range flag.Segments {
+			if seg.Rank > rank {
+				rank = seg.Rank
+			}
+		}
+
+		s.lastID++
+		segment := domain.Segment{
+			ID:             s.lastID,
+			Rank:           rank + 1,
+			Description:    req.Description,
+			RolloutPercent: int(req.RolloutPercent),
+		}
+		flag.Segments = append(flag.Segments, segment)
+		return flagr.SegmentFromDomain(segment), nil
+	})
+}
+
+// handleUpdateSegment updates a segment
+// (PUT /api/v1/flags/{flagID}/segments/{segmentID})
+func (s *Server) handleUpdateSegment(w http.ResponseWriter, r *http.Request) {
+	var req flagr.SegmentRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.RolloutPercent < 0 || req.RolloutPercent > 100 {
+		writeError(w, http.StatusBadRequest, "rolloutPercent must be between 0 and 100")
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, err := segmentIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+		flag.Segments[i].Description = req.Description
+		flag.Segments[i].RolloutPercent = int(req.RolloutPercent)
+		return flagr.SegmentFromDomain(flag.Segments[i]), nil
+	})
+}
+
+// handleDeleteSegment deletes a segment
+// (DELETE /api/v1/flags/{flagID}/segments/{segmentID})
+func (s *Server) handleDeleteSegment(w http.ResponseWriter, r *http.Request) {
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, err := segmentIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+		flag.Segments = append(flag.Segments[:i], flag.Segments[i+1:]...)
+		return struct{}{}, nil
+	})
+}
+
+// handleReorderSegments sets segment ranks from the given order
+// (PUT /api/v1/flags/{flagID}/segments/reorder)
+func (s *Server) handleReorderSegments(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SegmentIDs []int64 `json:"segmentIDs"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		if len(req.SegmentIDs) != len(flag.Segments) {
+			return nil, badRequest("segmentIDs must list every segment of the flag")
+		}
+
+		ranks := make(map[int64]int, len(req.SegmentIDs))
+		for i, id := range req.SegmentIDs {
+			ranks[id] = i + 1
+		}
+		for i, seg := range flag.Segments {
+			rank, ok := ranks[seg.ID]
+			if !ok {
+				return nil, badRequest("segment %d is missing from segmentIDs", seg.ID)
+			}
+			flag.Segments[i].Rank = rank
+		}
+		return struct{}{}, nil
+	})
+}
+
+// handleCreateConstraint adds a constraint to a segment
+// (POST /api/v1/flags/{flagID}/segments/{segmentID}/constraints)
+func (s *Server) handleCreateConstraint(w http.ResponseWriter, r *http.Request) {
+	var req flagr.ConstraintRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Property == "" || req.Operator == "" {
+		writeError(w, http.StatusBadRequest, "property and operator are required")
+		return
+	}
+	if !operators[domain.Operator(strings.ToUpper(req.Operator))] {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown operator %s", req.Operator))
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, err := segmentIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+
+		s.lastID++
+		constraint := domain.Constraint{
+			ID:       s.lastID,
+			Property: req.Property,
+			Operator: domain.Operator(strings.ToUpper(req.Operator)),
+			Value:    req.Value,
+		}
+		flag.Segments[i].Constraints = append(flag.Segments[i].Constraints, constraint)
+		return flagr.ConstraintFromDomain(constraint), nil
+	})
+}
+
+// handleUpdateConstraint updates a constraint
+// (PUT /api/v1/flags/{flagID}/segments/{segmentID}/constraints/{constraintID})
+func (s *Server) handleUpdateConstraint(w http.ResponseWriter, r *http.Request) {
+	var req flagr.ConstraintRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Property == "" || req.Operator == "" {
+		writeError(w, http.StatusBadRequest, "property and operator are required")
+		return
+	}
+	if !operators[domain.Operator(strings.ToUpper(req.Operator))] {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown operator %s", req.Operator))
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, j, err := constraintIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+
+		constraint := &flag.Segments[i].Constraints[j]
+		constraint.Property = req.Property
+		constraint.Operator = domain.Operator(strings.ToUpper(req.Operator))
+		constraint.Value = req.Value
+		return flagr.ConstraintFromDomain(*constraint), nil
+	})
+}
+
+// handleDeleteConstraint deletes a constraint
+// (DELETE /api/v1/flags/{flagID}/segments/{segmentID}/constraints/{constraintID})
+func (s *Server) handleDeleteConstraint(w http.ResponseWriter, r *http.Request) {
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, j, err := constraintIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+
+		constraints := flag.Segments[i].Constraints
+		flag.Segments[i].Constraints = append(constraints[:j], constraints[j+1:]...)
+		return struct{}{}, nil
+	})
+}
+
+// handleSetDistributions replaces the distributions of a segment; the
+// percentages must add up to 100
+// (PUT /api/v1/flags/{flagID}/segments/{segmentID}/distributions)
+func (s *Server) handleSetDistributions(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Distributions []flagr.DistributionRequest `json:"distributions"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mutate(w, r, func(flag *domain.Flag) (interface{}, error) {
+		i, err := segmentIndex(r, flag)
+		if err != nil {
+			return nil, err
+		}
+
+		total := int64(0)
+		distributions := make([]domain.Distribution, 0, len(req.Distributions))
+		for _, d := range req.Distributions {
+			if _, ok := flag.GetVariantByID(d.VariantID); !ok {
+				return nil, badRequest("variant %d not found", d.VariantID)
+			}
+			total += d.Percent
+
+			s.lastID++
+			distributions = append(distributions, domain.Distribution{
+				ID:        s.lastID,
+				VariantID: d.VariantID,
+				Percent:   int(d.Percent),
+			})
+		}
+		if len(distributions) > 0 && total != 100 {
+			return nil, badRequest("distribution percentages must add up to 100, got %d", total)
+		}
+
+		flag.Segments[i].Distributions = distributions
+		return flagr.SegmentFromDomain(flag.Segments[i]).Distributions, nil
+	})
+}
+
+// mutate runs fn on a copy of the request's flag under the write lock and
+// stores the copy if fn succeeds. A *domain.Flag result is written in
+// Flagr's format.
+func (s *Server) mutate(w http.ResponseWriter, r *http.Request, fn func(flag *domain.Flag) (interface{}, error)) {
+	flagID, err := pathID(r, "flagID")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.flags[flagID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("flag %d not found", flagID))
+		return
+	}
+
+	// Handed-out copies share slices with the stored flag, so they are
+	// never modified in place
+	flag := cloneFlag(current)
+
+	result, err := fn(&flag)
+	if err != nil {
+		status := http.StatusBadRequest
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			status = apiErr.status
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		flag.UpdatedAt = time.Now().UTC()
+		s.store(flag)
+	}
+
+	if f, ok := result.(*domain.Flag); ok {
+		result = s.flagrFlag(*f)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// keyInUse reports whether another flag has key. s.mu must be held.
+func (s *Server) keyInUse(key string, exceptID int64) bool {
+	for _, f := range s.flags {
+		if f.Key == key && f.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneFlag(f domain.Flag) domain.Flag {
+	f.Tags = append([]domain.Tag(nil), f.Tags...)
+	f.Variants = append([]domain.Variant(nil), f.Variants...)
+
+	segments := make([]domain.Segment, len(f.Segments))
+	for i, seg := range f.Segments {
+		seg.Constraints = append([]domain.Constraint(nil), seg.Constraints...)
+		seg.Distributions = append([]domain.Distribution(nil), seg.Distributions...)
+		segments[i] = seg
+	}
+	f.Segments = segments
+
+	return f
+}
+
+func pathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		return 0, badRequest("invalid %s", name)
+	}
+	return id, nil
+}
+
+func variantIndex(r *http.Request, flag *domain.Flag) (int, error) {
+	id, err := pathID(r, "variantID")
+	if err != nil {
+		return 0, err
+	}
+	for i, v := range flag.Variants {
+		if v.ID == id {
+			return i, nil
+		}
+	}
+	return 0, notFound("variant %d not found", id)
+}
+
+func segmentIndex(r *http.Request, flag *domain.Flag) (int, error) {
+	id, err := pathID(r, "segmentID")
+	if err != nil {
+		return 0, err
+	}
+	for i, seg := range flag.Segments {
+		if seg.ID == id {
+			return i, nil
+		}
+	}
+	return 0, notFound("segment %d not found", id)
+}
+
+func constraintIndex(r *http.Request, flag *domain.Flag) (int, int, error) {
+	i, err := segmentIndex(r, flag)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := pathID(r, "constraintID")
+	if err != nil {
+		return 0, 0, err
+	}
+	for j, c := range flag.Segments[i].Constraints {
+		if c.ID == id {
+			return i, j, nil
+		}
+	}
+	return 0, 0, notFound("constraint %d not found", id)
+}
+
+// decode reads a JSON request body, writing a 400 response on failure
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,542 @@
+// Package flagrtest provides an in-process Flagr stand-in for integration
+// tests, so that code talking to Flagr over HTTP can be tested without
+// docker-compose.
+//
+// The server implements the read and evaluation endpoints used by vexilla:
+//
+//	GET  /api/v1/flags               (enabled, key, tags, preload, limit, offset)
+//	GET  /api/v1/flags/{flagID}
+//	POST /api/v1/evaluation
+//	POST /api/v1/evaluation/batch
+//	GET  /api/v1/health
+//
+// and the write endpoints used by flagr.AdminClient to create, update and
+// delete flags, tags, variants, segments, constraints and distributions.
+//
+// Flags are evaluated with Flagr's semantics: evaluation stops at the first
+// segment whose constraints match, and disabled flags, entities that match
+// no segment and entities outside that segment's rollout get an empty
+// variant. Latency, 5xx errors and 429
+// responses can be injected to exercise retries and the circuit breaker.
+//
+// Example:
+//
+//	srv := flagrtest.NewServer()
+//	defer srv.Close()
+//	require.NoError(t, srv.LoadFile("testdata/flags.yaml"))
+//
+//	client, _ := vexilla.New(vexilla.WithFlagrEndpoint(srv.URL))
+//
+//	srv.FailWith(http.StatusServiceUnavailable)
+//	// ... assert the client keeps serving cached flags
+//	srv.Recover()
+package flagrtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/evaluator"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+)
+
+// Server is a Flagr-compatible HTTP server backed by an in-memory flag set.
+// Server is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	evaluator *evaluator.LocalEvaluator
+
+	mu       sync.RWMutex
+	flags    map[int64]domain.Flag
+	tags     map[string]int64 // tag value -> ID
+	lastID   int64            // last ID assigned to a created resource
+	latency  time.Duration
+	status   int // injected status code, 0 when healthy
+	failLeft int // remaining injected failures, -1 for unlimited
+	requests map[string]int
+}
+
+// NewServer starts a server serving flags. Call Close when done.
+func NewServer(flags ...domain.Flag) *Server {
+	s := &Server{
+		evaluator: evaluator.New(),
+		flags:     make(map[int64]domain.Flag),
+		tags:      make(map[string]int64),
+		requests:  make(map[string]int),
+	}
+	s.SetFlags(flags)
+
+	mux := http.NewServeMux()
+	s.handle(mux, "GET /api/v1/flags", s.handleFlags)
+	s.handle(mux, "GET /api/v1/flags/{flagID}", s.handleFlag)
+	s.handle(mux, "POST /api/v1/evaluation", s.handleEvaluation)
+	s.handle(mux, "POST /api/v1/evaluation/batch", s.handleBatch)
+	s.handle(mux, "GET /api/v1/health", s.handleHealth)
+	s.registerAdminRoutes(mux)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetFlags replaces the served flags
+func (s *Server) SetFlags(flags []domain.Flag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flags = make(map[int64]domain.Flag, len(flags))
+	for _, f := range flags {
+		s.store(f)
+	}
+}
+
+// AddFlag adds a flag, replacing the flag with the same ID
+func (s *Server) AddFlag(flag domain.Flag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(flag)
+}
+
+// RemoveFlag removes a flag by ID
+func (s *Server) RemoveFlag(flagID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.flags, flagID)
+}
+
+// Flags returns the served flags ordered by ID
+func (s *Server) Flags() []domain.Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make([]domain.Flag, 0, len(s.flags))
+	for _, f := range s.flags {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].ID < flags[j].ID })
+	return flags
+}
+
+// LoadFile replaces the served flags with a JSON or YAML flag file, in
+// Flagr's export format or the simple format accepted by WithFlagFile
+func (s *Server) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read flag file: %w", err)
+	}
+
+	flags, err := flagr.ParseFlagFile(path, data)
+	if err != nil {
+		return err
+	}
+
+	s.SetFlags(flags)
+	return nil
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// FailWith answers every request with statusCode until Recover is called.
+// 429 responses carry a Retry-After header.
+func (s *Server) FailWith(statusCode int) {
+	s.FailNext(-1, statusCode)
+}
+
+// FailNext answers the next n requests with statusCode. A negative n fails
+// every request until Recover is called.
+func (s *Server) FailNext(n int, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = statusCode
+	s.failLeft = n
+}
+
+// Recover removes injected failures and latency
+func (s *Server) Recover() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = 0
+	s.failLeft = 0
+	s.latency = 0
+}
+
+// Requests returns how many requests were received for a route path
+// (for example "/api/v1/evaluation" or "/api/v1/flags/{flagID}"),
+// including failed ones
+func (s *Server) Requests(path string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests[path]
+}
+
+// TotalRequests returns how many requests were received
+func (s *Server) TotalRequests() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := 0
+	for _, n := range s.requests {
+		total += n
+	}
+	return total
+}
+
+// ResetRequests clears the request counters
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = make(map[string]int)
+}
+
+// handle registers a route whose requests are counted and subject to
+// injected latency and failures
+func (s *Server) handle(mux *http.ServeMux, pattern string, h http.HandlerFunc) {
+	_, path, _ := strings.Cut(pattern, " ")
+	mux.Handle(pattern, s.inject(path, h))
+}
+
+// inject counts requests and applies injected latency and failures
+func (s *Server) inject(path string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[path]++
+		latency := s.latency
+		status := 0
+		if s.status != 0 && s.failLeft != 0 {
+			status = s.status
+			if s.failLeft > 0 {
+				s.failLeft--
+			}
+		}
+		s.mu.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			writeError(w, status, http.StatusText(status))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// store saves a flag and registers its IDs and tags. s.mu must be held.
+func (s *Server) store(flag domain.Flag) {
+	s.flags[flag.ID] = flag
+
+	ids := []int64{flag.ID}
+	for _, v := range flag.Variants {
+		ids = append(ids, v.ID)
+	}
+	for _, seg := range flag.Segments {
+		ids = append(ids, seg.ID)
+		for _, c := range seg.Constraints {
+			ids = append(ids, c.ID)
+		}
+		for _, d := range seg.Distributions {
+			ids = append(ids, d.ID)
+		}
+	}
+	for _, id := range ids {
+		if id > s.lastID {
+			s.lastID = id
+		}
+	}
+
+	for _, t := range flag.Tags {
+		s.tagID(t.Value)
+	}
+}
+
+// tagID returns the ID of a tag value, registering it if needed.
+// s.mu must be held for writing.
+func (s *Server) tagID(value string) int64 {
+	if id, ok := s.tags[value]; ok {
+		return id
+	}
+	s.lastID++
+	s.tags[value] = s.lastID
+	return s.lastID
+}
+
+// flagrFlag converts a flag to Flagr's model. s.mu must be held.
+func (s *Server) flagrFlag(f domain.Flag) flagr.FlagrFlag {
+	flag := flagr.FlagFromDomain(f)
+	for i := range flag.Tags {
+		flag.Tags[i].ID = s.tags[flag.Tags[i].Value]
+	}
+	return flag
+}
+
+// handleFlags lists flags (GET /api/v1/flags)
+func (s *Server) handleFlags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	preload := query.Get("preload") == "true"
+
+	var tags []string
+	if raw := query.Get("tags"); raw != "" {
+		tags = strings.Split(raw, ",")
+	}
+
+	flags := s.Flags()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]flagr.FlagrFlag, 0)
+	for _, f := range flags {
+		if enabled := query.Get("enabled"); enabled != "" && strconv.FormatBool(f.Enabled) != enabled {
+			continue
+		}
+		if key := query.Get("key"); key != "" && f.Key != key {
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(f, tags) {
+			continue
+		}
+
+		flag := s.flagrFlag(f)
+		if !preload {
+			flag.Segments = nil
+			flag.Variants = nil
+		}
+		result = append(result, flag)
+	}
+
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset > 0 {
+		if offset > len(result) {
+			offset = len(result)
+		}
+		result = result[offset:]
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit >= 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleFlag returns a flag with its segments and variants
+// (GET /api/v1/flags/{flagID})
+func (s *Server) handleFlag(w http.ResponseWriter, r *http.Request) {
+	flagID, err := strconv.ParseInt(r.PathValue("flagID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid flag ID")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.flags[flagID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("flag %d not found", flagID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.flagrFlag(f))
+}
+
+// handleEvaluation evaluates one flag (POST /api/v1/evaluation)
+func (s *Server) handleEvaluation(w http.ResponseWriter, r *http.Request) {
+	var req flagr.EvaluationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var flag *domain.Flag
+	for _, f := range s.Flags() {
+		if f.Key == req.FlagKey {
+			flag = &f
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.evaluate(flag, req))
+}
+
+// handleBatch evaluates flags selected by ID, key or tag for every entity
+// (POST /api/v1/evaluation/batch)
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req flagr.EvaluationBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	flags := s.Flags()
+	var selected []domain.Flag
+	for _, f := range flags {
+		if batchSelects(req, f) {
+			selected = append(selected, f)
+		}
+	}
+
+	resp := flagr.EvaluationBatchResponse{EvaluationResults: []flagr.EvaluationResponse{}}
+	for _, entity := range req.Entities {
+		for i := range selected {
+			resp.EvaluationResults = append(resp.EvaluationResults, s.evaluate(&selected[i], flagr.EvaluationRequest{
+				FlagKey:       selected[i].Key,
+				EntityID:      entity.EntityID,
+				EntityType:    entity.EntityType,
+				EntityContext: entity.EntityContext,
+				EnableDebug:   req.EnableDebug,
+			}))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleHealth reports the server as healthy (GET /api/v1/health)
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, flagr.HealthResponse{Status: "OK"})
+}
+
+// evaluate mirrors Flagr: unknown and disabled flags, entities that match
+// no segment, and entities left out by the rollout of the first segment
+// they match get a result without a variant
+func (s *Server) evaluate(flag *domain.Flag, req flagr.EvaluationRequest) flagr.EvaluationResponse {
+	evalCtx := domain.EvaluationContext{
+		EntityID:   req.EntityID,
+		EntityType: req.EntityType,
+		Context:    req.EntityContext,
+	}
+
+	resp := flagr.EvaluationResponse{
+		FlagKey:     req.FlagKey,
+		Timestamp:   time.Now().UTC(),
+		EvalContext: &req,
+	}
+
+	switch {
+	case flag == nil:
+		resp.EvalDebugLog.Msg = fmt.Sprintf("flagKey %s not found or deleted", req.FlagKey)
+		return resp
+	case !flag.Enabled:
+		resp.FlagID = flag.ID
+		resp.EvalDebugLog.Msg = fmt.Sprintf("flagID %d is not enabled", flag.ID)
+		return resp
+	}
+
+	resp.FlagID = flag.ID
+
+	segment, variant, err := s.evaluator.MatchRollout(*flag, evalCtx)
+	if err != nil {
+		resp.EvalDebugLog.Msg = err.Error()
+		return resp
+	}
+	if segment != nil {
+		resp.SegmentID = segment.ID
+	}
+	if variant != nil {
+		resp.VariantID = variant.ID
+		resp.VariantKey = variant.Key
+		resp.VariantAttachment = variant.Attachment
+	}
+
+	if req.EnableDebug {
+		resp.EvalDebugLog.SegmentDebugLogs = s.segmentLogs(*flag, evalCtx, resp.SegmentID, variant != nil)
+	}
+
+	return resp
+}
+
+// segmentLogs describes every segment up to the selected one, the way
+// Flagr's debug log does; inRollout tells whether the selected segment's
+// rollout includes the entity
+func (s *Server) segmentLogs(flag domain.Flag, evalCtx domain.EvaluationContext, selectedID int64, inRollout bool) []flagr.SegmentDebugLog {
+	trace, _ := s.evaluator.Explain(context.Background(), flag, evalCtx)
+
+	var logs []flagr.SegmentDebugLog
+	for _, seg := range trace.Segments {
+		msg := "constraints not match"
+		switch {
+		case seg.SegmentID == selectedID && inRollout:
+			msg = "matched all constraints. rollout yes."
+		case seg.SegmentID == selectedID:
+			msg = "matched all constraints. rollout no."
+		}
+		logs = append(logs, flagr.SegmentDebugLog{SegmentID: seg.SegmentID, Msg: msg})
+
+		if seg.SegmentID == selectedID {
+			break
+		}
+	}
+	return logs
+}
+
+// batchSelects reports whether a batch request targets flag
+func batchSelects(req flagr.EvaluationBatchRequest, flag domain.Flag) bool {
+	for _, id := range req.FlagIDs {
+		if id == flag.ID {
+			return true
+		}
+	}
+	for _, key := range req.FlagKeys {
+		if key == flag.Key {
+			return true
+		}
+	}
+	if len(req.FlagTags) == 0 {
+		return false
+	}
+	if strings.EqualFold(req.FlagTagsOperator, "ALL") {
+		for _, tag := range req.FlagTags {
+			if !hasAnyTag(flag, []string{tag}) {
+				return false
+			}
+		}
+		return true
+	}
+	return hasAnyTag(flag, req.FlagTags)
+}
+
+// hasAnyTag reports whether flag has at least one of tags
+func hasAnyTag(flag domain.Flag, tags []string) bool {
+	for _, t := range flag.Tags {
+		for _, tag := range tags {
+			if t.Value == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error body shaped like Flagr's
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
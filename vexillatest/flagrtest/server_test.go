@@ -0,0 +1,266 @@
+package flagrtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFlags() []domain.Flag {
+	return []domain.Flag{
+		{
+			ID:      1,
+			Key:     "br-launch",
+			Enabled: true,
+			Tags:    []domain.Tag{{Value: "checkout"}},
+			Variants: []domain.Variant{
+				{ID: 1, Key: "on", Attachment: map[string]json.RawMessage{"enabled": json.RawMessage("true")}},
+			},
+			Segments: []domain.Segment{
+				{
+					ID:             10,
+					Rank:           1,
+					RolloutPercent: 100,
+					Constraints:    []domain.Constraint{{Property: "country", Operator: domain.OperatorEQ, Value: "BR"}},
+					Distributions:  []domain.Distribution{{VariantID: 1, Percent: 100}},
+				},
+			},
+		},
+		{
+			ID:       2,
+			Key:      "retired",
+			Enabled:  false,
+			Tags:     []domain.Tag{{Value: "legacy"}},
+			Variants: []domain.Variant{{ID: 1, Key: "on"}},
+		},
+	}
+}
+
+func newClient(srv *Server) *flagr.HTTPClient {
+	return flagr.NewHTTPClient(flagr.Config{Endpoint: srv.URL, Timeout: time.Second})
+}
+
+func TestServer_Flags(t *testing.T) {
+	srv := NewServer(testFlags()...)
+	defer srv.Close()
+
+	flags, err := newClient(srv).GetAllFlags(context.Background())
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "br-launch", flags[0].Key)
+	require.Len(t, flags[0].Segments, 1)
+	assert.Equal(t, "country", flags[0].Segments[0].Constraints[0].Property)
+
+	var listed []flagr.FlagrFlag
+	getJSON(t, srv.URL+"/api/v1/flags?tags=legacy", &listed)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "retired", listed[0].Key)
+
+	getJSON(t, srv.URL+"/api/v1/flags?enabled=true&preload=true", &listed)
+	require.Len(t, listed, 1)
+	assert.NotEmpty(t, listed[0].Segments)
+
+	getJSON(t, srv.URL+"/api/v1/flags", &listed)
+	assert.Empty(t, listed[0].Segments)
+
+	resp, err := http.Get(srv.URL + "/api/v1/flags/99")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.Equal(t, 3, srv.Requests("/api/v1/flags/{flagID}"), "requests are grouped by route")
+}
+
+func TestServer_Evaluation(t *testing.T) {
+	srv := NewServer(testFlags()...)
+	defer srv.Close()
+	client := newClient(srv)
+	ctx := context.Background()
+
+	result, err := client.EvaluateFlag(ctx, "br-launch", domain.NewEvaluationContext("u1").WithAttribute("country", "BR"))
+	require.NoError(t, err)
+	assert.Equal(t, "on", result.VariantKey)
+	assert.Equal(t, int64(10), result.SegmentID)
+	assert.True(t, result.IsEnabled())
+
+	// Like Flagr, non-matching entities, disabled and unknown flags get no variant
+	result, err = client.EvaluateFlag(ctx, "br-launch", domain.NewEvaluationContext("u1").WithAttribute("country", "US"))
+	require.NoError(t, err)
+	assert.Empty(t, result.VariantKey)
+
+	result, err = client.EvaluateFlag(ctx, "retired", domain.NewEvaluationContext("u1"))
+	require.NoError(t, err)
+	assert.Empty(t, result.VariantKey)
+	assert.Contains(t, result.EvaluationReason, "not enabled")
+
+	result, err = client.EvaluateFlag(ctx, "missing", domain.NewEvaluationContext("u1"))
+	require.NoError(t, err)
+	assert.Contains(t, result.EvaluationReason, "not found")
+
+	trace, err := client.ExplainFlag(ctx, "br-launch", domain.NewEvaluationContext("u1").WithAttribute("country", "BR"))
+	require.NoError(t, err)
+	require.Len(t, trace.Segments, 1)
+	assert.True(t, trace.Segments[0].Selected)
+}
+
+func TestServer_Evaluation_PartialRollout(t *testing.T) {
+	srv := NewServer(domain.Flag{
+		ID:       3,
+		Key:      "gradual",
+		Enabled:  true,
+		Variants: []domain.Variant{{ID: 1, Key: "new"}, {ID: 2, Key: "old"}},
+		Segments: []domain.Segment{
+			{ID: 20, Rank: 1, RolloutPercent: 20, Distributions: []domain.Distribution{{VariantID: 1, Percent: 100}}},
+			{ID: 21, Rank: 2, RolloutPercent: 100, Distributions: []domain.Distribution{{VariantID: 2, Percent: 100}}},
+		},
+	})
+	defer srv.Close()
+	client := newClient(srv)
+	ctx := context.Background()
+
+	// Like Flagr, evaluation stops at the 20% segment: the entities it
+	// leaves out get no variant, not the catch-all's
+	variants := map[string]int{}
+	for i := 0; i < 500; i++ {
+		result, err := client.EvaluateFlag(ctx, "gradual", domain.NewEvaluationContext(fmt.Sprintf("u%d", i)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), result.SegmentID)
+		variants[result.VariantKey]++
+	}
+	assert.Zero(t, variants["old"])
+	assert.InDelta(t, 100, variants["new"], 40)
+	assert.InDelta(t, 400, variants[""], 40)
+
+	// The debug log reports the entity out of the rollout
+	for i := 0; ; i++ {
+		evalCtx := domain.NewEvaluationContext(fmt.Sprintf("u%d", i))
+		trace, err := client.ExplainFlag(ctx, "gradual", evalCtx)
+		require.NoError(t, err)
+		if trace.Result.VariantKey != "" {
+			continue
+		}
+		require.Len(t, trace.Segments, 1)
+		assert.Equal(t, "matched all constraints. rollout no.", trace.Segments[0].Message)
+		break
+	}
+}
+
+func TestServer_BatchEvaluation(t *testing.T) {
+	srv := NewServer(testFlags()...)
+	defer srv.Close()
+
+	req := flagr.EvaluationBatchRequest{
+		Entities: []flagr.EvaluationEntity{
+			{EntityID: "u1", EntityContext: map[string]interface{}{"country": "BR"}},
+			{EntityID: "u2", EntityContext: map[string]interface{}{"country": "US"}},
+		},
+		FlagTags: []string{"checkout", "legacy"},
+	}
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	resp, err := http.Post(srv.URL+"/api/v1/evaluation/batch", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var batch flagr.EvaluationBatchResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&batch))
+	require.Len(t, batch.EvaluationResults, 4)
+
+	first := batch.EvaluationResults[0]
+	assert.Equal(t, "br-launch", first.FlagKey)
+	assert.Equal(t, "u1", first.EvalContext.EntityID)
+	assert.Equal(t, "on", first.VariantKey)
+	assert.Empty(t, batch.EvaluationResults[2].VariantKey, "u2 does not match")
+}
+
+func TestServer_FaultInjection(t *testing.T) {
+	srv := NewServer(testFlags()...)
+	defer srv.Close()
+	client := newClient(srv)
+	ctx := context.Background()
+
+	srv.FailWith(http.StatusServiceUnavailable)
+	err := client.HealthCheck(ctx)
+	var httpErr *flagr.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+	_, err = client.GetAllFlags(ctx)
+	assert.Error(t, err)
+
+	srv.Recover()
+	require.NoError(t, client.HealthCheck(ctx))
+
+	srv.FailNext(1, http.StatusTooManyRequests)
+	resp, err := http.Get(srv.URL + "/api/v1/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+	require.NoError(t, client.HealthCheck(ctx), "only the next request fails")
+
+	// Retries get through a transient failure
+	srv.FailNext(1, http.StatusBadGateway)
+	retrying := flagr.NewHTTPClient(flagr.Config{Endpoint: srv.URL, Timeout: time.Second, MaxRetries: 1})
+	require.NoError(t, retrying.HealthCheck(ctx))
+
+	srv.SetLatency(200 * time.Millisecond)
+	slow := flagr.NewHTTPClient(flagr.Config{Endpoint: srv.URL, Timeout: 50 * time.Millisecond})
+	assert.Error(t, slow.HealthCheck(ctx))
+
+	srv.Recover()
+	require.NoError(t, slow.HealthCheck(ctx))
+
+	assert.Positive(t, srv.TotalRequests())
+	srv.ResetRequests()
+	assert.Zero(t, srv.TotalRequests())
+}
+
+func TestServer_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+flags:
+  dark-mode:
+    variants:
+      enabled: {enabled: true}
+    segments:
+      - variant: enabled
+`), 0o644))
+
+	srv := NewServer()
+	defer srv.Close()
+	require.NoError(t, srv.LoadFile(path))
+
+	result, err := newClient(srv).EvaluateFlag(context.Background(), "dark-mode", domain.NewEvaluationContext("u1"))
+	require.NoError(t, err)
+	assert.True(t, result.IsEnabled())
+
+	srv.RemoveFlag(1)
+	assert.Empty(t, srv.Flags())
+
+	assert.Error(t, srv.LoadFile(filepath.Join(t.TempDir(), "missing.yaml")))
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
@@ -0,0 +1,246 @@
+// Package vexillatest provides an in-memory vexilla client for unit tests.
+//
+// The client needs no Flagr server and no network access. Flags are built
+// with Flag, BoolFlag, StringFlag and IntFlag, evaluated exactly like
+// cached Flagr flags, and can be forced per test with Set helpers. Every
+// evaluation is recorded so tests can assert on which flags were read.
+//
+// Each client is independent, so tests using their own client are safe
+// under t.Parallel().
+//
+// Example:
+//
+//	func TestCheckout(t *testing.T) {
+//	    t.Parallel()
+//
+//	    flags := vexillatest.NewClient(
+//	        vexillatest.BoolFlag("new-checkout", false),
+//	    )
+//	    flags.SetBool("new-checkout", true)
+//
+//	    svc := checkout.NewService(flags.Client)
+//	    svc.Run(ctx, "user-123")
+//
+//	    assert.Equal(t, 1, flags.CallCount("new-checkout"))
+//	}
+package vexillatest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/OrlandoBitencourt/vexilla"
+	"github.com/OrlandoBitencourt/vexilla/internal/clienthooks"
+	"github.com/OrlandoBitencourt/vexilla/internal/domain"
+	"github.com/OrlandoBitencourt/vexilla/internal/flagr"
+	"github.com/OrlandoBitencourt/vexilla/internal/storage"
+)
+
+// Call is a recorded flag evaluation.
+type Call struct {
+	FlagKey string
+	Context vexilla.Context
+
+	// Result is nil when the evaluation failed
+	Result *vexilla.Result
+	Err    error
+}
+
+// Client is an in-memory vexilla client.
+// Pass the embedded *vexilla.Client to the code under test.
+type Client struct {
+	*vexilla.Client
+
+	source *flagr.StaticSource
+
+	mu     sync.Mutex
+	nextID int64
+	flags  map[string]domain.Flag
+	calls  []Call
+}
+
+// NewClient creates a client serving the given flags.
+// It panics if a flag is invalid (for example a segment without a variant).
+func NewClient(flags ...*FlagBuilder) *Client {
+	c := &Client{
+		source: flagr.NewStaticSource(),
+		flags:  make(map[string]domain.Flag),
+	}
+
+	client, err := vexilla.New(
+		clienthooks.WithFlagrClient(c.source).(vexilla.Option),
+		clienthooks.WithStorage(storage.NewMockStorage()).(vexilla.Option),
+		vexilla.WithEvaluationHook(c.record),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("vexillatest: %v", err))
+	}
+	c.Client = client
+
+	c.AddFlags(flags...)
+
+	return c
+}
+
+// AddFlags adds flags, replacing flags with the same key.
+// It panics if a flag is invalid.
+func (c *Client) AddFlags(flags ...*FlagBuilder) {
+	c.mu.Lock()
+	for _, b := range flags {
+		id := c.nextID + 1
+		if existing, ok := c.flags[b.key]; ok {
+			id = existing.ID
+		}
+
+		flag, err := b.build(id)
+		if err != nil {
+			c.mu.Unlock()
+			panic(fmt.Sprintf("vexillatest: %v", err))
+		}
+		if id > c.nextID {
+			c.nextID = id
+		}
+
+		c.flags[flag.Key] = flag
+		c.source.SetFlag(flag)
+	}
+	c.mu.Unlock()
+
+	if err := c.Client.Sync(context.Background()); err != nil {
+		panic(fmt.Sprintf("vexillatest: %v", err))
+	}
+}
+
+// Set forces variantKey for every entity. The variant's attachment is
+// served when the flag declares the variant.
+func (c *Client) Set(flagKey, variantKey string) {
+	c.set(vexilla.Override{FlagKey: flagKey, VariantKey: variantKey, Attachment: c.attachmentOf(flagKey, variantKey)})
+}
+
+// SetFor forces variantKey for a single entity.
+func (c *Client) SetFor(flagKey, entityID, variantKey string) {
+	c.set(vexilla.Override{
+		FlagKey:    flagKey,
+		EntityID:   entityID,
+		VariantKey: variantKey,
+		Attachment: c.attachmentOf(flagKey, variantKey),
+	})
+}
+
+// SetBool forces Bool to return value for every entity.
+func (c *Client) SetBool(flagKey string, value bool) {
+	variant := "disabled"
+	if value {
+		variant = "enabled"
+	}
+	c.set(vexilla.Override{FlagKey: flagKey, VariantKey: variant, Attachment: map[string]any{"enabled": value}})
+}
+
+// SetString forces String to return value for every entity.
+func (c *Client) SetString(flagKey, value string) {
+	c.set(vexilla.Override{FlagKey: flagKey, VariantKey: value, Attachment: map[string]any{"value": value}})
+}
+
+// SetInt forces Int to return value for every entity.
+func (c *Client) SetInt(flagKey string, value int) {
+	c.set(vexilla.Override{FlagKey: flagKey, VariantKey: strconv.Itoa(value), Attachment: map[string]any{"value": value}})
+}
+
+// Unset removes every value forced for the flag.
+func (c *Client) Unset(flagKey string) {
+	for _, o := range c.Overrides() {
+		if o.FlagKey == flagKey {
+			c.RemoveOverride(o)
+		}
+	}
+}
+
+// Calls returns every recorded evaluation in order.
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	calls := make([]Call, len(c.calls))
+	copy(calls, c.calls)
+	return calls
+}
+
+// CallsFor returns the recorded evaluations of a flag.
+func (c *Client) CallsFor(flagKey string) []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var calls []Call
+	for _, call := range c.calls {
+		if call.FlagKey == flagKey {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// CallCount returns how many times a flag was evaluated.
+func (c *Client) CallCount(flagKey string) int {
+	return len(c.CallsFor(flagKey))
+}
+
+// Evaluated reports whether a flag was evaluated at least once.
+func (c *Client) Evaluated(flagKey string) bool {
+	return c.CallCount(flagKey) > 0
+}
+
+// Reset clears the recorded calls and every forced value.
+func (c *Client) Reset() {
+	c.ClearOverrides()
+
+	c.mu.Lock()
+	c.calls = nil
+	c.mu.Unlock()
+}
+
+func (c *Client) set(o vexilla.Override) {
+	if err := c.SetOverride(o); err != nil {
+		panic(fmt.Sprintf("vexillatest: %v", err))
+	}
+}
+
+// attachmentOf returns the attachment of a declared variant
+func (c *Client) attachmentOf(flagKey, variantKey string) map[string]any {
+	c.mu.Lock()
+	flag, ok := c.flags[flagKey]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	for _, v := range flag.Variants {
+		if v.Key != variantKey || len(v.Attachment) == 0 {
+			continue
+		}
+		values := make(map[string]any, len(v.Attachment))
+		for k, raw := range v.Attachment {
+			var val any
+			if err := json.Unmarshal(raw, &val); err == nil {
+				values[k] = val
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+func (c *Client) record(flagKey string, evalCtx vexilla.Context, result *vexilla.Result, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, Call{
+		FlagKey: flagKey,
+		Context: evalCtx,
+		Result:  result,
+		Err:     err,
+	})
+}
@@ -0,0 +1,206 @@
+package vexillatest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/OrlandoBitencourt/vexilla"
+	"github.com/OrlandoBitencourt/vexilla/vexillatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient_TypedFlags(t *testing.T) {
+	t.Parallel()
+
+	flags := vexillatest.NewClient(
+		vexillatest.BoolFlag("dark-mode", true),
+		vexillatest.StringFlag("theme", "ocean"),
+		vexillatest.IntFlag("max-items", 25),
+	)
+	ctx := context.Background()
+	evalCtx := vexilla.NewContext("user-1")
+
+	assert.True(t, flags.Bool(ctx, "dark-mode", evalCtx))
+	assert.Equal(t, "ocean", flags.String(ctx, "theme", evalCtx, "default"))
+	assert.Equal(t, 25, flags.Int(ctx, "max-items", evalCtx, 0))
+
+	// Unknown flags fall back like a real client
+	assert.False(t, flags.Bool(ctx, "unknown", evalCtx))
+	assert.Equal(t, 10, flags.Int(ctx, "unknown", evalCtx, 10))
+}
+
+func TestNewClient_Segments(t *testing.T) {
+	t.Parallel()
+
+	flags := vexillatest.NewClient(
+		vexillatest.Flag("new-checkout").
+			Variant(vexillatest.Variant("on").With("enabled", true)).
+			Variant(vexillatest.Variant("off").With("enabled", false)).
+			Segment(vexillatest.Segment().Where("country", "EQ", "BR").Serve("on")).
+			Default("off"),
+		vexillatest.Flag("ab-test").
+			Segment(vexillatest.Segment().Split("a", 50).Split("b", 50)),
+		vexillatest.BoolFlag("retired", true).Disabled(),
+	)
+	ctx := context.Background()
+
+	assert.True(t, flags.Bool(ctx, "new-checkout", vexilla.NewContext("u1").WithAttribute("country", "BR")))
+	assert.False(t, flags.Bool(ctx, "new-checkout", vexilla.NewContext("u1").WithAttribute("country", "US")))
+	assert.False(t, flags.Bool(ctx, "retired", vexilla.NewContext("u1")))
+
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		evalCtx := vexilla.NewContext(fmt.Sprintf("user-%d", i))
+		variant := flags.String(ctx, "ab-test", evalCtx, "")
+		assert.Equal(t, variant, flags.String(ctx, "ab-test", evalCtx, ""), "assignment must be sticky")
+		seen[variant] = true
+	}
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, seen)
+}
+
+func TestClient_Set(t *testing.T) {
+	t.Parallel()
+
+	flags := vexillatest.NewClient(
+		vexillatest.Flag("banner").
+			Variant(vexillatest.Variant("blue").With("value", "blue")).
+			Variant(vexillatest.Variant("red").With("value", "red")).
+			Default("blue"),
+	)
+	ctx := context.Background()
+	evalCtx := vexilla.NewContext("user-1")
+
+	assert.Equal(t, "blue", flags.String(ctx, "banner", evalCtx, ""))
+
+	flags.Set("banner", "red")
+	assert.Equal(t, "red", flags.String(ctx, "banner", evalCtx, ""))
+
+	flags.SetFor("banner", "vip", "blue")
+	assert.Equal(t, "blue", flags.String(ctx, "banner", vexilla.NewContext("vip"), ""))
+	assert.Equal(t, "red", flags.String(ctx, "banner", evalCtx, ""))
+
+	flags.SetBool("brand-new", true)
+	assert.True(t, flags.Bool(ctx, "brand-new", evalCtx))
+	flags.SetBool("brand-new", false)
+	assert.False(t, flags.Bool(ctx, "brand-new", evalCtx))
+
+	flags.SetString("greeting", "hi")
+	assert.Equal(t, "hi", flags.String(ctx, "greeting", evalCtx, ""))
+
+	flags.SetInt("limit", 7)
+	assert.Equal(t, 7, flags.Int(ctx, "limit", evalCtx, 0))
+
+	flags.Unset("banner")
+	assert.Equal(t, "blue", flags.String(ctx, "banner", evalCtx, ""))
+
+	flags.Reset()
+	assert.False(t, flags.Bool(ctx, "brand-new", evalCtx))
+	assert.Empty(t, flags.Overrides())
+}
+
+func TestClient_AddFlags(t *testing.T) {
+	t.Parallel()
+
+	flags := vexillatest.NewClient(vexillatest.BoolFlag("feature", false))
+	ctx := context.Background()
+
+	assert.False(t, flags.Bool(ctx, "feature", vexilla.NewContext("u1")))
+
+	flags.AddFlags(vexillatest.BoolFlag("feature", true), vexillatest.StringFlag("other", "x"))
+	assert.True(t, flags.Bool(ctx, "feature", vexilla.NewContext("u1")))
+	assert.Equal(t, "x", flags.String(ctx, "other", vexilla.NewContext("u1"), ""))
+}
+
+func TestClient_Calls(t *testing.T) {
+	t.Parallel()
+
+	flags := vexillatest.NewClient(vexillatest.BoolFlag("feature", true))
+	ctx := context.Background()
+
+	assert.False(t, flags.Evaluated("feature"))
+
+	flags.Bool(ctx, "feature", vexilla.NewContext("u1").WithAttribute("plan", "pro"))
+	flags.Bool(ctx, "feature", vexilla.NewContext("u2"))
+	_, err := flags.Evaluate(ctx, "missing", vexilla.NewContext("u3"))
+	require.NoError(t, err) // fail_closed fallback
+
+	assert.True(t, flags.Evaluated("feature"))
+	assert.Equal(t, 2, flags.CallCount("feature"))
+
+	calls := flags.CallsFor("feature")
+	require.Len(t, calls, 2)
+	assert.Equal(t, "u1", calls[0].Context.EntityID)
+	assert.Equal(t, "pro", calls[0].Context.Attributes["plan"])
+	assert.Equal(t, "enabled", calls[0].Result.VariantKey)
+	assert.NoError(t, calls[0].Err)
+
+	assert.Len(t, flags.Calls(), 3)
+
+	flags.Reset()
+	assert.Empty(t, flags.Calls())
+}
+
+func TestClient_ConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	flags := vexillatest.NewClient(vexillatest.BoolFlag("feature", true))
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 50; j++ {
+				flags.Bool(ctx, "feature", vexilla.NewContext(fmt.Sprintf("u%d", i)))
+				if j%10 == 0 {
+					flags.SetBool("other", j%20 == 0)
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+
+	assert.Equal(t, 400, flags.CallCount("feature"))
+}
+
+func TestNewClient_InvalidFlag(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() {
+		vexillatest.NewClient(vexillatest.Flag("broken").Segment(vexillatest.Segment()))
+	})
+	assert.Panics(t, func() {
+		vexillatest.NewClient(vexillatest.Flag(""))
+	})
+}
+
+func TestNewFlagrServer(t *testing.T) {
+	t.Parallel()
+
+	srv := vexillatest.NewFlagrServer(
+		vexillatest.BoolFlag("dark-mode", true),
+		vexillatest.Flag("pricing").
+			Segment(vexillatest.Segment().Where("country", "EQ", "BR").Serve("premium")),
+	)
+	defer srv.Close()
+
+	client, err := vexilla.New(vexilla.WithFlagrEndpoint(srv.URL), vexilla.WithFlagrMaxRetries(0))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop()
+
+	assert.True(t, client.Bool(ctx, "dark-mode", vexilla.NewContext("u1")))
+
+	result, err := client.Evaluate(ctx, "pricing", vexilla.NewContext("u1").WithAttribute("country", "BR"))
+	require.NoError(t, err)
+	assert.Equal(t, "premium", result.VariantKey)
+
+	assert.Panics(t, func() {
+		vexillatest.NewFlagrServer(vexillatest.Flag("broken").Segment(vexillatest.Segment()))
+	})
+}